@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
@@ -12,12 +13,18 @@ import (
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	"io"
 	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/api/alerts"
+	"keycloak-events-adapter/internal/api/gateway"
 	grpc_server "keycloak-events-adapter/internal/api/grpc"
+	"keycloak-events-adapter/internal/api/webhook"
+	"keycloak-events-adapter/internal/metrics"
 	eventv1 "keycloak-events-adapter/internal/specs/gen/keycloak/event/v1"
 	"keycloak-events-adapter/internal/tarantool"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -95,10 +102,21 @@ func main() {
 		logger.Fatal("queue doesn't exist", zap.String("queue_name", tarantool.AdminEventsQueueName))
 	}
 
-	adminEventNotify := internal.NewDummy[internal.AdminEvent](logger)
-	eventNotify := internal.NewDummy[internal.Event](logger)
-	adminEventStorage := tarantool.NewEvent[internal.AdminEvent](tntAdminQueue, adminEventNotify, logger)
-	eventStorage := tarantool.NewEvent[internal.Event](tntQueue, eventNotify, logger)
+	pipes, err := newPipelines(&cfg, tntConn, logger)
+	if err != nil {
+		logger.Fatal("can't create pipelines", zap.Error(err))
+	}
+	defer pipes.Close()
+
+	adminEventStorage, adminEventCloser, err := newKeeper[internal.AdminEvent](&cfg, tntAdminQueue, tarantool.AdminEventsQueueName, pipes, logger)
+	if err != nil {
+		logger.Fatal("can't create sink", zap.Error(err))
+	}
+	eventStorage, eventCloser, err := newKeeper[internal.Event](&cfg, tntQueue, tarantool.EventsQueueName, pipes, logger)
+	if err != nil {
+		logger.Fatal("can't create sink", zap.Error(err))
+	}
+	pipes.setEventKeeper(eventStorage)
 	eventService := internal.NewEventService(adminEventStorage, eventStorage)
 
 	wg := sync.WaitGroup{}
@@ -111,13 +129,82 @@ func main() {
 		}
 	}()
 
+	if cfg.MetricsListen != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errN := startHTTPServer(ctx, "metrics", cfg.MetricsListen, mux, logger)
+			if errN != nil {
+				logger.Error("can't start metrics server or server return error while working", zap.Error(errN))
+			}
+		}()
+	}
+
+	if cfg.GatewayListen != "" {
+		handler := gateway.NewServer(eventService, logger).Handler()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errN := startHTTPServer(ctx, "gateway", cfg.GatewayListen, handler, logger)
+			if errN != nil {
+				logger.Error("can't start gateway server or server return error while working", zap.Error(errN))
+			}
+		}()
+	}
+
+	if cfg.WebhookListen != "" {
+		handler := webhook.NewServer(eventService, cfg.WebhookToken, logger).Handler()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errN := startHTTPServer(ctx, "webhook", cfg.WebhookListen, handler, logger)
+			if errN != nil {
+				logger.Error("can't start webhook server or server return error while working", zap.Error(errN))
+			}
+		}()
+	}
+
+	if cfg.Alerts.Listen != "" {
+		if pipes.alerter == nil {
+			logger.Fatal("alerts API requires detectors in pipelines")
+		}
+		handler := alerts.NewServer(pipes.alerter, cfg.Alerts.Token, logger).Handler()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errN := startHTTPServer(ctx, "alerts", cfg.Alerts.Listen, handler, logger)
+			if errN != nil {
+				logger.Error("can't start alerts server or server return error while working", zap.Error(errN))
+			}
+		}()
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		eventService.Read(ctx, 5)
 	}()
 
+	if pipes.alertKeeper != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pipes.alertKeeper.Process(ctx)
+		}()
+	}
+
 	wg.Wait()
+	for _, closer := range []io.Closer{eventCloser, adminEventCloser} {
+		if errC := closer.Close(); errC != nil {
+			logger.Error("can't close sink", zap.Error(errC))
+		}
+	}
 	logger.Info("Application has been shutdown gracefully")
 }
 
@@ -168,6 +255,31 @@ func startGRPCServer(
 	return s.Serve(lis)
 }
 
+// startHTTPServer запускает HTTP сервер, который останавливается при отмене ctx
+func startHTTPServer(ctx context.Context, name, listen string, handler http.Handler, logger *zap.Logger) error {
+	logger.Info("HTTP server started", zap.String("server", name), zap.String("listen", listen))
+
+	s := &http.Server{
+		Addr:              listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.Shutdown(shutdownCtx)
+	}()
+
+	err := s.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
 // initLogger создает и настраивает новый экземпляр логгера
 func initLogger(logLevel string, isLogJSON bool) (*zap.Logger, error) {
 	lvl := zap.InfoLevel
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	tnt "github.com/tarantool/go-tarantool"
+	tntqueue "github.com/tarantool/go-tarantool/queue"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/detect"
+	"keycloak-events-adapter/internal/enrich/geoip"
+	"keycloak-events-adapter/internal/pipeline"
+	"keycloak-events-adapter/internal/tarantool"
+)
+
+// alertEventsSink значение ALERTS_SINK, при котором тревоги доставляются событиями пользователей
+const alertEventsSink = "events"
+
+// eventsKeeper передает тревоги обработчику очереди, который создается после конвейеров
+type eventsKeeper struct {
+	internal.EventKeeper[internal.Event]
+}
+
+// pipelines конфигурация конвейеров обработки и общие ресурсы их обработчиков
+type pipelines struct {
+	cfg  *pipeline.Config
+	deps pipeline.Deps
+	geo  *geoip.DB
+
+	// alerter настроен, если в конвейерах есть детекторы. Тревоги доставляются через events
+	// или, если задан отдельный приемник, обработчиком очереди тревог alertKeeper.
+	alerter     *detect.Alerter
+	events      *eventsKeeper
+	alertKeeper internal.EventKeeper[internal.Event]
+	alertCloser io.Closer
+}
+
+// newPipelines читает PIPELINE_FILE. Без файла конвейер приема состоит из обогащения геолокацией,
+// если настроена база GeoIP, а конвейер доставки пуст. Детекторам нужны окна и тревоги в Tarantool,
+// а отдельному приемнику тревог ALERTS_SINK — очередь тревог.
+func newPipelines(cfg *Config, conn *tnt.Connection, logger *zap.Logger) (*pipelines, error) {
+	p := &pipelines{cfg: &pipeline.Config{}}
+
+	if cfg.GeoIP.CityFile != "" || cfg.GeoIP.ASNFile != "" {
+		geo, err := geoip.New(geoip.Options{
+			CityFile:       cfg.GeoIP.CityFile,
+			ASNFile:        cfg.GeoIP.ASNFile,
+			ReloadInterval: cfg.GeoIP.ReloadInterval,
+		}, logger)
+		if err != nil {
+			return nil, err
+		}
+		p.geo = geo
+		p.deps.Geo = geo
+		p.cfg.Ingest = []pipeline.StepConfig{{Type: pipeline.TypeGeoIP}}
+	}
+
+	if cfg.PipelineFile != "" {
+		pcfg, err := pipeline.LoadConfig(cfg.PipelineFile)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.cfg = pcfg
+	}
+
+	if p.cfg.HasDetectors() {
+		err := p.initAlerts(cfg, conn, logger)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("alerts: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// initAlerts настраивает хранилища детекторов и доставку тревог. По умолчанию тревоги помещаются
+// в обработчик событий пользователей и проходят конвейеры приема и доставки, с отдельным приемником —
+// проходят конвейер приема событий пользователей и помещаются в очередь тревог, из которой отправляются в ALERTS_SINK.
+func (p *pipelines) initAlerts(cfg *Config, conn *tnt.Connection, logger *zap.Logger) error {
+	p.deps.Windows = tarantool.NewWindows(conn)
+	p.deps.History = tarantool.NewHistory(conn)
+
+	if cfg.Alerts.Sink == alertEventsSink {
+		p.events = &eventsKeeper{}
+		p.alerter = detect.NewAlerter(tarantool.NewAlerts(conn), p.events, logger)
+		p.deps.Alerts = p.alerter
+
+		return nil
+	}
+
+	q := tntqueue.New(conn, tarantool.AlertsQueueName)
+	ok, err := q.Exists()
+	if err != nil {
+		return fmt.Errorf("check queue existence: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("queue %s doesn't exist", tarantool.AlertsQueueName)
+	}
+
+	alertCfg := *cfg
+	alertCfg.Sink = cfg.Alerts.Sink
+	sender, closer, err := newSender[internal.Event](&alertCfg, tarantool.AlertsQueueName, logger)
+	if err != nil {
+		return err
+	}
+
+	p.alertCloser = closer
+	queueKeeper := tarantool.NewEvent[internal.Event](q, sender, newBreaker(&alertCfg, tarantool.AlertsQueueName, logger), logger)
+
+	// конвейеру приема нужен alerter для политики, поэтому тревоги передаются ему после сборки конвейера
+	keeper := &eventsKeeper{}
+	p.alerter = detect.NewAlerter(tarantool.NewAlerts(conn), keeper, logger)
+	p.deps.Alerts = p.alerter
+
+	keeper.EventKeeper, err = wrapKeeper[internal.Event](queueKeeper, p, logger)
+	if err != nil {
+		return err
+	}
+	p.alertKeeper = keeper
+
+	return nil
+}
+
+// setEventKeeper передает тревогам обработчик событий пользователей, вызывается до приема событий
+func (p *pipelines) setEventKeeper(keeper internal.EventKeeper[internal.Event]) {
+	if p.events != nil {
+		p.events.EventKeeper = keeper
+	}
+}
+
+func (p *pipelines) Close() {
+	if p.geo != nil {
+		_ = p.geo.Close()
+	}
+	if p.alertCloser != nil {
+		_ = p.alertCloser.Close()
+	}
+}
+
+// wrapKeeper добавляет конвейер приема перед помещением событий в очередь
+func wrapKeeper[T internal.Event | internal.AdminEvent](
+	keeper internal.EventKeeper[T],
+	pipes *pipelines,
+	logger *zap.Logger,
+) (internal.EventKeeper[T], error) {
+	p, err := pipeline.Build[T](pipeline.StageIngest, pipes.cfg.Ingest, pipes.deps, logger)
+	if err != nil {
+		return nil, err
+	}
+	if p.Len() == 0 {
+		return keeper, nil
+	}
+
+	return pipeline.NewKeeper[T](keeper, p), nil
+}
+
+// wrapSender добавляет конвейер доставки перед отправкой в приемник, сохраняя поддержку пачек
+func wrapSender[T internal.Event | internal.AdminEvent](
+	sender internal.EventSender[T],
+	pipes *pipelines,
+	logger *zap.Logger,
+) (internal.EventSender[T], error) {
+	p, err := pipeline.Build[T](pipeline.StageDelivery, pipes.cfg.Delivery, pipes.deps, logger)
+	if err != nil {
+		return nil, err
+	}
+	if p.Len() == 0 {
+		return sender, nil
+	}
+
+	if batchSender, ok := sender.(interface {
+		internal.EventSender[T]
+		internal.BatchSender[T]
+	}); ok {
+		return pipeline.NewBatchSender[T](batchSender, p), nil
+	}
+
+	return pipeline.NewSender[T](sender, p), nil
+}
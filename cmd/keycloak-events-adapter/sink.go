@@ -0,0 +1,327 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tarantool/go-tarantool/queue"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/breaker"
+	"keycloak-events-adapter/internal/metrics"
+	"keycloak-events-adapter/internal/sink/amqp"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+	"keycloak-events-adapter/internal/sink/file"
+	"keycloak-events-adapter/internal/sink/forward"
+	"keycloak-events-adapter/internal/sink/loki"
+	"keycloak-events-adapter/internal/sink/mqtt"
+	"keycloak-events-adapter/internal/sink/nats"
+	"keycloak-events-adapter/internal/sink/otlp"
+	"keycloak-events-adapter/internal/sink/redis"
+	"keycloak-events-adapter/internal/sink/s3"
+	"keycloak-events-adapter/internal/sink/splunk"
+	"keycloak-events-adapter/internal/tarantool"
+)
+
+// cloudEventsSinks приемники, поддерживающие CloudEvents. Бинарный режим проверяют сами приемники:
+// он доступен только с заголовками у отдельного сообщения. redis и otlp раскладывают событие по полям
+// и атрибутам, а forward передает его в EventAPI вышестоящего адаптера, поэтому конверт к ним неприменим.
+var cloudEventsSinks = map[string]bool{
+	"file":   true,
+	"s3":     true,
+	"nats":   true,
+	"amqp":   true,
+	"splunk": true,
+	"loki":   true,
+	"mqtt":   true,
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// newSender создает отправителя событий указанного в конфигурации типа
+func newSender[T internal.Event | internal.AdminEvent](
+	cfg *Config,
+	name string,
+	logger *zap.Logger,
+) (internal.EventSender[T], io.Closer, error) {
+	ceMode := cloudevents.Mode(cfg.CloudEvents)
+	if ceMode != cloudevents.ModeNone && !cloudEventsSinks[cfg.Sink] {
+		return nil, nil, fmt.Errorf("%s sink doesn't support cloudevents", cfg.Sink)
+	}
+
+	switch cfg.Sink {
+	case "file":
+		sender, err := file.NewSender[T](file.Options{
+			Dir:            cfg.File.Dir,
+			Name:           name,
+			MaxSize:        cfg.File.MaxSize,
+			RotateInterval: cfg.File.RotateInterval,
+			Compression:    file.Compression(cfg.File.Compression),
+			MaxAge:         cfg.File.MaxAge,
+			MaxTotalSize:   cfg.File.MaxTotalSize,
+			Durability:     file.Durability(cfg.File.Durability),
+			SyncInterval:   cfg.File.SyncInterval,
+			CloudEvents:    ceMode,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("file sink: %w", err)
+		}
+
+		return sender, sender, nil
+	case "s3":
+		sender, err := s3.NewSender[T](s3.Options{
+			Endpoint:    cfg.S3.Endpoint,
+			Region:      cfg.S3.Region,
+			Bucket:      cfg.S3.Bucket,
+			AccessKey:   cfg.S3.AccessKey,
+			SecretKey:   cfg.S3.SecretKey,
+			UseSSL:      cfg.S3.UseSSL,
+			PathStyle:   cfg.S3.PathStyle,
+			Prefix:      cfg.S3.Prefix,
+			Format:      s3.Format(cfg.S3.Format),
+			Compression: s3.Compression(cfg.S3.Compression),
+			Timeout:     cfg.S3.Timeout,
+			CloudEvents: ceMode,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("s3 sink: %w", err)
+		}
+
+		return sender, nopCloser{}, nil
+	case "nats":
+		subject := cfg.Nats.EventSubject
+		switch name {
+		case tarantool.AdminEventsQueueName:
+			subject = cfg.Nats.AdminSubject
+		case tarantool.AlertsQueueName:
+			subject = cfg.Alerts.NatsSubject
+		}
+
+		sender, err := nats.NewSender[T](nats.Options{
+			URL:         cfg.Nats.URL,
+			CredsFile:   cfg.Nats.CredsFile,
+			User:        cfg.Nats.User,
+			Password:    cfg.Nats.Password,
+			Subject:     subject,
+			Timeout:     cfg.Nats.Timeout,
+			CloudEvents: ceMode,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("nats sink: %w", err)
+		}
+
+		return sender, sender, nil
+	case "amqp":
+		routingKey := cfg.AMQP.EventRoutingKey
+		switch name {
+		case tarantool.AdminEventsQueueName:
+			routingKey = cfg.AMQP.AdminRoutingKey
+		case tarantool.AlertsQueueName:
+			routingKey = cfg.Alerts.AMQPRoutingKey
+		}
+
+		sender, err := amqp.NewSender[T](amqp.Options{
+			URL:         cfg.AMQP.URL,
+			Exchange:    cfg.AMQP.Exchange,
+			RoutingKey:  routingKey,
+			Mandatory:   !cfg.AMQP.AllowUnroutable,
+			Timeout:     cfg.AMQP.Timeout,
+			CloudEvents: ceMode,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("amqp sink: %w", err)
+		}
+
+		return sender, sender, nil
+	case "redis":
+		sender, err := redis.NewSender[T](redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Username: cfg.Redis.Username,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			Stream:   cfg.Redis.Stream,
+			MaxLen:   cfg.Redis.MaxLen,
+			Timeout:  cfg.Redis.Timeout,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("redis sink: %w", err)
+		}
+
+		return sender, sender, nil
+	case "splunk":
+		sender, err := splunk.NewSender[T](splunk.Options{
+			URL:          cfg.Splunk.URL,
+			Token:        cfg.Splunk.Token,
+			IndexTokens:  cfg.Splunk.IndexTokens,
+			Index:        cfg.Splunk.Index,
+			RealmIndexes: cfg.Splunk.RealmIndexes,
+			Source:       cfg.Splunk.Source,
+			Host:         cfg.Splunk.Host,
+			UseAck:       cfg.Splunk.UseAck,
+			AckInterval:  cfg.Splunk.AckInterval,
+			AckTimeout:   cfg.Splunk.AckTimeout,
+			Timeout:      cfg.Splunk.Timeout,
+			CloudEvents:  ceMode,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("splunk sink: %w", err)
+		}
+
+		return sender, nopCloser{}, nil
+	case "otlp":
+		sender, err := otlp.NewSender[T](otlp.Options{
+			Protocol:    otlp.Protocol(cfg.OTLP.Protocol),
+			Endpoint:    cfg.OTLP.Endpoint,
+			Insecure:    cfg.OTLP.Insecure,
+			Headers:     cfg.OTLP.Headers,
+			ServiceName: cfg.OTLP.ServiceName,
+			Timeout:     cfg.OTLP.Timeout,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("otlp sink: %w", err)
+		}
+
+		return sender, sender, nil
+	case "loki":
+		sender, err := loki.NewSender[T](loki.Options{
+			URL:         cfg.Loki.URL,
+			TenantID:    cfg.Loki.TenantID,
+			Username:    cfg.Loki.Username,
+			Password:    cfg.Loki.Password,
+			Labels:      cfg.Loki.Labels,
+			Timeout:     cfg.Loki.Timeout,
+			CloudEvents: ceMode,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loki sink: %w", err)
+		}
+
+		return sender, nopCloser{}, nil
+	case "mqtt":
+		topic := cfg.MQTT.EventTopic
+		switch name {
+		case tarantool.AdminEventsQueueName:
+			topic = cfg.MQTT.AdminTopic
+		case tarantool.AlertsQueueName:
+			topic = cfg.Alerts.MQTTTopic
+		}
+
+		clientID := cfg.MQTT.ClientID
+		if clientID != "" {
+			clientID += "-" + name
+		}
+
+		sender, err := mqtt.NewSender[T](mqtt.Options{
+			URL:         cfg.MQTT.URL,
+			Version:     mqtt.Version(cfg.MQTT.Version),
+			ClientID:    clientID,
+			Username:    cfg.MQTT.Username,
+			Password:    cfg.MQTT.Password,
+			Topic:       topic,
+			Retain:      cfg.MQTT.Retain,
+			CAFile:      cfg.MQTT.CAFile,
+			CertFile:    cfg.MQTT.CertFile,
+			KeyFile:     cfg.MQTT.KeyFile,
+			Timeout:     cfg.MQTT.Timeout,
+			CloudEvents: ceMode,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("mqtt sink: %w", err)
+		}
+
+		return sender, sender, nil
+	case "forward":
+		sender, err := forward.NewSender[T](forward.Options{
+			Target:           cfg.Forward.Target,
+			Insecure:         cfg.Forward.Insecure,
+			CAFile:           cfg.Forward.CAFile,
+			CertFile:         cfg.Forward.CertFile,
+			KeyFile:          cfg.Forward.KeyFile,
+			ServerName:       cfg.Forward.ServerName,
+			KeepaliveTime:    cfg.Forward.KeepaliveTime,
+			KeepaliveTimeout: cfg.Forward.KeepaliveTimeout,
+			Timeout:          cfg.Forward.Timeout,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("forward sink: %w", err)
+		}
+
+		return sender, sender, nil
+	default:
+		return internal.NewDummy[T](logger), nopCloser{}, nil
+	}
+}
+
+// newKeeper создает обработчик очереди с отправителем, указанным в конфигурации, и конвейерами обработки.
+// Если отправитель умеет отправлять пачки и размер пачки больше 1, задачи забираются пачками.
+// Приемник s3 без пачек загружал бы объект на каждое событие, поэтому требует BATCH_SIZE больше 1.
+func newKeeper[T internal.Event | internal.AdminEvent](
+	cfg *Config,
+	q queue.Queue,
+	name string,
+	pipes *pipelines,
+	logger *zap.Logger,
+) (internal.EventKeeper[T], io.Closer, error) {
+	if cfg.Sink == "s3" && cfg.BatchSize <= 1 {
+		return nil, nil, errors.New("s3 sink requires BATCH_SIZE greater than 1")
+	}
+
+	sender, closer, err := newSender[T](cfg, name, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sender, err = wrapSender(sender, pipes, logger)
+	if err != nil {
+		_ = closer.Close()
+		return nil, nil, fmt.Errorf("pipeline: %w", err)
+	}
+
+	b := newBreaker(cfg, name, logger)
+
+	var keeper internal.EventKeeper[T]
+	if batchSender, ok := sender.(internal.BatchSender[T]); ok && cfg.BatchSize > 1 {
+		keeper = tarantool.NewBatchEvent[T](q, batchSender, b, cfg.BatchSize, cfg.BatchWindow, logger)
+	} else {
+		if cfg.BatchSize > 1 {
+			logger.Warn("sink doesn't support batches, events will be sent one by one", zap.String("sink", cfg.Sink))
+		}
+		keeper = tarantool.NewEvent[T](q, sender, b, logger)
+	}
+
+	keeper, err = wrapKeeper(keeper, pipes, logger)
+	if err != nil {
+		_ = closer.Close()
+		return nil, nil, fmt.Errorf("pipeline: %w", err)
+	}
+
+	return keeper, closer, nil
+}
+
+// newBreaker создает автомат, общий для всех обработчиков очереди name.
+// Смена состояния пишется в лог и в метрики.
+func newBreaker(cfg *Config, name string, logger *zap.Logger) *breaker.Breaker {
+	if cfg.BreakerThreshold <= 0 {
+		return nil
+	}
+
+	state := metrics.BreakerState.WithLabelValues(cfg.Sink, name)
+	state.Set(float64(breaker.StateClosed))
+
+	b := breaker.New(cfg.BreakerThreshold, cfg.BreakerTimeout)
+	b.OnStateChange(func(from, to breaker.State) {
+		logger.Warn("sink circuit breaker state changed",
+			zap.String("sink", cfg.Sink),
+			zap.String("queue_name", name),
+			zap.Stringer("from", from),
+			zap.Stringer("to", to),
+		)
+		state.Set(float64(to))
+		metrics.BreakerTransitions.WithLabelValues(cfg.Sink, name, to.String()).Inc()
+	})
+
+	return b
+}
@@ -0,0 +1,71 @@
+// keycloak-events-expr проверяет выражение CEL конвейера обработки на примере события в JSON
+// и печатает результат: true или false
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/pipeline"
+)
+
+// Options параметры командной строки
+type Options struct {
+	Kind  string `long:"kind" description:"Event kind" default:"events" choice:"events" choice:"admin_events"`
+	Expr  string `long:"expr" description:"CEL expression" required:"true"`
+	Event string `long:"event" description:"File with event JSON as written by the file sink, - reads stdin" default:"-"`
+}
+
+func main() {
+	var opts Options
+	_, err := flags.Parse(&opts)
+	if err != nil {
+		os.Exit(2)
+	}
+
+	data, err := readEvent(opts.Event)
+	if err != nil {
+		log.Fatal("Failed to read event. ", err)
+	}
+
+	var result bool
+	switch opts.Kind {
+	case internal.KindEvents:
+		result, err = eval[internal.Event](opts.Expr, data)
+	case internal.KindAdminEvents:
+		result, err = eval[internal.AdminEvent](opts.Expr, data)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(result)
+}
+
+func readEvent(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(path)
+}
+
+func eval[T internal.Event | internal.AdminEvent](source string, data []byte) (bool, error) {
+	expr, err := pipeline.CompileExpr[T](source)
+	if err != nil {
+		return false, err
+	}
+
+	var event T
+	err = json.Unmarshal(data, &event)
+	if err != nil {
+		return false, fmt.Errorf("decode event: %w", err)
+	}
+
+	return pipeline.EvalExpr(expr, &event)
+}
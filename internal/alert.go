@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AlertEventType тип события, которым тревога доставляется в приемник
+const AlertEventType = "SECURITY_ALERT"
+
+type AlertSeverity string
+
+const (
+	AlertSeverityLow      AlertSeverity = "low"
+	AlertSeverityMedium   AlertSeverity = "medium"
+	AlertSeverityHigh     AlertSeverity = "high"
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+// Rank возвращает порядок важности от 1 для low до 4 для critical, 0 — неизвестная важность
+func (s AlertSeverity) Rank() int {
+	switch s {
+	case AlertSeverityLow:
+		return 1
+	case AlertSeverityMedium:
+		return 2
+	case AlertSeverityHigh:
+		return 3
+	case AlertSeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// Alert тревога детектора. Пока тревога активна, повторные срабатывания правила по тому же ключу
+// обновляют ее, не создавая новую.
+type Alert struct {
+	Id       uuid.UUID     `json:"id"`
+	Rule     string        `json:"rule"`
+	Severity AlertSeverity `json:"severity"`
+	// Key объект тревоги в пределах правила, например realm и пользователь или IP адрес.
+	// Ключ содержит персональные данные до обработки redact и в событие тревоги не передается.
+	Key         string `json:"key"`
+	Description string `json:"description"`
+
+	RealmId   uuid.UUID `json:"realm_id"`
+	RealmName string    `json:"realm_name,omitempty"`
+	ClientId  string    `json:"client_id,omitempty"`
+	UserId    uuid.UUID `json:"user_id"`
+	IpAddress string    `json:"ip_address,omitempty"`
+
+	// Value наблюдаемое значение, Threshold — порог правила
+	Value     float64           `json:"value"`
+	Threshold float64           `json:"threshold"`
+	Details   map[string]string `json:"details,omitempty"`
+
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Enrichment геолокация и метки события, по которому создана тревога
+	Enrichment *Enrichment `json:"enrichment,omitempty"`
+}
+
+// AlertEnrichmentOf копирует для тревоги геолокацию и метки события, nil — событие не обогащено
+func AlertEnrichmentOf[T Event | AdminEvent](event *T) *Enrichment {
+	enrichment := MetaOf(event).Enrichment
+	if enrichment == nil || (enrichment.Geo == nil && len(enrichment.Tags) == 0) {
+		return nil
+	}
+
+	return &Enrichment{Geo: enrichment.Geo, Tags: maps.Clone(enrichment.Tags)}
+}
+
+// Event представляет тревогу событием типа SECURITY_ALERT, атрибуты тревоги передаются в деталях alert_*,
+// обогащение события, по которому создана тревога, — в обогащении. Пользователь и адрес передаются
+// полями события, чтобы их обработали правила redact.
+func (a *Alert) Event() *Event {
+	details := make(map[string]string, len(a.Details)+6)
+	maps.Copy(details, a.Details)
+	details["alert_rule"] = a.Rule
+	details["alert_severity"] = string(a.Severity)
+	details["alert_description"] = a.Description
+	details["alert_value"] = strconv.FormatFloat(a.Value, 'g', -1, 64)
+	details["alert_threshold"] = strconv.FormatFloat(a.Threshold, 'g', -1, 64)
+	details["alert_first_seen"] = a.FirstSeen.Format(time.RFC3339Nano)
+
+	event := &Event{
+		Id:        a.Id,
+		Time:      a.LastSeen,
+		RawType:   AlertEventType,
+		RealmId:   a.RealmId,
+		RealmName: a.RealmName,
+		ClientId:  a.ClientId,
+		UserId:    a.UserId,
+		IpAddress: a.IpAddress,
+		Details:   details,
+		// тревога не отбрасывается фильтрами и выборкой конвейеров
+		Enrichment: &Enrichment{Protected: true},
+	}
+	if a.Enrichment != nil {
+		event.Enrichment.Geo = a.Enrichment.Geo
+		event.Enrichment.Tags = maps.Clone(a.Enrichment.Tags)
+	}
+
+	return event
+}
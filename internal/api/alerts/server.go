@@ -0,0 +1,74 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/api/auth"
+)
+
+// ActiveAlerts источник активных тревог, реализуется detect.Alerter
+type ActiveAlerts interface {
+	Active() ([]internal.Alert, error)
+}
+
+type response struct {
+	Alerts []internal.Alert `json:"alerts"`
+}
+
+// Server HTTP API активных тревог детекторов
+type Server struct {
+	alerts ActiveAlerts
+	token  string
+	logger *zap.Logger
+}
+
+// NewServer создает сервер, token — ожидаемый Bearer токен, пустой токен отключает проверку
+func NewServer(alerts ActiveAlerts, token string, logger *zap.Logger) *Server {
+	return &Server{
+		alerts: alerts,
+		token:  token,
+		logger: logger,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/alerts", s.handleActive)
+
+	return auth.Bearer(s.token, mux)
+}
+
+// handleActive возвращает активные тревоги, последние первыми. Параметры realm, rule и severity
+// оставляют тревоги с указанными значениями, параметр может повторяться.
+func (s *Server) handleActive(w http.ResponseWriter, r *http.Request) {
+	alerts, err := s.alerts.Active()
+	if err != nil {
+		s.logger.Error("can't get active alerts", zap.Error(err))
+		http.Error(w, "can't get active alerts", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	result := response{Alerts: make([]internal.Alert, 0, len(alerts))}
+	for _, alert := range alerts {
+		if matchQuery(query["realm"], alert.RealmName) &&
+			matchQuery(query["rule"], alert.Rule) &&
+			matchQuery(query["severity"], string(alert.Severity)) {
+			result.Alerts = append(result.Alerts, alert)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		s.logger.Error("can't write response", zap.Error(err))
+	}
+}
+
+func matchQuery(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, value)
+}
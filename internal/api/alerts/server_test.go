@@ -0,0 +1,112 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+)
+
+type activeFunc func() ([]internal.Alert, error)
+
+func (f activeFunc) Active() ([]internal.Alert, error) {
+	return f()
+}
+
+func TestServer_Active(t *testing.T) {
+	active := activeFunc(func() ([]internal.Alert, error) {
+		return []internal.Alert{
+			{Rule: "ip_users", Severity: internal.AlertSeverityHigh, Key: "10.0.0.1", RealmName: "master"},
+			{Rule: "user_failures", Severity: internal.AlertSeverityMedium, Key: "master/john", RealmName: "master"},
+			{Rule: "user_failures", Severity: internal.AlertSeverityMedium, Key: "other/jane", RealmName: "other"},
+		}, nil
+	})
+
+	tests := []struct {
+		name     string
+		url      string
+		token    string
+		auth     string
+		alerts   ActiveAlerts
+		wantCode int
+		wantKeys []string
+	}{
+		{
+			name:     "all",
+			url:      "/v1/alerts",
+			alerts:   active,
+			wantCode: http.StatusOK,
+			wantKeys: []string{"10.0.0.1", "master/john", "other/jane"},
+		},
+		{
+			name:     "filters",
+			url:      "/v1/alerts?realm=master&rule=user_failures&rule=client_failure_ratio",
+			alerts:   active,
+			wantCode: http.StatusOK,
+			wantKeys: []string{"master/john"},
+		},
+		{
+			name:     "no matches",
+			url:      "/v1/alerts?severity=critical",
+			alerts:   active,
+			wantCode: http.StatusOK,
+			wantKeys: []string{},
+		},
+		{
+			name:     "token",
+			url:      "/v1/alerts?severity=high",
+			token:    "secret",
+			auth:     "Bearer secret",
+			alerts:   active,
+			wantCode: http.StatusOK,
+			wantKeys: []string{"10.0.0.1"},
+		},
+		{
+			name:     "invalid token",
+			url:      "/v1/alerts",
+			token:    "secret",
+			auth:     "Bearer other",
+			alerts:   active,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "store error",
+			url:  "/v1/alerts",
+			alerts: activeFunc(func() ([]internal.Alert, error) {
+				return nil, errors.New("connection error")
+			}),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			NewServer(tt.alerts, tt.token, zap.NewNop()).Handler().ServeHTTP(w, r)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			keys := []string{}
+			for _, alert := range got.Alerts {
+				keys = append(keys, alert.Key)
+			}
+			assert.Equal(t, tt.wantKeys, keys)
+		})
+	}
+}
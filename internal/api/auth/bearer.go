@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Bearer пропускает к next только запросы с заголовком Authorization: Bearer <token>,
+// пустой token отключает проверку
+func Bearer(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"keycloak-events-adapter/internal"
+	grpc_server "keycloak-events-adapter/internal/api/grpc"
+	eventv1 "keycloak-events-adapter/internal/specs/gen/keycloak/event/v1"
+)
+
+const maxBodySize = 1 << 20
+
+var unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+type validator interface {
+	proto.Message
+	Validate() error
+}
+
+// Server HTTP/JSON шлюз к EventAPI: тело запроса — JSON представление CreateRequest или CreateAdminRequest,
+// ошибки возвращаются объектом google.rpc.Status с HTTP кодом, соответствующим коду gRPC.
+type Server struct {
+	eventServer *grpc_server.EventServer
+	logger      *zap.Logger
+}
+
+func NewServer(eventService internal.EventProvider, logger *zap.Logger) *Server {
+	return &Server{
+		eventServer: grpc_server.NewEventServer(eventService),
+		logger:      logger,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/events", func(w http.ResponseWriter, r *http.Request) {
+		handle(s, w, r, &eventv1.CreateRequest{}, s.eventServer.Create)
+	})
+	mux.HandleFunc("POST /v1/admin-events", func(w http.ResponseWriter, r *http.Request) {
+		handle(s, w, r, &eventv1.CreateAdminRequest{}, s.eventServer.CreateAdmin)
+	})
+
+	return mux
+}
+
+func handle[Req validator, Resp proto.Message](
+	s *Server,
+	w http.ResponseWriter,
+	r *http.Request,
+	request Req,
+	call func(context.Context, Req) (Resp, error),
+) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		s.writeError(w, status.Error(codes.InvalidArgument, fmt.Sprintf("can't read body: %s", err)))
+		return
+	}
+
+	err = unmarshalOptions.Unmarshal(body, request)
+	if err != nil {
+		s.writeError(w, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid json: %s", err)))
+		return
+	}
+
+	err = request.Validate()
+	if err != nil {
+		s.writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	response, err := call(r.Context(), request)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.write(w, http.StatusOK, response)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	if st.Code() == codes.Internal || st.Code() == codes.Unknown {
+		s.logger.Error("gateway request failed", zap.Error(err))
+	}
+
+	s.write(w, HTTPStatusFromCode(st.Code()), st.Proto())
+}
+
+func (s *Server) write(w http.ResponseWriter, code int, message proto.Message) {
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		s.logger.Error("can't marshal response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+// HTTPStatusFromCode соответствие кодов gRPC и HTTP по google.rpc.Code
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
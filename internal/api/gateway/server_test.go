@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/api/grpc/mock"
+)
+
+const (
+	eventId = "3b4a1d7e-8f2c-4c6a-9d1e-5f7a8b9c0d1e"
+	realmId = "7c0e5f1a-2b3d-4e5f-8a9b-0c1d2e3f4a5b"
+	userId  = "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a"
+)
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		prepare  func(m *mock.MockEventProvider)
+		wantCode int
+		wantGRPC codes.Code
+	}{
+		{
+			name: "event",
+			path: "/v1/events",
+			body: `{"id":"` + eventId + `","time":"2024-01-15T10:30:00Z","type":"EVENT_TYPE_LOGIN_ERROR","realmId":"` + realmId + `",
+				"userId":"` + userId + `","ipAddress":"10.0.0.1","error":"invalid_user_credentials","details":{"username":"admin"}}`,
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().Push(gomock.Any()).DoAndReturn(func(event *internal.Event) error {
+					assert.Equal(t, internal.EventTypeLoginError, event.Type)
+					assert.Equal(t, "admin", event.Details["username"])
+					return nil
+				})
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "event with numeric enum and snake case fields",
+			path: "/v1/events",
+			body: `{"id":"` + eventId + `","type":1,"realm_id":"` + realmId + `","user_id":"` + userId + `","ip_address":"10.0.0.1","unknown":true}`,
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().Push(gomock.Any()).Return(nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "admin event",
+			path: "/v1/admin-events",
+			body: `{"id":"` + eventId + `","realmId":"` + realmId + `","operationType":"OPERATION_TYPE_DELETE","resourceType":"USER",
+				"authDetails":{"realmId":"` + realmId + `","clientId":"` + eventId + `","userId":"` + userId + `","ipAddress":"10.0.0.1"}}`,
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().PushAdmin(gomock.Any()).DoAndReturn(func(event *internal.AdminEvent) error {
+					assert.Equal(t, internal.OperationTypeDelete, event.OperationType)
+					return nil
+				})
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "validation error",
+			path:     "/v1/events",
+			body:     `{"id":"not-uuid","type":"EVENT_TYPE_LOGIN"}`,
+			wantCode: http.StatusBadRequest,
+			wantGRPC: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid json",
+			path:     "/v1/admin-events",
+			body:     `{"id":`,
+			wantCode: http.StatusBadRequest,
+			wantGRPC: codes.InvalidArgument,
+		},
+		{
+			name: "push error",
+			path: "/v1/events",
+			body: `{"id":"` + eventId + `","type":"EVENT_TYPE_LOGIN","realmId":"` + realmId + `","userId":"` + userId + `","ipAddress":"10.0.0.1"}`,
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().Push(gomock.Any()).Return(errors.New("can't put to queue"))
+			},
+			wantCode: http.StatusInternalServerError,
+			wantGRPC: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			provider := mock.NewMockEventProvider(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(provider)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			NewServer(provider, zap.NewNop()).Handler().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var body struct {
+				Code    codes.Code `json:"code"`
+				Message string     `json:"message"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantGRPC, body.Code)
+			if tt.wantGRPC != codes.OK {
+				assert.NotEmpty(t, body.Message)
+			}
+		})
+	}
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{code: codes.OK, want: http.StatusOK},
+		{code: codes.InvalidArgument, want: http.StatusBadRequest},
+		{code: codes.Unauthenticated, want: http.StatusUnauthorized},
+		{code: codes.ResourceExhausted, want: http.StatusTooManyRequests},
+		{code: codes.Unavailable, want: http.StatusServiceUnavailable},
+		{code: codes.DeadlineExceeded, want: http.StatusGatewayTimeout},
+		{code: codes.Internal, want: http.StatusInternalServerError},
+		{code: codes.DataLoss, want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, HTTPStatusFromCode(tt.code))
+		})
+	}
+}
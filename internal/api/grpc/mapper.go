@@ -6,85 +6,136 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"keycloak-events-adapter/internal"
 	eventv1 "keycloak-events-adapter/internal/specs/gen/keycloak/event/v1"
 )
 
 var eventTypeMap = map[eventv1.EventType]internal.EventType{
-	eventv1.EventType_EVENT_TYPE_LOGIN:                                    internal.EventTypeLogin,
-	eventv1.EventType_EVENT_TYPE_LOGIN_ERROR:                              internal.EventTypeLoginError,
-	eventv1.EventType_EVENT_TYPE_REGISTER:                                 internal.EventTypeRegister,
-	eventv1.EventType_EVENT_TYPE_REGISTER_ERROR:                           internal.EventTypeRegisterError,
-	eventv1.EventType_EVENT_TYPE_LOGOUT:                                   internal.EventTypeLogout,
-	eventv1.EventType_EVENT_TYPE_LOGOUT_ERROR:                             internal.EventTypeLogoutError,
-	eventv1.EventType_EVENT_TYPE_CODE_TO_TOKEN:                            internal.EventTypeCodeToToken,
-	eventv1.EventType_EVENT_TYPE_CODE_TO_TOKEN_ERROR:                      internal.EventTypeCodeToTokenError,
-	eventv1.EventType_EVENT_TYPE_CLIENT_LOGIN:                             internal.EventTypeClientLogin,
-	eventv1.EventType_EVENT_TYPE_CLIENT_LOGIN_ERROR:                       internal.EventTypeClientLoginError,
-	eventv1.EventType_EVENT_TYPE_REFRESH_TOKEN:                            internal.EventTypeRefreshToken,
-	eventv1.EventType_EVENT_TYPE_REFRESH_TOKEN_ERROR:                      internal.EventTypeRefreshTokenError,
-	eventv1.EventType_EVENT_TYPE_INTROSPECT_TOKEN:                         internal.EventTypeIntrospectToken,
-	eventv1.EventType_EVENT_TYPE_INTROSPECT_TOKEN_ERROR:                   internal.EventTypeIntrospectTokenError,
-	eventv1.EventType_EVENT_TYPE_FEDERATED_IDENTITY_LINK:                  internal.EventTypeFederatedIdentityLink,
-	eventv1.EventType_EVENT_TYPE_FEDERATED_IDENTITY_LINK_ERROR:            internal.EventTypeFederatedIdentityLinkError,
-	eventv1.EventType_EVENT_TYPE_REMOVE_FEDERATED_IDENTITY:                internal.EventTypeRemoveFederatedIdentity,
-	eventv1.EventType_EVENT_TYPE_REMOVE_FEDERATED_IDENTITY_ERROR:          internal.EventTypeRemoveFederatedIdentityError,
-	eventv1.EventType_EVENT_TYPE_UPDATE_EMAIL:                             internal.EventTypeUpdateEmail,
-	eventv1.EventType_EVENT_TYPE_UPDATE_EMAIL_ERROR:                       internal.EventTypeUpdateEmailError,
-	eventv1.EventType_EVENT_TYPE_UPDATE_PROFILE:                           internal.EventTypeUpdateProfile,
-	eventv1.EventType_EVENT_TYPE_UPDATE_PROFILE_ERROR:                     internal.EventTypeUpdateProfileError,
-	eventv1.EventType_EVENT_TYPE_VERIFY_EMAIL:                             internal.EventTypeVerifyEmail,
-	eventv1.EventType_EVENT_TYPE_VERIFY_EMAIL_ERROR:                       internal.EventTypeVerifyEmailError,
-	eventv1.EventType_EVENT_TYPE_VERIFY_PROFILE:                           internal.EventTypeVerifyProfile,
-	eventv1.EventType_EVENT_TYPE_VERIFY_PROFILE_ERROR:                     internal.EventTypeVerifyProfileError,
-	eventv1.EventType_EVENT_TYPE_GRANT_CONSENT:                            internal.EventTypeGrantConsent,
-	eventv1.EventType_EVENT_TYPE_GRANT_CONSENT_ERROR:                      internal.EventTypeGrantConsentError,
-	eventv1.EventType_EVENT_TYPE_UPDATE_CONSENT:                           internal.EventTypeUpdateConsent,
-	eventv1.EventType_EVENT_TYPE_UPDATE_CONSENT_ERROR:                     internal.EventTypeUpdateConsentError,
-	eventv1.EventType_EVENT_TYPE_REVOKE_GRANT:                             internal.EventTypeRevokeGrant,
-	eventv1.EventType_EVENT_TYPE_REVOKE_GRANT_ERROR:                       internal.EventTypeRevokeGrantError,
-	eventv1.EventType_EVENT_TYPE_SEND_VERIFY_EMAIL:                        internal.EventTypeSendVerifyEmail,
-	eventv1.EventType_EVENT_TYPE_SEND_VERIFY_EMAIL_ERROR:                  internal.EventTypeSendVerifyEmailError,
-	eventv1.EventType_EVENT_TYPE_SEND_RESET_PASSWORD:                      internal.EventTypeSendResetPassword,
-	eventv1.EventType_EVENT_TYPE_SEND_RESET_PASSWORD_ERROR:                internal.EventTypeSendResetPasswordError,
-	eventv1.EventType_EVENT_TYPE_RESET_PASSWORD:                           internal.EventTypeResetPassword,
-	eventv1.EventType_EVENT_TYPE_RESET_PASSWORD_ERROR:                     internal.EventTypeResetPasswordError,
-	eventv1.EventType_EVENT_TYPE_RESTART_AUTHENTICATION:                   internal.EventTypeRestartAuthentication,
-	eventv1.EventType_EVENT_TYPE_RESTART_AUTHENTICATION_ERROR:             internal.EventTypeRestartAuthenticationError,
-	eventv1.EventType_EVENT_TYPE_INVALID_SIGNATURE:                        internal.EventTypeInvalidSignature,
-	eventv1.EventType_EVENT_TYPE_INVALID_SIGNATURE_ERROR:                  internal.EventTypeInvalidSignatureError,
-	eventv1.EventType_EVENT_TYPE_REGISTER_NODE:                            internal.EventTypeRegisterNode,
-	eventv1.EventType_EVENT_TYPE_REGISTER_NODE_ERROR:                      internal.EventTypeRegisterNodeError,
-	eventv1.EventType_EVENT_TYPE_UNREGISTER_NODE:                          internal.EventTypeUnregisterNode,
-	eventv1.EventType_EVENT_TYPE_UNREGISTER_NODE_ERROR:                    internal.EventTypeUnregisterNodeError,
-	eventv1.EventType_EVENT_TYPE_USER_INFO_REQUEST:                        internal.EventTypeUserInfoRequest,
-	eventv1.EventType_EVENT_TYPE_USER_INFO_REQUEST_ERROR:                  internal.EventTypeUserInfoRequestError,
-	eventv1.EventType_EVENT_TYPE_IMPERSONATE:                              internal.EventTypeImpersonate,
-	eventv1.EventType_EVENT_TYPE_IMPERSONATE_ERROR:                        internal.EventTypeImpersonateError,
-	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTIONS:                          internal.EventTypeExecuteActions,
-	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTIONS_ERROR:                    internal.EventTypeExecuteActionsError,
-	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTION_TOKEN:                     internal.EventTypeExecuteActionToken,
-	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTION_TOKEN_ERROR:               internal.EventTypeExecuteActionTokenError,
-	eventv1.EventType_EVENT_TYPE_CLIENT_INFO:                              internal.EventTypeClientInfo,
-	eventv1.EventType_EVENT_TYPE_CLIENT_INFO_ERROR:                        internal.EventTypeClientInfoError,
-	eventv1.EventType_EVENT_TYPE_CLIENT_REGISTER:                          internal.EventTypeClientRegister,
-	eventv1.EventType_EVENT_TYPE_CLIENT_REGISTER_ERROR:                    internal.EventTypeClientRegisterError,
-	eventv1.EventType_EVENT_TYPE_CLIENT_UPDATE:                            internal.EventTypeClientUpdate,
-	eventv1.EventType_EVENT_TYPE_CLIENT_UPDATE_ERROR:                      internal.EventTypeClientUpdateError,
-	eventv1.EventType_EVENT_TYPE_CLIENT_DELETE:                            internal.EventTypeClientDelete,
-	eventv1.EventType_EVENT_TYPE_CLIENT_DELETE_ERROR:                      internal.EventTypeClientDeleteError,
-	eventv1.EventType_EVENT_TYPE_TOKEN_EXCHANGE:                           internal.EventTypeTokenExchange,
-	eventv1.EventType_EVENT_TYPE_TOKEN_EXCHANGE_ERROR:                     internal.EventTypeTokenExchangeError,
-	eventv1.EventType_EVENT_TYPE_DELETE_ACCOUNT:                           internal.EventTypeDeleteAccount,
-	eventv1.EventType_EVENT_TYPE_DELETE_ACCOUNT_ERROR:                     internal.EventTypeDeleteAccountError,
-	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT:       internal.EventTypeUserDisabledByPermanentLockout,
-	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT_ERROR: internal.EventTypeUserDisabledByPermanentLockoutError,
-	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT:       internal.EventTypeUserDisabledByTemporaryLockout,
-	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT_ERROR: internal.EventTypeUserDisabledByTemporaryLockoutError,
-	eventv1.EventType_EVENT_TYPE_UPDATE_CREDENTIAL:                        internal.EventTypeUpdateCredential,
-	eventv1.EventType_EVENT_TYPE_UPDATE_CREDENTIAL_ERROR:                  internal.EventTypeUpdateCredentialError,
-	eventv1.EventType_EVENT_TYPE_REMOVE_CREDENTIAL:                        internal.EventTypeRemoveCredential,
-	eventv1.EventType_EVENT_TYPE_REMOVE_CREDENTIAL_ERROR:                  internal.EventTypeRemoveCredentialError,
+	eventv1.EventType_EVENT_TYPE_LOGIN:                                            internal.EventTypeLogin,
+	eventv1.EventType_EVENT_TYPE_LOGIN_ERROR:                                      internal.EventTypeLoginError,
+	eventv1.EventType_EVENT_TYPE_REGISTER:                                         internal.EventTypeRegister,
+	eventv1.EventType_EVENT_TYPE_REGISTER_ERROR:                                   internal.EventTypeRegisterError,
+	eventv1.EventType_EVENT_TYPE_LOGOUT:                                           internal.EventTypeLogout,
+	eventv1.EventType_EVENT_TYPE_LOGOUT_ERROR:                                     internal.EventTypeLogoutError,
+	eventv1.EventType_EVENT_TYPE_CODE_TO_TOKEN:                                    internal.EventTypeCodeToToken,
+	eventv1.EventType_EVENT_TYPE_CODE_TO_TOKEN_ERROR:                              internal.EventTypeCodeToTokenError,
+	eventv1.EventType_EVENT_TYPE_CLIENT_LOGIN:                                     internal.EventTypeClientLogin,
+	eventv1.EventType_EVENT_TYPE_CLIENT_LOGIN_ERROR:                               internal.EventTypeClientLoginError,
+	eventv1.EventType_EVENT_TYPE_REFRESH_TOKEN:                                    internal.EventTypeRefreshToken,
+	eventv1.EventType_EVENT_TYPE_REFRESH_TOKEN_ERROR:                              internal.EventTypeRefreshTokenError,
+	eventv1.EventType_EVENT_TYPE_VALIDATE_ACCESS_TOKEN:                            internal.EventTypeValidateAccessToken,
+	eventv1.EventType_EVENT_TYPE_VALIDATE_ACCESS_TOKEN_ERROR:                      internal.EventTypeValidateAccessTokenError,
+	eventv1.EventType_EVENT_TYPE_INTROSPECT_TOKEN:                                 internal.EventTypeIntrospectToken,
+	eventv1.EventType_EVENT_TYPE_INTROSPECT_TOKEN_ERROR:                           internal.EventTypeIntrospectTokenError,
+	eventv1.EventType_EVENT_TYPE_FEDERATED_IDENTITY_LINK:                          internal.EventTypeFederatedIdentityLink,
+	eventv1.EventType_EVENT_TYPE_FEDERATED_IDENTITY_LINK_ERROR:                    internal.EventTypeFederatedIdentityLinkError,
+	eventv1.EventType_EVENT_TYPE_REMOVE_FEDERATED_IDENTITY:                        internal.EventTypeRemoveFederatedIdentity,
+	eventv1.EventType_EVENT_TYPE_REMOVE_FEDERATED_IDENTITY_ERROR:                  internal.EventTypeRemoveFederatedIdentityError,
+	eventv1.EventType_EVENT_TYPE_UPDATE_EMAIL:                                     internal.EventTypeUpdateEmail,
+	eventv1.EventType_EVENT_TYPE_UPDATE_EMAIL_ERROR:                               internal.EventTypeUpdateEmailError,
+	eventv1.EventType_EVENT_TYPE_UPDATE_PROFILE:                                   internal.EventTypeUpdateProfile,
+	eventv1.EventType_EVENT_TYPE_UPDATE_PROFILE_ERROR:                             internal.EventTypeUpdateProfileError,
+	eventv1.EventType_EVENT_TYPE_UPDATE_PASSWORD:                                  internal.EventTypeUpdatePassword,
+	eventv1.EventType_EVENT_TYPE_UPDATE_PASSWORD_ERROR:                            internal.EventTypeUpdatePasswordError,
+	eventv1.EventType_EVENT_TYPE_UPDATE_TOTP:                                      internal.EventTypeUpdateTotp,
+	eventv1.EventType_EVENT_TYPE_UPDATE_TOTP_ERROR:                                internal.EventTypeUpdateTotpError,
+	eventv1.EventType_EVENT_TYPE_VERIFY_EMAIL:                                     internal.EventTypeVerifyEmail,
+	eventv1.EventType_EVENT_TYPE_VERIFY_EMAIL_ERROR:                               internal.EventTypeVerifyEmailError,
+	eventv1.EventType_EVENT_TYPE_VERIFY_PROFILE:                                   internal.EventTypeVerifyProfile,
+	eventv1.EventType_EVENT_TYPE_VERIFY_PROFILE_ERROR:                             internal.EventTypeVerifyProfileError,
+	eventv1.EventType_EVENT_TYPE_REMOVE_TOTP:                                      internal.EventTypeRemoveTotp,
+	eventv1.EventType_EVENT_TYPE_REMOVE_TOTP_ERROR:                                internal.EventTypeRemoveTotpError,
+	eventv1.EventType_EVENT_TYPE_GRANT_CONSENT:                                    internal.EventTypeGrantConsent,
+	eventv1.EventType_EVENT_TYPE_GRANT_CONSENT_ERROR:                              internal.EventTypeGrantConsentError,
+	eventv1.EventType_EVENT_TYPE_UPDATE_CONSENT:                                   internal.EventTypeUpdateConsent,
+	eventv1.EventType_EVENT_TYPE_UPDATE_CONSENT_ERROR:                             internal.EventTypeUpdateConsentError,
+	eventv1.EventType_EVENT_TYPE_REVOKE_GRANT:                                     internal.EventTypeRevokeGrant,
+	eventv1.EventType_EVENT_TYPE_REVOKE_GRANT_ERROR:                               internal.EventTypeRevokeGrantError,
+	eventv1.EventType_EVENT_TYPE_SEND_VERIFY_EMAIL:                                internal.EventTypeSendVerifyEmail,
+	eventv1.EventType_EVENT_TYPE_SEND_VERIFY_EMAIL_ERROR:                          internal.EventTypeSendVerifyEmailError,
+	eventv1.EventType_EVENT_TYPE_SEND_RESET_PASSWORD:                              internal.EventTypeSendResetPassword,
+	eventv1.EventType_EVENT_TYPE_SEND_RESET_PASSWORD_ERROR:                        internal.EventTypeSendResetPasswordError,
+	eventv1.EventType_EVENT_TYPE_SEND_IDENTITY_PROVIDER_LINK:                      internal.EventTypeSendIdentityProviderLink,
+	eventv1.EventType_EVENT_TYPE_SEND_IDENTITY_PROVIDER_LINK_ERROR:                internal.EventTypeSendIdentityProviderLinkError,
+	eventv1.EventType_EVENT_TYPE_RESET_PASSWORD:                                   internal.EventTypeResetPassword,
+	eventv1.EventType_EVENT_TYPE_RESET_PASSWORD_ERROR:                             internal.EventTypeResetPasswordError,
+	eventv1.EventType_EVENT_TYPE_RESTART_AUTHENTICATION:                           internal.EventTypeRestartAuthentication,
+	eventv1.EventType_EVENT_TYPE_RESTART_AUTHENTICATION_ERROR:                     internal.EventTypeRestartAuthenticationError,
+	eventv1.EventType_EVENT_TYPE_INVALID_SIGNATURE:                                internal.EventTypeInvalidSignature,
+	eventv1.EventType_EVENT_TYPE_INVALID_SIGNATURE_ERROR:                          internal.EventTypeInvalidSignatureError,
+	eventv1.EventType_EVENT_TYPE_REGISTER_NODE:                                    internal.EventTypeRegisterNode,
+	eventv1.EventType_EVENT_TYPE_REGISTER_NODE_ERROR:                              internal.EventTypeRegisterNodeError,
+	eventv1.EventType_EVENT_TYPE_UNREGISTER_NODE:                                  internal.EventTypeUnregisterNode,
+	eventv1.EventType_EVENT_TYPE_UNREGISTER_NODE_ERROR:                            internal.EventTypeUnregisterNodeError,
+	eventv1.EventType_EVENT_TYPE_USER_INFO_REQUEST:                                internal.EventTypeUserInfoRequest,
+	eventv1.EventType_EVENT_TYPE_USER_INFO_REQUEST_ERROR:                          internal.EventTypeUserInfoRequestError,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_LINK_ACCOUNT:                   internal.EventTypeIdentityProviderLinkAccount,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_LINK_ACCOUNT_ERROR:             internal.EventTypeIdentityProviderLinkAccountError,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_LOGIN:                          internal.EventTypeIdentityProviderLogin,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_LOGIN_ERROR:                    internal.EventTypeIdentityProviderLoginError,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_FIRST_LOGIN:                    internal.EventTypeIdentityProviderFirstLogin,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_FIRST_LOGIN_ERROR:              internal.EventTypeIdentityProviderFirstLoginError,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_POST_LOGIN:                     internal.EventTypeIdentityProviderPostLogin,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_POST_LOGIN_ERROR:               internal.EventTypeIdentityProviderPostLoginError,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_RESPONSE:                       internal.EventTypeIdentityProviderResponse,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_RESPONSE_ERROR:                 internal.EventTypeIdentityProviderResponseError,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_RETRIEVE_TOKEN:                 internal.EventTypeIdentityProviderRetrieveToken,
+	eventv1.EventType_EVENT_TYPE_IDENTITY_PROVIDER_RETRIEVE_TOKEN_ERROR:           internal.EventTypeIdentityProviderRetrieveTokenError,
+	eventv1.EventType_EVENT_TYPE_IMPERSONATE:                                      internal.EventTypeImpersonate,
+	eventv1.EventType_EVENT_TYPE_IMPERSONATE_ERROR:                                internal.EventTypeImpersonateError,
+	eventv1.EventType_EVENT_TYPE_CUSTOM_REQUIRED_ACTION:                           internal.EventTypeCustomRequiredAction,
+	eventv1.EventType_EVENT_TYPE_CUSTOM_REQUIRED_ACTION_ERROR:                     internal.EventTypeCustomRequiredActionError,
+	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTIONS:                                  internal.EventTypeExecuteActions,
+	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTIONS_ERROR:                            internal.EventTypeExecuteActionsError,
+	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTION_TOKEN:                             internal.EventTypeExecuteActionToken,
+	eventv1.EventType_EVENT_TYPE_EXECUTE_ACTION_TOKEN_ERROR:                       internal.EventTypeExecuteActionTokenError,
+	eventv1.EventType_EVENT_TYPE_CLIENT_INFO:                                      internal.EventTypeClientInfo,
+	eventv1.EventType_EVENT_TYPE_CLIENT_INFO_ERROR:                                internal.EventTypeClientInfoError,
+	eventv1.EventType_EVENT_TYPE_CLIENT_REGISTER:                                  internal.EventTypeClientRegister,
+	eventv1.EventType_EVENT_TYPE_CLIENT_REGISTER_ERROR:                            internal.EventTypeClientRegisterError,
+	eventv1.EventType_EVENT_TYPE_CLIENT_UPDATE:                                    internal.EventTypeClientUpdate,
+	eventv1.EventType_EVENT_TYPE_CLIENT_UPDATE_ERROR:                              internal.EventTypeClientUpdateError,
+	eventv1.EventType_EVENT_TYPE_CLIENT_DELETE:                                    internal.EventTypeClientDelete,
+	eventv1.EventType_EVENT_TYPE_CLIENT_DELETE_ERROR:                              internal.EventTypeClientDeleteError,
+	eventv1.EventType_EVENT_TYPE_CLIENT_INITIATED_ACCOUNT_LINKING:                 internal.EventTypeClientInitiatedAccountLinking,
+	eventv1.EventType_EVENT_TYPE_CLIENT_INITIATED_ACCOUNT_LINKING_ERROR:           internal.EventTypeClientInitiatedAccountLinkingError,
+	eventv1.EventType_EVENT_TYPE_TOKEN_EXCHANGE:                                   internal.EventTypeTokenExchange,
+	eventv1.EventType_EVENT_TYPE_TOKEN_EXCHANGE_ERROR:                             internal.EventTypeTokenExchangeError,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_DEVICE_AUTH:                               internal.EventTypeOAuth2DeviceAuth,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_DEVICE_AUTH_ERROR:                         internal.EventTypeOAuth2DeviceAuthError,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_DEVICE_VERIFY_USER_CODE:                   internal.EventTypeOAuth2DeviceVerifyUserCode,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_DEVICE_VERIFY_USER_CODE_ERROR:             internal.EventTypeOAuth2DeviceVerifyUserCodeError,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_DEVICE_CODE_TO_TOKEN:                      internal.EventTypeOAuth2DeviceCodeToToken,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_DEVICE_CODE_TO_TOKEN_ERROR:                internal.EventTypeOAuth2DeviceCodeToTokenError,
+	eventv1.EventType_EVENT_TYPE_AUTHREQID_TO_TOKEN:                               internal.EventTypeAuthReqIdToToken,
+	eventv1.EventType_EVENT_TYPE_AUTHREQID_TO_TOKEN_ERROR:                         internal.EventTypeAuthReqIdToTokenError,
+	eventv1.EventType_EVENT_TYPE_PERMISSION_TOKEN:                                 internal.EventTypePermissionToken,
+	eventv1.EventType_EVENT_TYPE_PERMISSION_TOKEN_ERROR:                           internal.EventTypePermissionTokenError,
+	eventv1.EventType_EVENT_TYPE_DELETE_ACCOUNT:                                   internal.EventTypeDeleteAccount,
+	eventv1.EventType_EVENT_TYPE_DELETE_ACCOUNT_ERROR:                             internal.EventTypeDeleteAccountError,
+	eventv1.EventType_EVENT_TYPE_PUSHED_AUTHORIZATION_REQUEST:                     internal.EventTypePushedAuthorizationRequest,
+	eventv1.EventType_EVENT_TYPE_PUSHED_AUTHORIZATION_REQUEST_ERROR:               internal.EventTypePushedAuthorizationRequestError,
+	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT:               internal.EventTypeUserDisabledByPermanentLockout,
+	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT_ERROR:         internal.EventTypeUserDisabledByPermanentLockoutError,
+	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT:               internal.EventTypeUserDisabledByTemporaryLockout,
+	eventv1.EventType_EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT_ERROR:         internal.EventTypeUserDisabledByTemporaryLockoutError,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_EXTENSION_GRANT:                           internal.EventTypeOAuth2ExtensionGrant,
+	eventv1.EventType_EVENT_TYPE_OAUTH2_EXTENSION_GRANT_ERROR:                     internal.EventTypeOAuth2ExtensionGrantError,
+	eventv1.EventType_EVENT_TYPE_FEDERATED_IDENTITY_OVERRIDE_LINK:                 internal.EventTypeFederatedIdentityOverrideLink,
+	eventv1.EventType_EVENT_TYPE_FEDERATED_IDENTITY_OVERRIDE_LINK_ERROR:           internal.EventTypeFederatedIdentityOverrideLinkError,
+	eventv1.EventType_EVENT_TYPE_UPDATE_CREDENTIAL:                                internal.EventTypeUpdateCredential,
+	eventv1.EventType_EVENT_TYPE_UPDATE_CREDENTIAL_ERROR:                          internal.EventTypeUpdateCredentialError,
+	eventv1.EventType_EVENT_TYPE_REMOVE_CREDENTIAL:                                internal.EventTypeRemoveCredential,
+	eventv1.EventType_EVENT_TYPE_REMOVE_CREDENTIAL_ERROR:                          internal.EventTypeRemoveCredentialError,
+	eventv1.EventType_EVENT_TYPE_INVITE_ORG:                                       internal.EventTypeInviteOrg,
+	eventv1.EventType_EVENT_TYPE_INVITE_ORG_ERROR:                                 internal.EventTypeInviteOrgError,
+	eventv1.EventType_EVENT_TYPE_USER_SESSION_DELETED:                             internal.EventTypeUserSessionDeleted,
+	eventv1.EventType_EVENT_TYPE_USER_SESSION_DELETED_ERROR:                       internal.EventTypeUserSessionDeletedError,
+	eventv1.EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST:                    internal.EventTypeVerifiableCredentialRequest,
+	eventv1.EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST_ERROR:              internal.EventTypeVerifiableCredentialRequestError,
+	eventv1.EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT:       internal.EventTypeVerifiableCredentialPreAuthorizedGrant,
+	eventv1.EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT_ERROR: internal.EventTypeVerifiableCredentialPreAuthorizedGrantError,
 }
 
 var operationTypeMap = map[eventv1.OperationType]internal.OperationType{
@@ -94,6 +145,31 @@ var operationTypeMap = map[eventv1.OperationType]internal.OperationType{
 	eventv1.OperationType_OPERATION_TYPE_ACTION: internal.OperationTypeAction,
 }
 
+var eventTypeReverseMap = reverse(eventTypeMap)
+
+var operationTypeReverseMap = reverse(operationTypeMap)
+
+// EventTypeToProto возвращает значение перечисления EventAPI для типа события
+func EventTypeToProto(t internal.EventType) (eventv1.EventType, bool) {
+	value, ok := eventTypeReverseMap[t]
+	return value, ok
+}
+
+// OperationTypeToProto возвращает значение перечисления EventAPI для типа операции
+func OperationTypeToProto(t internal.OperationType) (eventv1.OperationType, bool) {
+	value, ok := operationTypeReverseMap[t]
+	return value, ok
+}
+
+func reverse[K, V comparable](m map[K]V) map[V]K {
+	result := make(map[V]K, len(m))
+	for k, v := range m {
+		result[v] = k
+	}
+
+	return result
+}
+
 func mapCreateAdminRequestToAdminEvent(request *eventv1.CreateAdminRequest) (*internal.AdminEvent, error) {
 	if request == nil {
 		return nil, errors.New("request is empty")
@@ -189,15 +265,16 @@ func mapCreateRequestToEvent(request *eventv1.CreateRequest) (*internal.Event, e
 		return nil, fmt.Errorf("invalid user id: %w", err)
 	}
 
-	eventType, ok := eventTypeMap[request.Type]
-	if !ok {
-		return nil, fmt.Errorf("invalid event type: %s", request.Type)
+	eventType, rawType, err := mapEventType(request.GetType(), request.GetTypeName())
+	if err != nil {
+		return nil, err
 	}
 
 	return &internal.Event{
 		Id:        id,
 		Time:      eventTime,
 		Type:      eventType,
+		RawType:   rawType,
 		RealmId:   realmId,
 		RealmName: request.GetRealmName(),
 		ClientId:  request.GetClientId(),
@@ -208,3 +285,93 @@ func mapCreateRequestToEvent(request *eventv1.CreateRequest) (*internal.Event, e
 		Details:   request.GetDetails(),
 	}, nil
 }
+
+// mapEventType определяет тип события. Тип, отсутствующий в перечислении EventAPI, передается как
+// EVENT_TYPE_INVALID с именем в type_name и сохраняется как EventTypeUnknown с исходным именем.
+func mapEventType(protoType eventv1.EventType, name string) (internal.EventType, string, error) {
+	if eventType, ok := eventTypeMap[protoType]; ok {
+		return eventType, "", nil
+	}
+	if protoType != eventv1.EventType_EVENT_TYPE_INVALID || name == "" {
+		return 0, "", fmt.Errorf("invalid event type: %s", protoType)
+	}
+
+	// имя может быть известно адаптеру, даже если отправитель собран со старым перечислением
+	if eventType, ok := internal.ParseEventType(name); ok {
+		return eventType, "", nil
+	}
+
+	return internal.EventTypeUnknown, name, nil
+}
+
+// MapAdminEventToCreateAdminRequest преобразует событие администрирования обратно в запрос EventAPI
+func MapAdminEventToCreateAdminRequest(event *internal.AdminEvent) (*eventv1.CreateAdminRequest, error) {
+	if event == nil {
+		return nil, errors.New("event is empty")
+	}
+
+	operationType, ok := OperationTypeToProto(event.OperationType)
+	if !ok {
+		return nil, fmt.Errorf("invalid operation type: %s", event.OperationType)
+	}
+
+	var authDetails *eventv1.CreateAdminRequest_AuthDetails
+	if event.AuthDetails != nil {
+		authDetails = &eventv1.CreateAdminRequest_AuthDetails{
+			RealmId:   event.AuthDetails.RealmId.String(),
+			RealmName: event.AuthDetails.RealmName,
+			ClientId:  event.AuthDetails.ClientId.String(),
+			UserId:    event.AuthDetails.UserId.String(),
+			IpAddress: event.AuthDetails.IpAddress,
+		}
+	}
+
+	return &eventv1.CreateAdminRequest{
+		Id:             event.Id.String(),
+		Time:           mapTime(event.Time),
+		RealmId:        event.RealmId.String(),
+		RealmName:      event.RealmName,
+		AuthDetails:    authDetails,
+		ResourceType:   event.ResourceType,
+		OperationType:  operationType,
+		ResourcePath:   event.ResourcePath,
+		Representation: event.Representation,
+		Error:          event.Error,
+		Details:        event.Details,
+	}, nil
+}
+
+// MapEventToCreateRequest преобразует событие обратно в запрос EventAPI
+func MapEventToCreateRequest(event *internal.Event) (*eventv1.CreateRequest, error) {
+	if event == nil {
+		return nil, errors.New("event is empty")
+	}
+
+	eventType, ok := EventTypeToProto(event.Type)
+	if !ok && (event.Type != internal.EventTypeUnknown || event.RawType == "") {
+		return nil, fmt.Errorf("invalid event type: %s", event.Type)
+	}
+
+	return &eventv1.CreateRequest{
+		Id:        event.Id.String(),
+		Time:      mapTime(event.Time),
+		Type:      eventType,
+		TypeName:  event.TypeName(),
+		RealmId:   event.RealmId.String(),
+		RealmName: event.RealmName,
+		ClientId:  event.ClientId,
+		UserId:    event.UserId.String(),
+		SessionId: event.SessionId,
+		IpAddress: event.IpAddress,
+		Error:     event.Error,
+		Details:   event.Details,
+	}, nil
+}
+
+func mapTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
@@ -0,0 +1,196 @@
+package grpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"keycloak-events-adapter/internal"
+	eventv1 "keycloak-events-adapter/internal/specs/gen/keycloak/event/v1"
+)
+
+func TestMapEventToCreateRequest_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Time:      time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Type:      internal.EventTypeLoginError,
+		RealmId:   uuid.New(),
+		RealmName: "master",
+		ClientId:  "account",
+		UserId:    uuid.New(),
+		SessionId: "session",
+		IpAddress: "127.0.0.1",
+		Error:     "invalid_user_credentials",
+		Details:   map[string]string{"username": "admin"},
+	}
+
+	request, err := MapEventToCreateRequest(event)
+	require.NoError(t, err)
+	require.NoError(t, request.Validate())
+
+	got, err := mapCreateRequestToEvent(request)
+	require.NoError(t, err)
+	assert.Equal(t, event, got)
+}
+
+func TestMapAdminEventToCreateAdminRequest_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *internal.AdminEvent
+	}{
+		{
+			name: "with auth details",
+			event: &internal.AdminEvent{
+				Id:             uuid.New(),
+				Time:           time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+				RealmId:        uuid.New(),
+				RealmName:      "master",
+				AuthDetails:    &internal.AuthDetails{RealmId: uuid.New(), ClientId: uuid.New(), UserId: uuid.New(), IpAddress: "10.0.0.1"},
+				ResourceType:   "USER",
+				OperationType:  internal.OperationTypeUpdate,
+				ResourcePath:   "users/1",
+				Representation: `{"enabled":true}`,
+				Details:        map[string]string{"key": "value"},
+			},
+		},
+		{
+			name: "without auth details and time",
+			event: &internal.AdminEvent{
+				Id:            uuid.New(),
+				RealmId:       uuid.New(),
+				OperationType: internal.OperationTypeDelete,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			request, err := MapAdminEventToCreateAdminRequest(tt.event)
+			require.NoError(t, err)
+			require.NoError(t, request.Validate())
+
+			got, err := mapCreateAdminRequestToAdminEvent(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.event, got)
+		})
+	}
+}
+
+func TestMapEventToCreateRequest_InvalidType(t *testing.T) {
+	t.Parallel()
+
+	_, err := MapEventToCreateRequest(&internal.Event{Id: uuid.New(), Type: internal.EventType(255)})
+	assert.Error(t, err)
+}
+
+// TestEventTypeMap_Bijection проверяет, что каждое значение перечисления EventAPI отображается
+// в свой тип события с тем же именем и новые значения не будут отклонены как неизвестные
+func TestEventTypeMap_Bijection(t *testing.T) {
+	t.Parallel()
+
+	for value, name := range eventv1.EventType_name {
+		protoType := eventv1.EventType(value)
+		if protoType == eventv1.EventType_EVENT_TYPE_INVALID {
+			assert.NotContains(t, eventTypeMap, protoType)
+			continue
+		}
+
+		eventType, ok := eventTypeMap[protoType]
+		if !assert.True(t, ok, "%s is not mapped", name) {
+			continue
+		}
+		assert.Equal(t, strings.TrimPrefix(name, "EVENT_TYPE_"), eventType.String())
+
+		back, ok := EventTypeToProto(eventType)
+		assert.True(t, ok)
+		assert.Equal(t, protoType, back)
+	}
+	assert.Len(t, eventTypeMap, len(eventv1.EventType_name)-1)
+	assert.Len(t, eventTypeReverseMap, len(eventTypeMap))
+}
+
+func TestOperationTypeMap_Bijection(t *testing.T) {
+	t.Parallel()
+
+	for value, name := range eventv1.OperationType_name {
+		protoType := eventv1.OperationType(value)
+		if protoType == eventv1.OperationType_OPERATION_TYPE_INVALID {
+			assert.NotContains(t, operationTypeMap, protoType)
+			continue
+		}
+
+		operationType, ok := operationTypeMap[protoType]
+		if !assert.True(t, ok, "%s is not mapped", name) {
+			continue
+		}
+		assert.Equal(t, strings.TrimPrefix(name, "OPERATION_TYPE_"), operationType.String())
+
+		back, ok := OperationTypeToProto(operationType)
+		assert.True(t, ok)
+		assert.Equal(t, protoType, back)
+	}
+	assert.Len(t, operationTypeMap, len(eventv1.OperationType_name)-1)
+	assert.Len(t, operationTypeReverseMap, len(operationTypeMap))
+}
+
+func TestMapEventType(t *testing.T) {
+	tests := []struct {
+		name      string
+		protoType eventv1.EventType
+		typeName  string
+		want      internal.EventType
+		wantRaw   string
+		wantErr   bool
+	}{
+		{name: "known type", protoType: eventv1.EventType_EVENT_TYPE_LOGIN, want: internal.EventTypeLogin},
+		{name: "known type ignores name", protoType: eventv1.EventType_EVENT_TYPE_LOGIN, typeName: "LOGOUT", want: internal.EventTypeLogin},
+		{name: "known name", typeName: "UPDATE_TOTP", want: internal.EventTypeUpdateTotp},
+		{name: "unknown name", typeName: "JWT_AUTHORIZATION_GRANT", want: internal.EventTypeUnknown, wantRaw: "JWT_AUTHORIZATION_GRANT"},
+		{name: "invalid without name", wantErr: true},
+		{name: "undefined value", protoType: eventv1.EventType(250), typeName: "LOGIN", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, raw, err := mapEventType(tt.protoType, tt.typeName)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantRaw, raw)
+		})
+	}
+}
+
+func TestMapEventToCreateRequest_RawType(t *testing.T) {
+	t.Parallel()
+
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Type:      internal.EventTypeUnknown,
+		RawType:   "JWT_AUTHORIZATION_GRANT",
+		RealmId:   uuid.New(),
+		UserId:    uuid.New(),
+		IpAddress: "10.0.0.1",
+	}
+
+	request, err := MapEventToCreateRequest(event)
+	require.NoError(t, err)
+	assert.Equal(t, eventv1.EventType_EVENT_TYPE_INVALID, request.GetType())
+	assert.Equal(t, "JWT_AUTHORIZATION_GRANT", request.GetTypeName())
+	require.NoError(t, request.Validate())
+
+	got, err := mapCreateRequestToEvent(request)
+	require.NoError(t, err)
+	assert.Equal(t, event, got)
+}
@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	eventv1 "keycloak-events-adapter/internal/specs/gen/keycloak/event/v1"
+)
+
+// eventRepresentation событие в формате org.keycloak.representations.idm.EventRepresentation
+type eventRepresentation struct {
+	Id        string            `json:"id"`
+	Time      int64             `json:"time"`
+	Type      string            `json:"type"`
+	RealmId   string            `json:"realmId"`
+	RealmName string            `json:"realmName"`
+	ClientId  string            `json:"clientId"`
+	UserId    string            `json:"userId"`
+	SessionId string            `json:"sessionId"`
+	IpAddress string            `json:"ipAddress"`
+	Error     string            `json:"error"`
+	Details   map[string]string `json:"details"`
+}
+
+type authDetailsRepresentation struct {
+	RealmId   string `json:"realmId"`
+	RealmName string `json:"realmName"`
+	ClientId  string `json:"clientId"`
+	UserId    string `json:"userId"`
+	IpAddress string `json:"ipAddress"`
+}
+
+// adminEventRepresentation событие администрирования в формате org.keycloak.representations.idm.AdminEventRepresentation
+type adminEventRepresentation struct {
+	Id             string                     `json:"id"`
+	Time           int64                      `json:"time"`
+	RealmId        string                     `json:"realmId"`
+	RealmName      string                     `json:"realmName"`
+	AuthDetails    *authDetailsRepresentation `json:"authDetails"`
+	OperationType  string                     `json:"operationType"`
+	ResourceType   string                     `json:"resourceType"`
+	ResourcePath   string                     `json:"resourcePath"`
+	Representation string                     `json:"representation"`
+	Error          string                     `json:"error"`
+	Details        map[string]string          `json:"details"`
+}
+
+// toCreateRequest преобразует событие в запрос EventAPI. Тип, отсутствующий в перечислении,
+// передается по имени, как это делает отправитель со старой версией API.
+func (r *eventRepresentation) toCreateRequest() *eventv1.CreateRequest {
+	eventType := eventv1.EventType(eventv1.EventType_value["EVENT_TYPE_"+r.Type])
+
+	return &eventv1.CreateRequest{
+		Id:        r.Id,
+		Time:      mapTime(r.Time),
+		Type:      eventType,
+		RealmId:   r.RealmId,
+		RealmName: r.RealmName,
+		ClientId:  r.ClientId,
+		UserId:    r.UserId,
+		SessionId: r.SessionId,
+		IpAddress: r.IpAddress,
+		Error:     r.Error,
+		Details:   r.Details,
+		TypeName:  r.Type,
+	}
+}
+
+func (r *adminEventRepresentation) toCreateAdminRequest() (*eventv1.CreateAdminRequest, error) {
+	operationType, ok := eventv1.OperationType_value["OPERATION_TYPE_"+r.OperationType]
+	if !ok || operationType == 0 {
+		return nil, fmt.Errorf("invalid operation type: %q", r.OperationType)
+	}
+
+	var authDetails *eventv1.CreateAdminRequest_AuthDetails
+	if r.AuthDetails != nil {
+		authDetails = &eventv1.CreateAdminRequest_AuthDetails{
+			RealmId:   r.AuthDetails.RealmId,
+			RealmName: r.AuthDetails.RealmName,
+			ClientId:  r.AuthDetails.ClientId,
+			UserId:    r.AuthDetails.UserId,
+			IpAddress: r.AuthDetails.IpAddress,
+		}
+	}
+
+	return &eventv1.CreateAdminRequest{
+		Id:             r.Id,
+		Time:           mapTime(r.Time),
+		RealmId:        r.RealmId,
+		RealmName:      r.RealmName,
+		AuthDetails:    authDetails,
+		ResourceType:   r.ResourceType,
+		OperationType:  eventv1.OperationType(operationType),
+		ResourcePath:   r.ResourcePath,
+		Representation: r.Representation,
+		Error:          r.Error,
+		Details:        r.Details,
+	}, nil
+}
+
+// mapTime переводит время Keycloak в миллисекундах от начала эпохи
+func mapTime(millis int64) *timestamppb.Timestamp {
+	if millis == 0 {
+		return nil
+	}
+
+	return timestamppb.New(time.UnixMilli(millis))
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/api/auth"
+	grpc_server "keycloak-events-adapter/internal/api/grpc"
+)
+
+const maxBodySize = 1 << 20
+
+type validator interface {
+	Validate() error
+}
+
+// Server принимает события Keycloak в собственном JSON формате, который отправляют HTTP webhook слушатели событий.
+// События проходят ту же валидацию и преобразование, что и запросы EventAPI.
+type Server struct {
+	eventServer *grpc_server.EventServer
+	token       string
+	logger      *zap.Logger
+}
+
+// NewServer создает сервер, token — ожидаемый Bearer токен, пустой токен отключает проверку
+func NewServer(eventService internal.EventProvider, token string, logger *zap.Logger) *Server {
+	return &Server{
+		eventServer: grpc_server.NewEventServer(eventService),
+		token:       token,
+		logger:      logger,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /events", s.handleEvent)
+	mux.HandleFunc("POST /admin-events", s.handleAdminEvent)
+
+	return auth.Bearer(s.token, mux)
+}
+
+func (s *Server) handleEvent(w http.ResponseWriter, r *http.Request) {
+	var representation eventRepresentation
+	if !decode(w, r, &representation) {
+		return
+	}
+
+	request := representation.toCreateRequest()
+	if !validate(w, request) {
+		return
+	}
+
+	_, err := s.eventServer.Create(r.Context(), request)
+	s.respond(w, err)
+}
+
+func (s *Server) handleAdminEvent(w http.ResponseWriter, r *http.Request) {
+	var representation adminEventRepresentation
+	if !decode(w, r, &representation) {
+		return
+	}
+
+	request, err := representation.toCreateAdminRequest()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validate(w, request) {
+		return
+	}
+
+	_, err = s.eventServer.CreateAdmin(r.Context(), request)
+	s.respond(w, err)
+}
+
+func (s *Server) respond(w http.ResponseWriter, err error) {
+	if err == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if status.Code(err) == codes.InvalidArgument {
+		http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+		return
+	}
+
+	s.logger.Error("can't push event", zap.Error(err))
+	http.Error(w, "can't push event", http.StatusInternalServerError)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %s", err), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func validate(w http.ResponseWriter, request validator) bool {
+	err := request.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
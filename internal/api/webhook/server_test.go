@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/api/grpc/mock"
+)
+
+const (
+	eventId = "3b4a1d7e-8f2c-4c6a-9d1e-5f7a8b9c0d1e"
+	realmId = "7c0e5f1a-2b3d-4e5f-8a9b-0c1d2e3f4a5b"
+	userId  = "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a"
+)
+
+func TestServer_Event(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		token    string
+		auth     string
+		prepare  func(m *mock.MockEventProvider)
+		wantCode int
+	}{
+		{
+			name: "login error",
+			body: `{"id":"` + eventId + `","time":1705314600123,"type":"LOGIN_ERROR","realmId":"` + realmId + `",
+				"clientId":"account","userId":"` + userId + `","ipAddress":"10.0.0.1","error":"invalid_user_credentials",
+				"details":{"username":"admin","auth_method":"openid-connect"}}`,
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().Push(gomock.Any()).DoAndReturn(func(event *internal.Event) error {
+					assert.Equal(t, eventId, event.Id.String())
+					assert.Equal(t, time.UnixMilli(1705314600123).UTC(), event.Time.UTC())
+					assert.Equal(t, internal.EventTypeLoginError, event.Type)
+					assert.Equal(t, "account", event.ClientId)
+					assert.Equal(t, "invalid_user_credentials", event.Error)
+					assert.Equal(t, "admin", event.Details["username"])
+					return nil
+				})
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "unknown type",
+			body: `{"id":"` + eventId + `","type":"JWT_AUTHORIZATION_GRANT","realmId":"` + realmId + `","userId":"` + userId + `","ipAddress":"10.0.0.1"}`,
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().Push(gomock.Any()).DoAndReturn(func(event *internal.Event) error {
+					assert.Equal(t, internal.EventTypeUnknown, event.Type)
+					assert.Equal(t, "JWT_AUTHORIZATION_GRANT", event.RawType)
+					return nil
+				})
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:     "invalid user id",
+			body:     `{"id":"` + eventId + `","type":"LOGIN","realmId":"` + realmId + `","userId":"admin"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid json",
+			body:     `{"id":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unauthorized",
+			body:     `{}`,
+			token:    "secret",
+			auth:     "Bearer other",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:  "push error",
+			body:  `{"id":"` + eventId + `","type":"LOGIN","realmId":"` + realmId + `","userId":"` + userId + `","ipAddress":"10.0.0.1"}`,
+			token: "secret",
+			auth:  "Bearer secret",
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().Push(gomock.Any()).Return(errors.New("can't put to queue"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			provider := mock.NewMockEventProvider(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(provider)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			NewServer(provider, tt.token, zap.NewNop()).Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestServer_AdminEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		prepare  func(m *mock.MockEventProvider)
+		wantCode int
+	}{
+		{
+			name: "role mapping",
+			body: `{"id":"` + eventId + `","time":1705314600000,"realmId":"` + realmId + `",
+				"authDetails":{"realmId":"` + realmId + `","clientId":"` + eventId + `","userId":"` + userId + `","ipAddress":"10.0.0.1"},
+				"operationType":"CREATE","resourceType":"REALM_ROLE_MAPPING","resourcePath":"users/` + userId + `/role-mappings/realm",
+				"representation":"[{\"name\":\"admin\"}]"}`,
+			prepare: func(m *mock.MockEventProvider) {
+				m.EXPECT().PushAdmin(gomock.Any()).DoAndReturn(func(event *internal.AdminEvent) error {
+					assert.Equal(t, internal.OperationTypeCreate, event.OperationType)
+					assert.Equal(t, "REALM_ROLE_MAPPING", event.ResourceType)
+					assert.Equal(t, userId, event.AuthDetails.UserId.String())
+					assert.Equal(t, `[{"name":"admin"}]`, event.Representation)
+					return nil
+				})
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:     "unknown operation type",
+			body:     `{"id":"` + eventId + `","realmId":"` + realmId + `","operationType":"MERGE"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid realm id",
+			body:     `{"id":"` + eventId + `","realmId":"master","operationType":"DELETE"}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			provider := mock.NewMockEventProvider(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(provider)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/admin-events", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			NewServer(provider, "", zap.NewNop()).Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestServer_MethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	rec := httptest.NewRecorder()
+	NewServer(nil, "", zap.NewNop()).Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,171 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State uint8
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Token разрешение вызова, выданное Allow. Пробный вызов получает номер пробы, остальные — 0.
+type Token uint64
+
+// Breaker размыкается после threshold ошибок подряд и не пропускает вызовы в течение timeout.
+// После timeout пропускается единственный пробный вызов: успех замыкает цепь, ошибка снова размыкает.
+// Пока цепь не замкнута, результаты вызовов, разрешенных до размыкания, не учитываются.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	timeout   time.Duration
+	state     State
+	failures  int
+	openedAt  time.Time
+	// probe номер выполняемой пробы, 0 — проба не выполняется
+	probe     Token
+	lastProbe Token
+	onChange  func(from, to State)
+	now       func() time.Time
+}
+
+func New(threshold int, timeout time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Breaker{
+		threshold: threshold,
+		timeout:   timeout,
+		now:       time.Now,
+	}
+}
+
+// OnStateChange задает функцию, вызываемую при смене состояния под блокировкой автомата
+func (b *Breaker) OnStateChange(fn func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.onChange = fn
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Allow проверяет, можно ли выполнить вызов. Результат разрешенного вызова нужно передать в Done
+// вместе с полученным разрешением.
+func (b *Breaker) Allow() (Token, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.timeout {
+			return 0, ErrOpen
+		}
+		b.setState(StateHalfOpen)
+
+		return b.startProbe(), nil
+	case StateHalfOpen:
+		if b.probe != 0 {
+			return 0, ErrOpen
+		}
+
+		return b.startProbe(), nil
+	default:
+		return 0, nil
+	}
+}
+
+func (b *Breaker) startProbe() Token {
+	b.lastProbe++
+	b.probe = b.lastProbe
+
+	return b.probe
+}
+
+// Wait возвращает время до перехода в полуоткрытое состояние, 0 — вызов можно выполнять сейчас
+func (b *Breaker) Wait() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != StateOpen {
+		return 0
+	}
+
+	return max(b.timeout-b.now().Sub(b.openedAt), 0)
+}
+
+// Done учитывает результат вызова, разрешенного Allow с разрешением token
+func (b *Breaker) Done(token Token, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if token != 0 {
+		if token != b.probe {
+			return
+		}
+		b.probe = 0
+	} else if b.state != StateClosed {
+		return
+	}
+
+	if success {
+		b.failures = 0
+		if b.state != StateClosed {
+			b.setState(StateClosed)
+		}
+
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		if b.state != StateOpen {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+// Cancel освобождает разрешение Allow, если вызов так и не был выполнен, состояние не меняется
+func (b *Breaker) Cancel(token Token) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if token != 0 && token == b.probe {
+		b.probe = 0
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestBreaker(threshold int) (*Breaker, *clock, *[]State) {
+	c := &clock{now: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)}
+	b := New(threshold, time.Minute)
+	b.now = c.Now
+
+	var transitions []State
+	b.OnStateChange(func(_, to State) {
+		transitions = append(transitions, to)
+	})
+
+	return b, c, &transitions
+}
+
+// call выполняет разрешенный вызов с результатом success
+func call(t *testing.T, b *Breaker, success bool) {
+	t.Helper()
+
+	token, err := b.Allow()
+	require.NoError(t, err)
+	b.Done(token, success)
+}
+
+func TestBreaker_Open(t *testing.T) {
+	t.Parallel()
+
+	b, _, transitions := newTestBreaker(3)
+
+	for i := 0; i < 2; i++ {
+		call(t, b, false)
+	}
+	assert.Equal(t, StateClosed, b.State())
+
+	// успех сбрасывает счетчик ошибок подряд
+	call(t, b, true)
+	for i := 0; i < 2; i++ {
+		call(t, b, false)
+	}
+	assert.Equal(t, StateClosed, b.State())
+
+	call(t, b, false)
+	assert.Equal(t, StateOpen, b.State())
+	_, err := b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, time.Minute, b.Wait())
+	assert.Equal(t, []State{StateOpen}, *transitions)
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name        string
+		probeOK     bool
+		wantState   State
+		wantHistory []State
+	}{
+		{name: "probe succeeds", probeOK: true, wantState: StateClosed, wantHistory: []State{StateOpen, StateHalfOpen, StateClosed}},
+		{name: "probe fails", probeOK: false, wantState: StateOpen, wantHistory: []State{StateOpen, StateHalfOpen, StateOpen}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, c, transitions := newTestBreaker(1)
+			call(t, b, false)
+
+			c.now = c.now.Add(30 * time.Second)
+			_, err := b.Allow()
+			assert.ErrorIs(t, err, ErrOpen)
+			assert.Equal(t, 30*time.Second, b.Wait())
+
+			c.now = c.now.Add(30 * time.Second)
+			assert.Zero(t, b.Wait())
+			probe, err := b.Allow()
+			require.NoError(t, err)
+			assert.Equal(t, StateHalfOpen, b.State())
+
+			// в полуоткрытом состоянии пропускается только один пробный вызов
+			_, err = b.Allow()
+			assert.ErrorIs(t, err, ErrOpen)
+
+			b.Done(probe, tt.probeOK)
+			assert.Equal(t, tt.wantState, b.State())
+			assert.Equal(t, tt.wantHistory, *transitions)
+		})
+	}
+}
+
+func TestBreaker_StaleCalls(t *testing.T) {
+	t.Parallel()
+
+	b, c, _ := newTestBreaker(1)
+
+	// вызов разрешен до размыкания и завершается во время пробы
+	stale, err := b.Allow()
+	require.NoError(t, err)
+	call(t, b, false)
+	c.now = c.now.Add(time.Minute)
+
+	probe, err := b.Allow()
+	require.NoError(t, err)
+
+	// результат старого вызова не снимает пробу и не меняет состояние
+	b.Done(stale, true)
+	b.Cancel(stale)
+	assert.Equal(t, StateHalfOpen, b.State())
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	b.Done(probe, true)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_Cancel(t *testing.T) {
+	t.Parallel()
+
+	b, c, _ := newTestBreaker(1)
+
+	call(t, b, false)
+	c.now = c.now.Add(time.Minute)
+
+	probe, err := b.Allow()
+	require.NoError(t, err)
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	// пробный вызов не состоялся, разрешение получает следующий
+	b.Cancel(probe)
+	assert.Equal(t, StateHalfOpen, b.State())
+	_, err = b.Allow()
+	require.NoError(t, err)
+}
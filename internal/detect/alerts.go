@@ -0,0 +1,147 @@
+package detect
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+// AlertStore активные тревоги. Тревога определяется правилом и ключом и активна до ExpiresAt.
+type AlertStore interface {
+	// Raise сохраняет тревогу и возвращает true, если она новая. Если тревога активна, она заменяется
+	// с сохранением Id и FirstSeen, которые записываются и в alert.
+	Raise(alert *internal.Alert) (bool, error)
+	// Withdraw удаляет тревогу, если активна именно она, чтобы следующее срабатывание снова было новым
+	Withdraw(alert *internal.Alert) error
+	// Active возвращает тревоги, активные в момент now
+	Active(now time.Time) ([]internal.Alert, error)
+}
+
+// AlertKey ключ тревоги в хранилище
+func AlertKey(alert *internal.Alert) string {
+	return alert.Rule + "/" + alert.Key
+}
+
+// MemoryAlerts тревоги в памяти процесса: для тестов и единственной реплики адаптера
+type MemoryAlerts struct {
+	mu     sync.Mutex
+	alerts map[string]internal.Alert
+}
+
+func NewMemoryAlerts() *MemoryAlerts {
+	return &MemoryAlerts{alerts: map[string]internal.Alert{}}
+}
+
+func (m *MemoryAlerts) Raise(alert *internal.Alert) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := AlertKey(alert)
+	active, ok := m.alerts[key]
+	isNew := !ok || !active.ExpiresAt.After(alert.LastSeen)
+	if !isNew {
+		alert.Id, alert.FirstSeen = active.Id, active.FirstSeen
+	}
+	m.alerts[key] = *alert
+
+	for k, a := range m.alerts {
+		if a.ExpiresAt.Before(alert.LastSeen) {
+			delete(m.alerts, k)
+		}
+	}
+
+	return isNew, nil
+}
+
+func (m *MemoryAlerts) Withdraw(alert *internal.Alert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := AlertKey(alert)
+	if active, ok := m.alerts[key]; ok && active.Id == alert.Id {
+		delete(m.alerts, key)
+	}
+
+	return nil
+}
+
+func (m *MemoryAlerts) Active(now time.Time) ([]internal.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := make([]internal.Alert, 0, len(m.alerts))
+	for _, alert := range m.alerts {
+		if alert.ExpiresAt.After(now) {
+			alerts = append(alerts, alert)
+		}
+	}
+	SortAlerts(alerts)
+
+	return alerts, nil
+}
+
+// SortAlerts упорядочивает тревоги от последних к более ранним
+func SortAlerts(alerts []internal.Alert) {
+	slices.SortFunc(alerts, func(a, b internal.Alert) int {
+		return cmp.Or(b.LastSeen.Compare(a.LastSeen), cmp.Compare(AlertKey(&a), AlertKey(&b)))
+	})
+}
+
+// Alerter регистрирует тревоги детекторов: новая тревога сохраняется активной и помещается в очередь тревог,
+// повторное срабатывание активной тревоги только продлевает ее. Если тревогу не удалось поместить в очередь,
+// она снимается, и следующее срабатывание снова попытается ее доставить.
+type Alerter struct {
+	store  AlertStore
+	keeper internal.EventKeeper[internal.Event]
+	logger *zap.Logger
+}
+
+func NewAlerter(store AlertStore, keeper internal.EventKeeper[internal.Event], logger *zap.Logger) *Alerter {
+	return &Alerter{
+		store:  store,
+		keeper: keeper,
+		logger: logger,
+	}
+}
+
+func (a *Alerter) Raise(alert *internal.Alert) error {
+	isNew, err := a.store.Raise(alert)
+	if err != nil {
+		return fmt.Errorf("store alert: %w", err)
+	}
+	if !isNew {
+		return nil
+	}
+
+	err = a.keeper.Push(alert.Event())
+	if err != nil {
+		errW := a.store.Withdraw(alert)
+		if errW != nil {
+			errW = fmt.Errorf("withdraw alert: %w", errW)
+		}
+
+		return errors.Join(fmt.Errorf("push alert: %w", err), errW)
+	}
+
+	metrics.Alerts.WithLabelValues(alert.Rule, string(alert.Severity)).Inc()
+	a.logger.Warn("alert raised",
+		zap.String("rule", alert.Rule),
+		zap.String("severity", string(alert.Severity)),
+		zap.String("key", alert.Key),
+		zap.String("description", alert.Description),
+	)
+
+	return nil
+}
+
+// Active возвращает активные тревоги, последние первыми
+func (a *Alerter) Active() ([]internal.Alert, error) {
+	return a.store.Active(time.Now())
+}
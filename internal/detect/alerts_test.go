@@ -0,0 +1,93 @@
+package detect
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/mock"
+)
+
+func testAlert(key string, at time.Time) *internal.Alert {
+	return &internal.Alert{
+		Id:        uuid.New(),
+		Rule:      RuleUserFailures,
+		Severity:  internal.AlertSeverityMedium,
+		Key:       key,
+		FirstSeen: at,
+		LastSeen:  at,
+		ExpiresAt: at.Add(10 * time.Minute),
+	}
+}
+
+func TestMemoryAlerts(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryAlerts()
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	first := testAlert("master/john", start)
+	isNew, err := store.Raise(first)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+
+	repeated := testAlert("master/john", start.Add(5*time.Minute))
+	isNew, err = store.Raise(repeated)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, first.Id, repeated.Id)
+	assert.Equal(t, start, repeated.FirstSeen)
+
+	other := testAlert("master/jane", start.Add(6*time.Minute))
+	_, err = store.Raise(other)
+	require.NoError(t, err)
+
+	active, err := store.Active(start.Add(12 * time.Minute))
+	require.NoError(t, err)
+	require.Len(t, active, 2)
+	assert.Equal(t, "master/jane", active[0].Key)
+	assert.Equal(t, start.Add(5*time.Minute), active[1].LastSeen)
+
+	active, err = store.Active(start.Add(16 * time.Minute))
+	require.NoError(t, err)
+	require.Len(t, active, 0)
+
+	// тревога после истечения активной считается новой
+	isNew, err = store.Raise(testAlert("master/john", start.Add(30*time.Minute)))
+	require.NoError(t, err)
+	assert.True(t, isNew)
+}
+
+func TestAlerter_Raise(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	keeper := mock.NewMockEventKeeper[internal.Event](ctrl)
+	alerter := NewAlerter(NewMemoryAlerts(), keeper, zap.NewNop())
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	alert := testAlert("master/john", start)
+	keeper.EXPECT().Push(gomock.Any()).DoAndReturn(func(event *internal.Event) error {
+		assert.Equal(t, alert.Id, event.Id)
+		assert.Equal(t, internal.AlertEventType, event.TypeName())
+		assert.Equal(t, RuleUserFailures, event.Details["alert_rule"])
+		return nil
+	})
+	require.NoError(t, alerter.Raise(alert))
+
+	// повторное срабатывание не отправляется
+	require.NoError(t, alerter.Raise(testAlert("master/john", start.Add(time.Minute))))
+
+	keeper.EXPECT().Push(gomock.Any()).Return(errors.New("queue error"))
+	assert.Error(t, alerter.Raise(testAlert("master/jane", start)))
+
+	// недоставленная тревога снята, следующее срабатывание доставляет ее снова
+	keeper.EXPECT().Push(gomock.Any()).Return(nil)
+	require.NoError(t, alerter.Raise(testAlert("master/jane", start.Add(time.Minute))))
+}
@@ -0,0 +1,289 @@
+package detect
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"keycloak-events-adapter/internal"
+)
+
+const (
+	RuleImpossibleTravel = "impossible_travel"
+	RuleNewCountry       = "new_country"
+	RuleNewClient        = "new_client"
+)
+
+const (
+	defaultHistorySize      = 50
+	defaultHistoryRetention = 90 * 24 * time.Hour
+	defaultUserAgentDetail  = "user_agent"
+)
+
+// earthRadius средний радиус Земли в км
+const earthRadius = 6371.0
+
+// minTravelTime время между входами, меньше которого скорость не вычисляется, чтобы одновременные входы
+// не давали бесконечную скорость
+const minTravelTime = time.Minute
+
+// LoginAnomalyOptions правила детектора аномальных входов
+type LoginAnomalyOptions struct {
+	// MaxSpeed максимальная правдоподобная скорость перемещения между входами в км/ч, 0 отключает правило
+	MaxSpeed float64 `yaml:"max_speed"`
+	// MinDistance расстояние в км, на котором перемещение не проверяется
+	MinDistance float64 `yaml:"min_distance"`
+	// NewCountry и NewClient включают правила входа из новой для пользователя страны и в новый клиент
+	NewCountry bool `yaml:"new_country"`
+	NewClient  bool `yaml:"new_client"`
+	// MinHistory число входов в истории, начиная с которого новые страна и клиент считаются аномалией
+	MinHistory int `yaml:"min_history"`
+	// HistorySize и Retention ограничивают историю входов пользователя
+	HistorySize int           `yaml:"history_size"`
+	Retention   time.Duration `yaml:"retention"`
+	// UserAgentDetail деталь события с User-Agent, Keycloak не передает его без доработки слушателя
+	UserAgentDetail string `yaml:"user_agent_detail"`
+	// Cooldown время, в течение которого тревога остается активной после последнего срабатывания
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+func (o *LoginAnomalyOptions) validate() error {
+	if o.MaxSpeed == 0 && !o.NewCountry && !o.NewClient {
+		return errors.New("no rules are enabled")
+	}
+	if o.MaxSpeed < 0 {
+		return fmt.Errorf("max_speed %v is negative", o.MaxSpeed)
+	}
+	if o.MinDistance < 0 {
+		return fmt.Errorf("min_distance %v is negative", o.MinDistance)
+	}
+	if o.MinHistory < 0 {
+		return fmt.Errorf("min_history %d is negative", o.MinHistory)
+	}
+	if o.HistorySize < 0 {
+		return fmt.Errorf("history_size %d is negative", o.HistorySize)
+	}
+	if o.Retention < 0 {
+		return errors.New("retention is negative")
+	}
+	if o.Cooldown < 0 {
+		return errors.New("cooldown is negative")
+	}
+
+	if o.MinHistory == 0 {
+		o.MinHistory = 1
+	}
+	if o.HistorySize == 0 {
+		o.HistorySize = defaultHistorySize
+	}
+	if o.MinHistory > o.HistorySize {
+		return fmt.Errorf("min_history %d exceeds history_size %d", o.MinHistory, o.HistorySize)
+	}
+	if o.Retention == 0 {
+		o.Retention = defaultHistoryRetention
+	}
+	if o.UserAgentDetail == "" {
+		o.UserAgentDetail = defaultUserAgentDetail
+	}
+	if o.Cooldown == 0 {
+		o.Cooldown = defaultCooldown
+	}
+
+	return nil
+}
+
+// LoginAnomaly сравнивает успешный вход (LOGIN) с историей входов пользователя: обнаруживает перемещение
+// с неправдоподобной скоростью, вход из страны и в клиент, которых нет в истории. Страна и координаты
+// берутся из геолокации, поэтому детектор должен выполняться после обработчика geoip.
+type LoginAnomaly struct {
+	opts    LoginAnomalyOptions
+	history History
+	alerts  AlertRaiser
+}
+
+func NewLoginAnomaly(opts LoginAnomalyOptions, history History, alerts AlertRaiser) (*LoginAnomaly, error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginAnomaly{
+		opts:    opts,
+		history: history,
+		alerts:  alerts,
+	}, nil
+}
+
+func (a *LoginAnomaly) Observe(event *internal.Event) error {
+	user := userOf(event)
+	if event.Type != internal.EventTypeLogin || user == "" {
+		return nil
+	}
+
+	login := a.login(event)
+	key := realmOf(event) + "/" + user
+	previous, err := a.history.Add(key, login, a.opts.HistorySize, a.opts.Retention)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if a.opts.MaxSpeed > 0 {
+		errs = append(errs, a.checkTravel(event, key, login, previous))
+	}
+	if len(previous) >= a.opts.MinHistory {
+		if a.opts.NewCountry && login.CountryCode != "" {
+			errs = append(errs, a.checkCountry(event, key, login, previous))
+		}
+		if a.opts.NewClient && login.ClientId != "" {
+			errs = append(errs, a.checkClient(event, key, login, previous))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *LoginAnomaly) login(event *internal.Event) Login {
+	login := Login{
+		EventId:   event.Id.String(),
+		Time:      event.Time,
+		IpAddress: event.IpAddress,
+		ClientId:  event.ClientId,
+		UserAgent: event.Details[a.opts.UserAgentDetail],
+	}
+	if login.Time.IsZero() {
+		login.Time = time.Now()
+	}
+
+	if event.Enrichment != nil && event.Enrichment.Geo != nil {
+		geo := event.Enrichment.Geo
+		login.CountryCode = geo.CountryCode
+		if geo.Latitude != 0 || geo.Longitude != 0 {
+			login.Location = &Location{
+				Latitude:       geo.Latitude,
+				Longitude:      geo.Longitude,
+				AccuracyRadius: geo.AccuracyRadius,
+			}
+		}
+	}
+
+	return login
+}
+
+// checkTravel сравнивает вход с ближайшим предыдущим по времени входом с известными координатами.
+// Расстояние уменьшается на радиусы точности геолокации обоих входов.
+func (a *LoginAnomaly) checkTravel(event *internal.Event, key string, login Login, previous []Login) error {
+	if login.Location == nil {
+		return nil
+	}
+
+	i := slices.IndexFunc(previous, func(l Login) bool {
+		return l.Location != nil && !l.Time.After(login.Time)
+	})
+	if i < 0 {
+		return nil
+	}
+	prev := previous[i]
+
+	distance := haversine(*prev.Location, *login.Location) -
+		float64(prev.Location.AccuracyRadius) - float64(login.Location.AccuracyRadius)
+	if distance <= a.opts.MinDistance {
+		return nil
+	}
+
+	elapsed := login.Time.Sub(prev.Time)
+	speed := distance / max(elapsed, minTravelTime).Hours()
+	if speed <= a.opts.MaxSpeed {
+		return nil
+	}
+
+	alert := a.alert(event, RuleImpossibleTravel, internal.AlertSeverityHigh, key, login, prev)
+	alert.Value, alert.Threshold = math.Round(speed), a.opts.MaxSpeed
+	alert.Details["distance_km"] = strconv.FormatFloat(math.Round(distance), 'f', -1, 64)
+	alert.Description = fmt.Sprintf("user logged in %.0f km away from previous login within %s", distance, elapsed)
+
+	return a.alerts.Raise(alert)
+}
+
+func (a *LoginAnomaly) checkCountry(event *internal.Event, key string, login Login, previous []Login) error {
+	if slices.ContainsFunc(previous, func(l Login) bool { return l.CountryCode == login.CountryCode }) {
+		return nil
+	}
+
+	alert := a.alert(event, RuleNewCountry, internal.AlertSeverityMedium, key+"/"+login.CountryCode, login, previous[0])
+	alert.Value, alert.Threshold = float64(len(previous)), float64(a.opts.MinHistory)
+	alert.Description = fmt.Sprintf("user logged in from new country %s after %d known logins",
+		login.CountryCode, len(previous))
+
+	return a.alerts.Raise(alert)
+}
+
+func (a *LoginAnomaly) checkClient(event *internal.Event, key string, login Login, previous []Login) error {
+	if slices.ContainsFunc(previous, func(l Login) bool { return l.ClientId == login.ClientId }) {
+		return nil
+	}
+
+	alert := a.alert(event, RuleNewClient, internal.AlertSeverityLow, key+"/"+login.ClientId, login, previous[0])
+	alert.ClientId = login.ClientId
+	alert.Value, alert.Threshold = float64(len(previous)), float64(a.opts.MinHistory)
+	alert.Description = fmt.Sprintf("user logged in to new client %s after %d known logins",
+		login.ClientId, len(previous))
+
+	return a.alerts.Raise(alert)
+}
+
+// alert создает тревогу с атрибутами входа и предыдущего входа prev в деталях
+func (a *LoginAnomaly) alert(
+	event *internal.Event,
+	rule string,
+	severity internal.AlertSeverity,
+	key string,
+	login, prev Login,
+) *internal.Alert {
+	details := map[string]string{
+		"previous_event_id":   prev.EventId,
+		"previous_time":       prev.Time.Format(time.RFC3339Nano),
+		"previous_ip_address": prev.IpAddress,
+		"previous_client_id":  prev.ClientId,
+		"previous_country":    prev.CountryCode,
+		"previous_user_agent": prev.UserAgent,
+		"country":             login.CountryCode,
+		"user_agent":          login.UserAgent,
+	}
+	for k, v := range details {
+		if v == "" {
+			delete(details, k)
+		}
+	}
+
+	return &internal.Alert{
+		Id:         uuid.New(),
+		Rule:       rule,
+		Severity:   severity,
+		Key:        key,
+		RealmId:    event.RealmId,
+		RealmName:  event.RealmName,
+		UserId:     event.UserId,
+		IpAddress:  event.IpAddress,
+		Details:    details,
+		FirstSeen:  login.Time,
+		LastSeen:   login.Time,
+		ExpiresAt:  login.Time.Add(a.opts.Cooldown),
+		Enrichment: internal.AlertEnrichmentOf(event),
+	}
+}
+
+// haversine расстояние по дуге большого круга в км
+func haversine(a, b Location) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
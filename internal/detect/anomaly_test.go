@@ -0,0 +1,276 @@
+package detect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"keycloak-events-adapter/internal"
+)
+
+func TestHaversine(t *testing.T) {
+	t.Parallel()
+
+	berlin := Location{Latitude: 52.52, Longitude: 13.405}
+	paris := Location{Latitude: 48.8566, Longitude: 2.3522}
+
+	assert.InDelta(t, 878, haversine(berlin, paris), 5)
+	assert.InDelta(t, 878, haversine(paris, berlin), 5)
+	assert.Zero(t, haversine(berlin, berlin))
+}
+
+func TestLoginAnomaly_Observe(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	john := uuid.New()
+
+	berlin := &internal.Geo{CountryCode: "DE", Latitude: 52.52, Longitude: 13.405, AccuracyRadius: 20}
+	potsdam := &internal.Geo{CountryCode: "DE", Latitude: 52.39, Longitude: 13.06, AccuracyRadius: 20}
+	paris := &internal.Geo{CountryCode: "FR", Latitude: 48.8566, Longitude: 2.3522, AccuracyRadius: 20}
+	newYork := &internal.Geo{CountryCode: "US", Latitude: 40.7128, Longitude: -74.006, AccuracyRadius: 50}
+
+	login := func(geo *internal.Geo, client string, offset time.Duration) *internal.Event {
+		event := &internal.Event{
+			Id:        uuid.New(),
+			Time:      start.Add(offset),
+			Type:      internal.EventTypeLogin,
+			RealmName: "master",
+			ClientId:  client,
+			UserId:    john,
+			IpAddress: "203.0.113.7",
+			Details:   map[string]string{"user_agent": "Firefox"},
+		}
+		if geo != nil {
+			event.Enrichment = &internal.Enrichment{Geo: geo}
+		}
+		return event
+	}
+	repeat := func(event *internal.Event) *internal.Event {
+		again := *event
+		return &again
+	}
+	first := login(berlin, "account", 0)
+	travel := LoginAnomalyOptions{MaxSpeed: 900}
+	user := "master/" + john.String()
+
+	tests := []struct {
+		name     string
+		opts     LoginAnomalyOptions
+		events   []*internal.Event
+		wantRule []string
+		wantKey  []string
+	}{
+		{
+			name: "impossible travel",
+			opts: travel,
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(newYork, "account", 2*time.Hour),
+			},
+			wantRule: []string{RuleImpossibleTravel},
+			wantKey:  []string{user},
+		},
+		{
+			name: "plausible travel",
+			opts: travel,
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(paris, "account", 2*time.Hour),
+				login(newYork, "account", 12*time.Hour),
+			},
+		},
+		{
+			name: "distance within accuracy",
+			opts: travel,
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(potsdam, "account", 0),
+			},
+		},
+		{
+			name: "min distance",
+			opts: LoginAnomalyOptions{MaxSpeed: 900, MinDistance: 1000},
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(paris, "account", time.Minute),
+			},
+		},
+		{
+			name: "simultaneous logins",
+			opts: travel,
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(paris, "account", 0),
+			},
+			wantRule: []string{RuleImpossibleTravel},
+			wantKey:  []string{user},
+		},
+		{
+			name: "without geolocation",
+			opts: travel,
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(nil, "account", time.Minute),
+				login(newYork, "account", 12*time.Hour),
+			},
+		},
+		{
+			name: "new country",
+			opts: LoginAnomalyOptions{NewCountry: true},
+			events: []*internal.Event{
+				// первый вход пользователя не с чем сравнить
+				login(berlin, "account", 0),
+				login(potsdam, "account", time.Hour),
+				login(paris, "account", 2*time.Hour),
+				login(paris, "account", 3*time.Hour),
+				login(nil, "account", 4*time.Hour),
+			},
+			wantRule: []string{RuleNewCountry},
+			wantKey:  []string{user + "/FR"},
+		},
+		{
+			name: "new country below min history",
+			opts: LoginAnomalyOptions{NewCountry: true, MinHistory: 3},
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(potsdam, "account", time.Hour),
+				login(paris, "account", 2*time.Hour),
+				login(newYork, "account", 3*time.Hour),
+			},
+			wantRule: []string{RuleNewCountry},
+			wantKey:  []string{user + "/US"},
+		},
+		{
+			name: "new client",
+			opts: LoginAnomalyOptions{NewClient: true},
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				login(berlin, "account", time.Hour),
+				login(berlin, "admin-console", 2*time.Hour),
+			},
+			wantRule: []string{RuleNewClient},
+			wantKey:  []string{user + "/admin-console"},
+		},
+		{
+			name:   "redelivered login",
+			opts:   LoginAnomalyOptions{MaxSpeed: 900, NewCountry: true, NewClient: true},
+			events: []*internal.Event{first, repeat(first), repeat(first)},
+		},
+		{
+			name: "other event types",
+			opts: LoginAnomalyOptions{NewClient: true},
+			events: []*internal.Event{
+				login(berlin, "account", 0),
+				{Id: uuid.New(), Time: start, Type: internal.EventTypeCodeToToken, RealmName: "master", ClientId: "other", UserId: john},
+				{Id: uuid.New(), Time: start, Type: internal.EventTypeLogin, RealmName: "master", ClientId: "other"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var alerts []*internal.Alert
+			detector, err := NewLoginAnomaly(tt.opts, NewMemoryHistory(), recordAlerts(&alerts))
+			require.NoError(t, err)
+
+			for _, event := range tt.events {
+				require.NoError(t, detector.Observe(event))
+			}
+
+			var rules, keys []string
+			for _, alert := range alerts {
+				rules = append(rules, alert.Rule)
+				keys = append(keys, alert.Key)
+				assert.Equal(t, john, alert.UserId)
+				assert.Equal(t, "Firefox", alert.Details["user_agent"])
+				assert.NotEmpty(t, alert.Details["previous_event_id"])
+				assert.NotEmpty(t, alert.Description)
+			}
+			assert.Equal(t, tt.wantRule, rules)
+			assert.Equal(t, tt.wantKey, keys)
+		})
+	}
+}
+
+func TestLoginAnomaly_ImpossibleTravelAlert(t *testing.T) {
+	t.Parallel()
+
+	var alerts []*internal.Alert
+	detector, err := NewLoginAnomaly(LoginAnomalyOptions{MaxSpeed: 900, Cooldown: time.Hour}, NewMemoryHistory(), recordAlerts(&alerts))
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	userId, previousId := uuid.New(), uuid.New()
+	require.NoError(t, detector.Observe(&internal.Event{
+		Id:         previousId,
+		Time:       start,
+		Type:       internal.EventTypeLogin,
+		RealmName:  "master",
+		UserId:     userId,
+		IpAddress:  "198.51.100.1",
+		Enrichment: &internal.Enrichment{Geo: &internal.Geo{CountryCode: "DE", Latitude: 52.52, Longitude: 13.405}},
+	}))
+	require.NoError(t, detector.Observe(&internal.Event{
+		Id:         uuid.New(),
+		Time:       start.Add(30 * time.Minute),
+		Type:       internal.EventTypeLogin,
+		RealmName:  "master",
+		UserId:     uuid.New(),
+		IpAddress:  "198.51.100.1",
+		Enrichment: &internal.Enrichment{Geo: &internal.Geo{CountryCode: "US", Latitude: 40.7128, Longitude: -74.006}},
+	}))
+	require.Empty(t, alerts, "history of other user")
+
+	at := start.Add(30 * time.Minute)
+	require.NoError(t, detector.Observe(&internal.Event{
+		Id:         uuid.New(),
+		Time:       at,
+		Type:       internal.EventTypeLogin,
+		RealmName:  "master",
+		UserId:     userId,
+		IpAddress:  "203.0.113.7",
+		Enrichment: &internal.Enrichment{Geo: &internal.Geo{CountryCode: "FR", Latitude: 48.8566, Longitude: 2.3522}},
+	}))
+	require.Len(t, alerts, 1)
+
+	alert := alerts[0]
+	assert.Equal(t, internal.AlertSeverityHigh, alert.Severity)
+	assert.Equal(t, "203.0.113.7", alert.IpAddress)
+	assert.InDelta(t, 1756, alert.Value, 10)
+	assert.Equal(t, float64(900), alert.Threshold)
+	assert.Equal(t, at.Add(time.Hour), alert.ExpiresAt)
+	assert.Equal(t, map[string]string{
+		"previous_event_id":   previousId.String(),
+		"previous_time":       "2024-01-15T10:00:00Z",
+		"previous_ip_address": "198.51.100.1",
+		"previous_country":    "DE",
+		"country":             "FR",
+		"distance_km":         "877",
+	}, alert.Details)
+	require.NotNil(t, alert.Enrichment)
+	assert.Equal(t, "FR", alert.Enrichment.Geo.CountryCode)
+}
+
+func TestNewLoginAnomaly_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts LoginAnomalyOptions
+	}{
+		{name: "no rules"},
+		{name: "negative speed", opts: LoginAnomalyOptions{MaxSpeed: -1}},
+		{name: "negative min distance", opts: LoginAnomalyOptions{MaxSpeed: 900, MinDistance: -1}},
+		{name: "min history exceeds size", opts: LoginAnomalyOptions{NewCountry: true, MinHistory: 10, HistorySize: 5}},
+		{name: "negative retention", opts: LoginAnomalyOptions{NewClient: true, Retention: -time.Hour}},
+		{name: "negative cooldown", opts: LoginAnomalyOptions{NewClient: true, Cooldown: -time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewLoginAnomaly(tt.opts, NewMemoryHistory(), recordAlerts(new([]*internal.Alert)))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package detect
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"keycloak-events-adapter/internal"
+)
+
+const (
+	RuleUserFailures       = "user_failures"
+	RuleIPUsers            = "ip_users"
+	RuleClientFailureRatio = "client_failure_ratio"
+)
+
+const defaultCooldown = 15 * time.Minute
+
+// AlertRaiser принимает тревоги детекторов
+type AlertRaiser interface {
+	Raise(alert *internal.Alert) error
+}
+
+// Threshold порог числа событий в окне, Count = 0 отключает правило
+type Threshold struct {
+	Count  int           `yaml:"count"`
+	Window time.Duration `yaml:"window"`
+}
+
+func (t *Threshold) validate() error {
+	if t.Count < 0 {
+		return fmt.Errorf("count %d is negative", t.Count)
+	}
+	if t.Count > 0 && t.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+
+	return nil
+}
+
+// RatioThreshold порог доли ошибок в окне, Ratio = 0 отключает правило
+type RatioThreshold struct {
+	Ratio float64 `yaml:"ratio"`
+	// MinEvents минимальное число входов в окне, при котором проверяется доля ошибок
+	MinEvents int           `yaml:"min_events"`
+	Window    time.Duration `yaml:"window"`
+}
+
+func (t *RatioThreshold) validate() error {
+	if t.Ratio == 0 {
+		return nil
+	}
+	if t.Ratio < 0 || t.Ratio > 1 {
+		return fmt.Errorf("ratio %v is out of (0, 1]", t.Ratio)
+	}
+	if t.MinEvents <= 0 {
+		return errors.New("min_events must be positive")
+	}
+	if t.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+
+	return nil
+}
+
+// BruteForceOptions правила детектора подбора паролей
+type BruteForceOptions struct {
+	// UserFailures неудачные входы пользователя в realm
+	UserFailures Threshold `yaml:"user_failures"`
+	// IPUsers различные пользователи с неудачными входами с одного IP адреса
+	IPUsers Threshold `yaml:"ip_users"`
+	// ClientFailureRatio доля неудачных входов в клиент realm
+	ClientFailureRatio RatioThreshold `yaml:"client_failure_ratio"`
+	// Cooldown время, в течение которого тревога остается активной после последнего срабатывания
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+func (o *BruteForceOptions) validate() error {
+	if o.UserFailures.Count == 0 && o.IPUsers.Count == 0 && o.ClientFailureRatio.Ratio == 0 {
+		return errors.New("no rules are enabled")
+	}
+
+	err := o.UserFailures.validate()
+	if err != nil {
+		return fmt.Errorf("%s: %w", RuleUserFailures, err)
+	}
+	err = o.IPUsers.validate()
+	if err != nil {
+		return fmt.Errorf("%s: %w", RuleIPUsers, err)
+	}
+	err = o.ClientFailureRatio.validate()
+	if err != nil {
+		return fmt.Errorf("%s: %w", RuleClientFailureRatio, err)
+	}
+
+	if o.Cooldown < 0 {
+		return errors.New("cooldown is negative")
+	}
+	if o.Cooldown == 0 {
+		o.Cooldown = defaultCooldown
+	}
+
+	return nil
+}
+
+// BruteForce обнаруживает подбор паролей по событиям LOGIN и LOGIN_ERROR: много неудачных входов пользователя,
+// неудачные входы многих пользователей с одного адреса (credential stuffing) и рост доли ошибок входа в клиент.
+// Окна отсчитываются по времени событий.
+type BruteForce struct {
+	opts    BruteForceOptions
+	windows Windows
+	alerts  AlertRaiser
+}
+
+func NewBruteForce(opts BruteForceOptions, windows Windows, alerts AlertRaiser) (*BruteForce, error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &BruteForce{
+		opts:    opts,
+		windows: windows,
+		alerts:  alerts,
+	}, nil
+}
+
+func (b *BruteForce) Observe(event *internal.Event) error {
+	var failed bool
+	switch event.Type {
+	case internal.EventTypeLoginError:
+		failed = true
+	case internal.EventTypeLogin:
+	default:
+		return nil
+	}
+
+	at := event.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	realm := realmOf(event)
+	user := userOf(event)
+
+	var errs []error
+	if failed && b.opts.UserFailures.Count > 0 && user != "" {
+		errs = append(errs, b.checkUser(event, realm, user, at))
+	}
+	if failed && b.opts.IPUsers.Count > 0 && user != "" && event.IpAddress != "" {
+		errs = append(errs, b.checkIP(event, realm, user, at))
+	}
+	if b.opts.ClientFailureRatio.Ratio > 0 && event.ClientId != "" {
+		errs = append(errs, b.checkClient(event, realm, failed, at))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (b *BruteForce) checkUser(event *internal.Event, realm, user string, at time.Time) error {
+	t := b.opts.UserFailures
+	key := realm + "/" + user
+	count, err := b.windows.Add(RuleUserFailures+"/"+key, event.Id.String(), at, t.Window)
+	if err != nil {
+		return err
+	}
+	if count < t.Count {
+		return nil
+	}
+
+	alert := b.alert(event, RuleUserFailures, internal.AlertSeverityMedium, key, at)
+	alert.UserId = event.UserId
+	if event.UserId == uuid.Nil {
+		alert.Details = map[string]string{"username": user}
+	}
+	alert.Value, alert.Threshold = float64(count), float64(t.Count)
+	alert.Description = fmt.Sprintf("%d failed logins of user in realm %s within %s", count, realm, t.Window)
+
+	return b.alerts.Raise(alert)
+}
+
+func (b *BruteForce) checkIP(event *internal.Event, realm, user string, at time.Time) error {
+	t := b.opts.IPUsers
+	count, err := b.windows.Add(RuleIPUsers+"/"+event.IpAddress, realm+"/"+user, at, t.Window)
+	if err != nil {
+		return err
+	}
+	if count < t.Count {
+		return nil
+	}
+
+	alert := b.alert(event, RuleIPUsers, internal.AlertSeverityHigh, event.IpAddress, at)
+	alert.Value, alert.Threshold = float64(count), float64(t.Count)
+	alert.Description = fmt.Sprintf("failed logins of %d users from ip address within %s", count, t.Window)
+
+	return b.alerts.Raise(alert)
+}
+
+func (b *BruteForce) checkClient(event *internal.Event, realm string, failed bool, at time.Time) error {
+	t := b.opts.ClientFailureRatio
+	key := realm + "/" + event.ClientId
+
+	// входы в клиент считаются счетчиками: окно с отметкой на каждый вход росло бы с нагрузкой на клиент
+	total, err := b.windows.Increment(RuleClientFailureRatio+"/all/"+key, at, t.Window)
+	if err != nil || !failed {
+		return err
+	}
+	failures, err := b.windows.Increment(RuleClientFailureRatio+"/failed/"+key, at, t.Window)
+	if err != nil {
+		return err
+	}
+
+	ratio := float64(failures) / float64(total)
+	if total < t.MinEvents || ratio < t.Ratio {
+		return nil
+	}
+
+	alert := b.alert(event, RuleClientFailureRatio, internal.AlertSeverityHigh, key, at)
+	alert.ClientId = event.ClientId
+	alert.Value, alert.Threshold = ratio, t.Ratio
+	alert.Description = fmt.Sprintf("%d of %d logins to client %s in realm %s failed within %s",
+		failures, total, event.ClientId, realm, t.Window)
+
+	return b.alerts.Raise(alert)
+}
+
+// alert создает тревогу с атрибутами realm и адресом события, атрибуты объекта тревоги заполняет правило.
+// Пользователь и адрес передаются только полями тревоги, которые обрабатывает redact, но не описанием.
+func (b *BruteForce) alert(event *internal.Event, rule string, severity internal.AlertSeverity, key string, at time.Time) *internal.Alert {
+	return &internal.Alert{
+		Id:         uuid.New(),
+		Rule:       rule,
+		Severity:   severity,
+		Key:        key,
+		RealmId:    event.RealmId,
+		RealmName:  event.RealmName,
+		IpAddress:  event.IpAddress,
+		FirstSeen:  at,
+		LastSeen:   at,
+		ExpiresAt:  at.Add(b.opts.Cooldown),
+		Enrichment: internal.AlertEnrichmentOf(event),
+	}
+}
+
+func realmOf(event *internal.Event) string {
+	if event.RealmName != "" {
+		return event.RealmName
+	}
+
+	return event.RealmId.String()
+}
+
+// userOf возвращает ID пользователя, а для неизвестного Keycloak пользователя — введенное имя
+func userOf(event *internal.Event) string {
+	if event.UserId != uuid.Nil {
+		return event.UserId.String()
+	}
+
+	return event.Details["username"]
+}
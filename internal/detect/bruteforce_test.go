@@ -0,0 +1,201 @@
+package detect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"keycloak-events-adapter/internal"
+)
+
+type alertsFunc func(alert *internal.Alert) error
+
+func (f alertsFunc) Raise(alert *internal.Alert) error {
+	return f(alert)
+}
+
+// recordAlerts возвращает приемник, сохраняющий только новые тревоги
+func recordAlerts(alerts *[]*internal.Alert) AlertRaiser {
+	store := NewMemoryAlerts()
+	return alertsFunc(func(alert *internal.Alert) error {
+		isNew, err := store.Raise(alert)
+		if isNew {
+			*alerts = append(*alerts, alert)
+		}
+		return err
+	})
+}
+
+func TestBruteForce_Observe(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	john, jane := uuid.New(), uuid.New()
+
+	login := func(eventType internal.EventType, userId uuid.UUID, ip, client string, offset time.Duration) *internal.Event {
+		return &internal.Event{
+			Id:        uuid.New(),
+			Time:      start.Add(offset),
+			Type:      eventType,
+			RealmName: "master",
+			ClientId:  client,
+			UserId:    userId,
+			IpAddress: ip,
+		}
+	}
+	repeat := func(event *internal.Event) *internal.Event {
+		again := *event
+		return &again
+	}
+	failure := login(internal.EventTypeLoginError, john, "10.0.0.1", "", 0)
+
+	tests := []struct {
+		name     string
+		opts     BruteForceOptions
+		events   []*internal.Event
+		wantRule []string
+		wantKey  []string
+		// wantUsername введенное имя неизвестного пользователя в деталях тревоги
+		wantUsername string
+	}{
+		{
+			name: "user failures",
+			opts: BruteForceOptions{UserFailures: Threshold{Count: 3, Window: 5 * time.Minute}},
+			events: []*internal.Event{
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "", 0),
+				login(internal.EventTypeLogin, john, "10.0.0.1", "", time.Minute),
+				login(internal.EventTypeLoginError, john, "10.0.0.2", "", 2*time.Minute),
+				login(internal.EventTypeLoginError, jane, "10.0.0.2", "", 2*time.Minute),
+				login(internal.EventTypeLoginError, john, "10.0.0.3", "", 3*time.Minute),
+				// повторные срабатывания не создают новую тревогу
+				login(internal.EventTypeLoginError, john, "10.0.0.3", "", 4*time.Minute),
+			},
+			wantRule: []string{RuleUserFailures},
+			wantKey:  []string{"master/" + john.String()},
+		},
+		{
+			name: "user failures outside window",
+			opts: BruteForceOptions{UserFailures: Threshold{Count: 3, Window: 5 * time.Minute}},
+			events: []*internal.Event{
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "", 0),
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "", 4*time.Minute),
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "", 9*time.Minute),
+			},
+		},
+		{
+			name:   "redelivered event",
+			opts:   BruteForceOptions{UserFailures: Threshold{Count: 2, Window: 5 * time.Minute}},
+			events: []*internal.Event{failure, repeat(failure), repeat(failure)},
+		},
+		{
+			name: "unknown user by username",
+			opts: BruteForceOptions{UserFailures: Threshold{Count: 2, Window: 5 * time.Minute}},
+			events: []*internal.Event{
+				{Id: uuid.New(), Time: start, Type: internal.EventTypeLoginError, RealmName: "master", Details: map[string]string{"username": "root"}},
+				{Id: uuid.New(), Time: start, Type: internal.EventTypeLoginError, RealmName: "master", Details: map[string]string{"username": "root"}},
+			},
+			wantRule:     []string{RuleUserFailures},
+			wantKey:      []string{"master/root"},
+			wantUsername: "root",
+		},
+		{
+			name: "ip users",
+			opts: BruteForceOptions{IPUsers: Threshold{Count: 2, Window: 5 * time.Minute}},
+			events: []*internal.Event{
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "", 0),
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "", time.Minute),
+				login(internal.EventTypeLoginError, jane, "10.0.0.2", "", time.Minute),
+				login(internal.EventTypeLogin, jane, "10.0.0.1", "", time.Minute),
+				login(internal.EventTypeLoginError, jane, "10.0.0.1", "", 2*time.Minute),
+			},
+			wantRule: []string{RuleIPUsers},
+			wantKey:  []string{"10.0.0.1"},
+		},
+		{
+			name: "client failure ratio",
+			opts: BruteForceOptions{ClientFailureRatio: RatioThreshold{Ratio: 0.5, MinEvents: 4, Window: 5 * time.Minute}},
+			events: []*internal.Event{
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "app", 0),
+				login(internal.EventTypeLoginError, jane, "10.0.0.1", "app", 0),
+				login(internal.EventTypeLogin, john, "10.0.0.1", "app", time.Minute),
+				login(internal.EventTypeLogin, jane, "10.0.0.1", "app", time.Minute),
+				login(internal.EventTypeLogin, jane, "10.0.0.1", "app", time.Minute),
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "other", time.Minute),
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "app", 2*time.Minute),
+			},
+			wantRule: []string{RuleClientFailureRatio},
+			wantKey:  []string{"master/app"},
+		},
+		{
+			name: "client failure ratio below min events",
+			opts: BruteForceOptions{ClientFailureRatio: RatioThreshold{Ratio: 0.5, MinEvents: 4, Window: 5 * time.Minute}},
+			events: []*internal.Event{
+				login(internal.EventTypeLoginError, john, "10.0.0.1", "app", 0),
+				login(internal.EventTypeLoginError, jane, "10.0.0.1", "app", 0),
+				login(internal.EventTypeLoginError, jane, "10.0.0.1", "app", time.Minute),
+			},
+		},
+		{
+			name: "other event types",
+			opts: BruteForceOptions{UserFailures: Threshold{Count: 1, Window: 5 * time.Minute}},
+			events: []*internal.Event{
+				login(internal.EventTypeCodeToTokenError, john, "10.0.0.1", "app", 0),
+				login(internal.EventTypeLogin, john, "10.0.0.1", "app", 0),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var alerts []*internal.Alert
+			detector, err := NewBruteForce(tt.opts, NewMemoryWindows(), recordAlerts(&alerts))
+			require.NoError(t, err)
+
+			for _, event := range tt.events {
+				require.NoError(t, detector.Observe(event))
+			}
+
+			var rules, keys []string
+			for _, alert := range alerts {
+				rules = append(rules, alert.Rule)
+				keys = append(keys, alert.Key)
+				assert.Equal(t, "master", alert.RealmName)
+				assert.Equal(t, alert.LastSeen.Add(defaultCooldown), alert.ExpiresAt)
+				assert.NotEmpty(t, alert.Description)
+				assert.Equal(t, tt.wantUsername, alert.Details["username"])
+
+				// пользователь и адрес передаются только полями, которые обрабатывает redact
+				event := alert.Event()
+				assert.NotContains(t, event.Details, "alert_key")
+				for _, pii := range []string{"root", john.String(), jane.String(), "10.0.0.1"} {
+					assert.NotContains(t, event.Details["alert_description"], pii)
+				}
+			}
+			assert.Equal(t, tt.wantRule, rules)
+			assert.Equal(t, tt.wantKey, keys)
+		})
+	}
+}
+
+func TestNewBruteForce_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts BruteForceOptions
+	}{
+		{name: "no rules"},
+		{name: "negative count", opts: BruteForceOptions{UserFailures: Threshold{Count: -1, Window: time.Minute}}},
+		{name: "no window", opts: BruteForceOptions{IPUsers: Threshold{Count: 10}}},
+		{name: "ratio above one", opts: BruteForceOptions{ClientFailureRatio: RatioThreshold{Ratio: 2, MinEvents: 10, Window: time.Minute}}},
+		{name: "no min events", opts: BruteForceOptions{ClientFailureRatio: RatioThreshold{Ratio: 0.5, Window: time.Minute}}},
+		{name: "negative cooldown", opts: BruteForceOptions{UserFailures: Threshold{Count: 5, Window: time.Minute}, Cooldown: -time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewBruteForce(tt.opts, NewMemoryWindows(), recordAlerts(new([]*internal.Alert)))
+			assert.Error(t, err)
+		})
+	}
+}
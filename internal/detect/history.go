@@ -0,0 +1,103 @@
+package detect
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Location координаты входа и радиус точности в км
+type Location struct {
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	AccuracyRadius uint16  `json:"accuracy_radius,omitempty"`
+}
+
+// Login вход пользователя в истории входов
+type Login struct {
+	EventId     string    `json:"event_id"`
+	Time        time.Time `json:"time"`
+	IpAddress   string    `json:"ip_address,omitempty"`
+	ClientId    string    `json:"client_id,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	CountryCode string    `json:"country_code,omitempty"`
+	Location    *Location `json:"location,omitempty"`
+}
+
+// History истории последних входов пользователей
+type History interface {
+	// Add добавляет вход в историю key и возвращает остальные входы истории, последние первыми. История хранит
+	// не больше size последних входов, включая добавленный, и не старше retention от последнего входа.
+	// Повторно доставленный вход с тем же EventId историю не меняет.
+	Add(key string, login Login, size int, retention time.Duration) ([]Login, error)
+}
+
+type memoryHistory struct {
+	logins    []Login
+	expiresAt time.Time
+}
+
+// MemoryHistory истории входов в памяти процесса: для тестов и единственной реплики адаптера
+type MemoryHistory struct {
+	mu        sync.Mutex
+	histories map[string]*memoryHistory
+	nextSweep time.Time
+}
+
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{histories: map[string]*memoryHistory{}}
+}
+
+func (h *MemoryHistory) Add(key string, login Login, size int, retention time.Duration) ([]Login, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sweep(login.Time)
+
+	hist, ok := h.histories[key]
+	if !ok {
+		hist = &memoryHistory{}
+		h.histories[key] = hist
+	}
+
+	if !slices.ContainsFunc(hist.logins, func(l Login) bool { return l.EventId == login.EventId }) {
+		hist.logins = append(hist.logins, login)
+		sortLogins(hist.logins)
+	}
+
+	since := hist.logins[0].Time.Add(-retention)
+	hist.logins = slices.DeleteFunc(hist.logins, func(l Login) bool {
+		return l.Time.Before(since)
+	})
+	if len(hist.logins) > size {
+		hist.logins = hist.logins[:size]
+	}
+	hist.expiresAt = hist.logins[0].Time.Add(retention)
+
+	previous := slices.DeleteFunc(slices.Clone(hist.logins), func(l Login) bool {
+		return l.EventId == login.EventId
+	})
+
+	return previous, nil
+}
+
+func (h *MemoryHistory) sweep(now time.Time) {
+	if now.Before(h.nextSweep) {
+		return
+	}
+	h.nextSweep = now.Add(sweepInterval)
+
+	for key, hist := range h.histories {
+		if hist.expiresAt.Before(now) {
+			delete(h.histories, key)
+		}
+	}
+}
+
+// sortLogins упорядочивает входы от последних к более ранним
+func sortLogins(logins []Login) {
+	slices.SortStableFunc(logins, func(a, b Login) int {
+		return cmp.Or(b.Time.Compare(a.Time), cmp.Compare(b.EventId, a.EventId))
+	})
+}
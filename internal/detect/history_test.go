@@ -0,0 +1,52 @@
+package detect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryHistory_Add(t *testing.T) {
+	t.Parallel()
+
+	history := NewMemoryHistory()
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		key     string
+		eventId string
+		offset  time.Duration
+		want    []string
+	}{
+		{key: "a", eventId: "1"},
+		{key: "a", eventId: "2", offset: time.Hour, want: []string{"1"}},
+		// повторно доставленный вход не попадает в историю дважды
+		{key: "a", eventId: "2", offset: time.Hour, want: []string{"1"}},
+		{key: "b", eventId: "1", offset: time.Hour},
+		// вход, доставленный с опозданием, занимает место по времени
+		{key: "a", eventId: "3", offset: 30 * time.Minute, want: []string{"2", "1"}},
+		// история ограничена тремя входами, включая добавленный
+		{key: "a", eventId: "4", offset: 2 * time.Hour, want: []string{"2", "3"}},
+		{key: "a", eventId: "5", offset: 3 * time.Hour, want: []string{"4", "2"}},
+		// входы старше суток до последнего входа удаляются
+		{key: "a", eventId: "6", offset: 26 * time.Hour, want: []string{"5", "4"}},
+		{key: "a", eventId: "7", offset: 27 * time.Hour, want: []string{"6", "5"}},
+		{key: "a", eventId: "8", offset: 28*time.Hour + time.Minute, want: []string{"7", "6"}},
+		{key: "a", eventId: "9", offset: 52 * time.Hour, want: []string{"8"}},
+	}
+	for _, step := range steps {
+		previous, err := history.Add(step.key, Login{EventId: step.eventId, Time: start.Add(step.offset)}, 3, 24*time.Hour)
+		require.NoError(t, err)
+
+		var got []string
+		for _, l := range previous {
+			got = append(got, l.EventId)
+		}
+		assert.Equal(t, step.want, got, "%s/%s at %s", step.key, step.eventId, step.offset)
+	}
+
+	// история b удалена как устаревшая
+	assert.NotContains(t, history.histories, "b")
+}
@@ -0,0 +1,163 @@
+package detect
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// sweepInterval период удаления окон, в которые давно ничего не добавлялось
+const sweepInterval = time.Minute
+
+// WindowBuckets число интервалов, на которые делится окно счетчика. Счетчик учитывает события
+// последних WindowBuckets интервалов, поэтому фактическая длина окна — от (WindowBuckets-1)/WindowBuckets окна до окна.
+const WindowBuckets = 10
+
+// Windows скользящие окна детекторов
+type Windows interface {
+	// Add добавляет в окно key отметку member со временем at, удаляет отметки старше at-window
+	// и возвращает число различных отметок в окне. Окно хранит различные отметки с временем последнего
+	// появления, поэтому повторно доставленное событие не учитывается дважды.
+	Add(key, member string, at time.Time, window time.Duration) (int, error)
+	// Increment увеличивает счетчик key в интервале времени at и возвращает сумму последних WindowBuckets
+	// интервалов окна window. Счетчик занимает память по числу интервалов, а не событий, но не отличает
+	// повторно доставленное событие.
+	Increment(key string, at time.Time, window time.Duration) (int, error)
+}
+
+// WindowBucket длина интервала счетчика окна window в миллисекундах
+func WindowBucket(window time.Duration) int64 {
+	return max((window / WindowBuckets).Milliseconds(), 1)
+}
+
+// windowMark отметка окна в очереди по времени, отметка устарела, если член окна появился позже
+type windowMark struct {
+	member string
+	at     time.Time
+}
+
+// windowMarks очередь отметок, первой — самая ранняя
+type windowMarks []windowMark
+
+func (m windowMarks) Len() int           { return len(m) }
+func (m windowMarks) Less(i, j int) bool { return m[i].at.Before(m[j].at) }
+func (m windowMarks) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m *windowMarks) Push(x any)        { *m = append(*m, x.(windowMark)) }
+func (m *windowMarks) Pop() any {
+	old := *m
+	mark := old[len(old)-1]
+	*m = old[:len(old)-1]
+	return mark
+}
+
+type memoryWindow struct {
+	members   map[string]time.Time
+	marks     windowMarks
+	expiresAt time.Time
+}
+
+type memoryCounter struct {
+	buckets   map[int64]int
+	expiresAt time.Time
+}
+
+// MemoryWindows окна в памяти процесса: для тестов и единственной реплики адаптера
+type MemoryWindows struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	counters  map[string]*memoryCounter
+	nextSweep time.Time
+}
+
+func NewMemoryWindows() *MemoryWindows {
+	return &MemoryWindows{
+		windows:  map[string]*memoryWindow{},
+		counters: map[string]*memoryCounter{},
+	}
+}
+
+func (w *MemoryWindows) Add(key, member string, at time.Time, window time.Duration) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sweep(at)
+
+	win, ok := w.windows[key]
+	if !ok {
+		win = &memoryWindow{members: map[string]time.Time{}}
+		w.windows[key] = win
+	}
+	if last, ok := win.members[member]; !ok || at.After(last) {
+		win.members[member] = at
+		heap.Push(&win.marks, windowMark{member: member, at: at})
+	}
+	win.expiresAt = maxTime(win.expiresAt, at.Add(window))
+
+	// удаляются только отметки старше окна, начиная с самой ранней
+	since := at.Add(-window)
+	for win.marks.Len() > 0 && win.marks[0].at.Before(since) {
+		mark := heap.Pop(&win.marks).(windowMark)
+		if win.members[mark.member].Equal(mark.at) {
+			delete(win.members, mark.member)
+		}
+	}
+
+	return len(win.members), nil
+}
+
+func (w *MemoryWindows) Increment(key string, at time.Time, window time.Duration) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sweep(at)
+
+	counter, ok := w.counters[key]
+	if !ok {
+		counter = &memoryCounter{buckets: map[int64]int{}}
+		w.counters[key] = counter
+	}
+
+	bucket := WindowBucket(window)
+	slot := at.UnixMilli() / bucket
+	counter.buckets[slot]++
+	counter.expiresAt = maxTime(counter.expiresAt, time.UnixMilli((slot+WindowBuckets)*bucket))
+
+	total := 0
+	for s, count := range counter.buckets {
+		switch {
+		case s > slot:
+		case s > slot-WindowBuckets:
+			total += count
+		default:
+			delete(counter.buckets, s)
+		}
+	}
+
+	return total, nil
+}
+
+func (w *MemoryWindows) sweep(now time.Time) {
+	if now.Before(w.nextSweep) {
+		return
+	}
+	w.nextSweep = now.Add(sweepInterval)
+
+	for key, win := range w.windows {
+		if win.expiresAt.Before(now) {
+			delete(w.windows, key)
+		}
+	}
+	for key, counter := range w.counters {
+		if counter.expiresAt.Before(now) {
+			delete(w.counters, key)
+		}
+	}
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
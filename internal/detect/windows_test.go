@@ -0,0 +1,98 @@
+package detect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryWindows_Add(t *testing.T) {
+	t.Parallel()
+
+	windows := NewMemoryWindows()
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		key    string
+		member string
+		offset time.Duration
+		want   int
+	}{
+		{key: "a", member: "1", want: 1},
+		{key: "a", member: "2", offset: time.Minute, want: 2},
+		// повторная отметка не увеличивает окно, но продлевает ее
+		{key: "a", member: "1", offset: 2 * time.Minute, want: 2},
+		{key: "b", member: "1", offset: 2 * time.Minute, want: 1},
+		// отметка 2 старше окна
+		{key: "a", member: "3", offset: 7 * time.Minute, want: 2},
+		{key: "a", member: "4", offset: 20 * time.Minute, want: 1},
+	}
+	for _, step := range steps {
+		got, err := windows.Add(step.key, step.member, start.Add(step.offset), 5*time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, step.want, got, "%s/%s at %s", step.key, step.member, step.offset)
+	}
+
+	// окно b удалено как устаревшее
+	assert.NotContains(t, windows.windows, "b")
+}
+
+func TestMemoryWindows_AddOutOfOrder(t *testing.T) {
+	t.Parallel()
+
+	windows := NewMemoryWindows()
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// отметка 1 продлена позже отметки 2, устаревшая запись очереди не удаляет ее из окна
+	for _, step := range []struct {
+		member string
+		offset time.Duration
+		want   int
+	}{
+		{member: "1", want: 1},
+		{member: "2", offset: time.Minute, want: 2},
+		{member: "1", offset: 4 * time.Minute, want: 2},
+		{member: "3", offset: 3 * time.Minute, want: 3},
+		{member: "4", offset: 7 * time.Minute, want: 3},
+		{member: "5", offset: 8*time.Minute + time.Second, want: 3},
+	} {
+		got, err := windows.Add("a", step.member, start.Add(step.offset), 5*time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, step.want, got, "%s at %s", step.member, step.offset)
+	}
+	assert.Len(t, windows.windows["a"].marks, 3)
+}
+
+func TestMemoryWindows_Increment(t *testing.T) {
+	t.Parallel()
+
+	windows := NewMemoryWindows()
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// окно 10 минут делится на интервалы по минуте
+	steps := []struct {
+		key    string
+		offset time.Duration
+		want   int
+	}{
+		{key: "a", want: 1},
+		{key: "a", offset: 30 * time.Second, want: 2},
+		{key: "a", offset: 5 * time.Minute, want: 3},
+		{key: "b", offset: 5 * time.Minute, want: 1},
+		// событие из прошлого учитывается в своем интервале
+		{key: "a", offset: 2 * time.Minute, want: 3},
+		// интервал первой минуты вышел из окна
+		{key: "a", offset: 10 * time.Minute, want: 3},
+		{key: "a", offset: 30 * time.Minute, want: 1},
+	}
+	for _, step := range steps {
+		got, err := windows.Increment(step.key, start.Add(step.offset), 10*time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, step.want, got, "%s at %s", step.key, step.offset)
+	}
+
+	assert.Len(t, windows.counters["a"].buckets, 1)
+	assert.NotContains(t, windows.counters, "b")
+}
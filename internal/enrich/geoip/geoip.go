@@ -0,0 +1,226 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+)
+
+type Options struct {
+	// CityFile база City (GeoLite2-City, GeoIP2-City): страна, город и координаты
+	CityFile string
+	// ASNFile база ASN (GeoLite2-ASN): номер и организация автономной системы
+	ASNFile string
+	// ReloadInterval период проверки изменения файлов, 0 отключает перечитывание
+	ReloadInterval time.Duration
+}
+
+func (o Options) validate() error {
+	if o.CityFile == "" && o.ASNFile == "" {
+		return errors.New("neither city nor asn database is set")
+	}
+	if o.ReloadInterval < 0 {
+		return errors.New("reload interval is negative")
+	}
+
+	return nil
+}
+
+// record поля баз City и ASN, одна запись заполняется из обеих баз
+type record struct {
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude       float64 `maxminddb:"latitude"`
+		Longitude      float64 `maxminddb:"longitude"`
+		AccuracyRadius uint16  `maxminddb:"accuracy_radius"`
+	} `maxminddb:"location"`
+	ASN            uint32 `maxminddb:"autonomous_system_number"`
+	ASOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// database файл mmdb, который перечитывается при изменении времени модификации или размера
+type database struct {
+	path    string
+	reader  atomic.Pointer[maxminddb.Reader]
+	modTime time.Time
+	size    int64
+}
+
+func openDatabase(path string) (*database, error) {
+	db := &database{path: path}
+	_, err := db.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// reload загружает файл, если он изменился. Файл читается в память целиком,
+// поэтому поиск по старой версии безопасно завершается во время замены.
+func (d *database) reload() (bool, error) {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return false, fmt.Errorf("can't stat %s: %w", d.path, err)
+	}
+	if info.ModTime().Equal(d.modTime) && info.Size() == d.size {
+		return false, nil
+	}
+
+	buf, err := os.ReadFile(d.path)
+	if err != nil {
+		return false, fmt.Errorf("can't read %s: %w", d.path, err)
+	}
+
+	reader, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		return false, fmt.Errorf("can't open %s: %w", d.path, err)
+	}
+
+	d.reader.Store(reader)
+	d.modTime = info.ModTime()
+	d.size = info.Size()
+
+	return true, nil
+}
+
+func (d *database) lookup(ip net.IP, rec *record) error {
+	if d == nil {
+		return nil
+	}
+
+	return d.reader.Load().Lookup(ip, rec)
+}
+
+// DB определяет геолокацию IP адресов по локальным базам MaxMind
+type DB struct {
+	city   *database
+	asn    *database
+	logger *zap.Logger
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func New(opts Options, logger *zap.Logger) (*DB, error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+
+	if opts.CityFile != "" {
+		db.city, err = openDatabase(opts.CityFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.ASNFile != "" {
+		db.asn, err = openDatabase(opts.ASNFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.ReloadInterval > 0 {
+		db.wg.Add(1)
+		go db.watch(opts.ReloadInterval)
+	}
+
+	return db, nil
+}
+
+// Lookup возвращает геолокацию адреса. Для непубличных адресов заполняется только Scope,
+// для некорректного или пустого адреса возвращается nil.
+func (d *DB) Lookup(ip string) *internal.Geo {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	addr = addr.Unmap()
+
+	scope := ScopeOf(addr)
+	if scope != internal.GeoScopePublic {
+		return &internal.Geo{Scope: scope}
+	}
+
+	var rec record
+	netIP := net.IP(addr.AsSlice())
+	for _, db := range []*database{d.city, d.asn} {
+		err = db.lookup(netIP, &rec)
+		if err != nil {
+			d.logger.Warn("can't lookup ip address", zap.String("ip_address", ip), zap.Error(err))
+		}
+	}
+
+	return &internal.Geo{
+		Scope:          scope,
+		CountryCode:    rec.Country.IsoCode,
+		CountryName:    rec.Country.Names["en"],
+		City:           rec.City.Names["en"],
+		Latitude:       rec.Location.Latitude,
+		Longitude:      rec.Location.Longitude,
+		AccuracyRadius: rec.Location.AccuracyRadius,
+		ASN:            rec.ASN,
+		ASOrganization: rec.ASOrganization,
+	}
+}
+
+func (d *DB) Close() error {
+	d.closeOnce.Do(func() {
+		close(d.done)
+	})
+	d.wg.Wait()
+
+	return nil
+}
+
+func (d *DB) watch(interval time.Duration) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, db := range []*database{d.city, d.asn} {
+			if db == nil {
+				continue
+			}
+
+			reloaded, err := db.reload()
+			if err != nil {
+				d.logger.Error("can't reload geoip database, previous version is used", zap.Error(err))
+				continue
+			}
+			if reloaded {
+				d.logger.Info("geoip database reloaded", zap.String("path", db.path))
+			}
+		}
+	}
+}
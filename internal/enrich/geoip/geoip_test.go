@@ -0,0 +1,223 @@
+package geoip
+
+import (
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+)
+
+func writeDB(t *testing.T, path, databaseType string, records map[string]mmdbtype.Map) {
+	t.Helper()
+
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: databaseType, RecordSize: 24})
+	require.NoError(t, err)
+
+	for cidr, rec := range records {
+		_, network, errP := net.ParseCIDR(cidr)
+		require.NoError(t, errP)
+		require.NoError(t, tree.Insert(network, rec))
+	}
+
+	f, err := os.Create(path + ".tmp")
+	require.NoError(t, err)
+	_, err = tree.WriteTo(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, os.Rename(path+".tmp", path))
+}
+
+func cityRecord(countryCode, country, city string, lat, lon float64) mmdbtype.Map {
+	return mmdbtype.Map{
+		"country": mmdbtype.Map{
+			"iso_code": mmdbtype.String(countryCode),
+			"names":    mmdbtype.Map{"en": mmdbtype.String(country)},
+		},
+		"city": mmdbtype.Map{
+			"names": mmdbtype.Map{"en": mmdbtype.String(city)},
+		},
+		"location": mmdbtype.Map{
+			"latitude":        mmdbtype.Float64(lat),
+			"longitude":       mmdbtype.Float64(lon),
+			"accuracy_radius": mmdbtype.Uint16(20),
+		},
+	}
+}
+
+func asnRecord(asn uint32, org string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"autonomous_system_number":       mmdbtype.Uint32(asn),
+		"autonomous_system_organization": mmdbtype.String(org),
+	}
+}
+
+func TestDB_Lookup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cityFile := filepath.Join(dir, "city.mmdb")
+	asnFile := filepath.Join(dir, "asn.mmdb")
+	writeDB(t, cityFile, "GeoLite2-City", map[string]mmdbtype.Map{
+		"81.2.69.0/24":     cityRecord("GB", "United Kingdom", "London", 51.5142, -0.0931),
+		"2a02:6b8::/32":    cityRecord("RU", "Russia", "Moscow", 55.7527, 37.6172),
+		"89.160.20.112/28": cityRecord("SE", "Sweden", "Linköping", 58.4167, 15.6167),
+	})
+	writeDB(t, asnFile, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"81.2.69.0/24":  asnRecord(20712, "Andrews & Arnold Ltd"),
+		"2a02:6b8::/32": asnRecord(13238, "YANDEX LLC"),
+	})
+
+	db, err := New(Options{CityFile: cityFile, ASNFile: asnFile}, zap.NewNop())
+	require.NoError(t, err)
+	defer db.Close()
+
+	tests := []struct {
+		name string
+		ip   string
+		want *internal.Geo
+	}{
+		{
+			name: "city and asn",
+			ip:   "81.2.69.142",
+			want: &internal.Geo{
+				Scope:          internal.GeoScopePublic,
+				CountryCode:    "GB",
+				CountryName:    "United Kingdom",
+				City:           "London",
+				Latitude:       51.5142,
+				Longitude:      -0.0931,
+				AccuracyRadius: 20,
+				ASN:            20712,
+				ASOrganization: "Andrews & Arnold Ltd",
+			},
+		},
+		{
+			name: "ipv6",
+			ip:   "2a02:6b8::2:242",
+			want: &internal.Geo{
+				Scope:          internal.GeoScopePublic,
+				CountryCode:    "RU",
+				CountryName:    "Russia",
+				City:           "Moscow",
+				Latitude:       55.7527,
+				Longitude:      37.6172,
+				AccuracyRadius: 20,
+				ASN:            13238,
+				ASOrganization: "YANDEX LLC",
+			},
+		},
+		{
+			name: "city only",
+			ip:   "::ffff:89.160.20.113",
+			want: &internal.Geo{
+				Scope:          internal.GeoScopePublic,
+				CountryCode:    "SE",
+				CountryName:    "Sweden",
+				City:           "Linköping",
+				Latitude:       58.4167,
+				Longitude:      15.6167,
+				AccuracyRadius: 20,
+			},
+		},
+		{name: "not found", ip: "8.8.8.8", want: &internal.Geo{Scope: internal.GeoScopePublic}},
+		{name: "private", ip: "10.1.2.3", want: &internal.Geo{Scope: internal.GeoScopePrivate}},
+		{name: "loopback", ip: "::1", want: &internal.Geo{Scope: internal.GeoScopeLoopback}},
+		{name: "empty", ip: "", want: nil},
+		{name: "invalid", ip: "unknown", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, db.Lookup(tt.ip))
+		})
+	}
+}
+
+func TestDB_Reload(t *testing.T) {
+	t.Parallel()
+
+	cityFile := filepath.Join(t.TempDir(), "city.mmdb")
+	writeDB(t, cityFile, "GeoLite2-City", map[string]mmdbtype.Map{
+		"81.2.69.0/24": cityRecord("GB", "United Kingdom", "London", 51.5142, -0.0931),
+	})
+
+	db, err := New(Options{CityFile: cityFile, ReloadInterval: 10 * time.Millisecond}, zap.NewNop())
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, "London", db.Lookup("81.2.69.142").City)
+
+	writeDB(t, cityFile, "GeoLite2-City", map[string]mmdbtype.Map{
+		"81.2.69.0/24": cityRecord("GB", "United Kingdom", "Manchester", 53.4809, -2.2374),
+	})
+	// время модификации может совпасть, но размер файла отличается
+	assert.Eventually(t, func() bool {
+		return db.Lookup("81.2.69.142").City == "Manchester"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(cityFile, []byte("broken"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "Manchester", db.Lookup("81.2.69.142").City)
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "no databases", opts: Options{}},
+		{name: "negative reload interval", opts: Options{CityFile: "city.mmdb", ReloadInterval: -time.Second}},
+		{name: "missing file", opts: Options{CityFile: filepath.Join(os.TempDir(), "missing.mmdb")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestScopeOf(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want internal.GeoScope
+	}{
+		{ip: "81.2.69.142", want: internal.GeoScopePublic},
+		{ip: "2a02:6b8::1", want: internal.GeoScopePublic},
+		{ip: "10.0.0.1", want: internal.GeoScopePrivate},
+		{ip: "172.16.5.4", want: internal.GeoScopePrivate},
+		{ip: "192.168.1.1", want: internal.GeoScopePrivate},
+		{ip: "fd00::1", want: internal.GeoScopePrivate},
+		{ip: "127.0.0.1", want: internal.GeoScopeLoopback},
+		{ip: "::1", want: internal.GeoScopeLoopback},
+		{ip: "169.254.1.1", want: internal.GeoScopeLinkLocal},
+		{ip: "fe80::1", want: internal.GeoScopeLinkLocal},
+		{ip: "224.0.0.1", want: internal.GeoScopeMulticast},
+		{ip: "ff02::1", want: internal.GeoScopeMulticast},
+		{ip: "0.0.0.0", want: internal.GeoScopeReserved},
+		{ip: "100.64.0.1", want: internal.GeoScopeReserved},
+		{ip: "192.0.2.10", want: internal.GeoScopeReserved},
+		{ip: "203.0.113.5", want: internal.GeoScopeReserved},
+		{ip: "2001:db8::1", want: internal.GeoScopeReserved},
+		{ip: "255.255.255.255", want: internal.GeoScopeReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, ScopeOf(netip.MustParseAddr(tt.ip)))
+		})
+	}
+}
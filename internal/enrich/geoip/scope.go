@@ -0,0 +1,49 @@
+package geoip
+
+import (
+	"net/netip"
+
+	"keycloak-events-adapter/internal"
+)
+
+// reserved диапазоны специального назначения (RFC 6890), которые не описываются методами netip.Addr
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// ScopeOf определяет класс адреса, адрес должен быть без IPv4-mapped префикса
+func ScopeOf(addr netip.Addr) internal.GeoScope {
+	switch {
+	case addr.IsLoopback():
+		return internal.GeoScopeLoopback
+	case addr.IsPrivate():
+		return internal.GeoScopePrivate
+	case addr.IsLinkLocalUnicast():
+		return internal.GeoScopeLinkLocal
+	case addr.IsMulticast(), addr.IsLinkLocalMulticast(), addr.IsInterfaceLocalMulticast():
+		return internal.GeoScopeMulticast
+	case addr.IsUnspecified():
+		return internal.GeoScopeReserved
+	}
+
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return internal.GeoScopeReserved
+		}
+	}
+
+	return internal.GeoScopePublic
+}
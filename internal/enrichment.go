@@ -0,0 +1,71 @@
+package internal
+
+// GeoScope класс IP адреса: справочник геолокации используется только для публичных адресов
+type GeoScope string
+
+const (
+	GeoScopePublic    GeoScope = "public"
+	GeoScopePrivate   GeoScope = "private"
+	GeoScopeLoopback  GeoScope = "loopback"
+	GeoScopeLinkLocal GeoScope = "link_local"
+	GeoScopeMulticast GeoScope = "multicast"
+	GeoScopeReserved  GeoScope = "reserved"
+)
+
+// Geo геолокация IP адреса события
+type Geo struct {
+	Scope          GeoScope `json:"scope"`
+	CountryCode    string   `json:"country_code,omitempty"`
+	CountryName    string   `json:"country_name,omitempty"`
+	City           string   `json:"city,omitempty"`
+	Latitude       float64  `json:"latitude,omitempty"`
+	Longitude      float64  `json:"longitude,omitempty"`
+	AccuracyRadius uint16   `json:"accuracy_radius,omitempty"`
+	ASN            uint32   `json:"asn,omitempty"`
+	ASOrganization string   `json:"as_organization,omitempty"`
+}
+
+// Enrichment данные, добавленные адаптером к событию Keycloak
+type Enrichment struct {
+	Geo *Geo `json:"geo,omitempty"`
+	// Route маршрут, назначенный конвейером обработки, доступен в шаблонах как {route}
+	Route string            `json:"route,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	// Protected событие не отбрасывается обработчиками конвейера, назначается политикой
+	Protected bool `json:"protected,omitempty"`
+	// Resource разобранный путь ресурса события администрирования
+	Resource *ResourceRef `json:"resource,omitempty"`
+}
+
+// IpAddressOf возвращает IP адрес, с которого выполнено действие
+func IpAddressOf[T Event | AdminEvent](event *T) string {
+	switch e := any(event).(type) {
+	case *Event:
+		return e.IpAddress
+	case *AdminEvent:
+		if e.AuthDetails != nil {
+			return e.AuthDetails.IpAddress
+		}
+	}
+
+	return ""
+}
+
+// EnrichmentOf возвращает обогащение события, создавая его при необходимости
+func EnrichmentOf[T Event | AdminEvent](event *T) *Enrichment {
+	var enrichment **Enrichment
+	switch e := any(event).(type) {
+	case *Event:
+		enrichment = &e.Enrichment
+	case *AdminEvent:
+		enrichment = &e.Enrichment
+	default:
+		return &Enrichment{}
+	}
+
+	if *enrichment == nil {
+		*enrichment = &Enrichment{}
+	}
+
+	return *enrichment
+}
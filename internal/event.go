@@ -19,117 +19,187 @@ const (
 	OperationTypeAction OperationType = 4
 )
 
+// EventTypeUnknown тип, не известный адаптеру: имя, полученное от Keycloak, хранится в Event.RawType
+const EventTypeUnknown EventType = 0
+
 const (
-	EventTypeLogin                               EventType = 1
-	EventTypeLoginError                          EventType = 2
-	EventTypeRegister                            EventType = 3
-	EventTypeRegisterError                       EventType = 4
-	EventTypeLogout                              EventType = 5
-	EventTypeLogoutError                         EventType = 6
-	EventTypeCodeToToken                         EventType = 7
-	EventTypeCodeToTokenError                    EventType = 8
-	EventTypeClientLogin                         EventType = 9
-	EventTypeClientLoginError                    EventType = 10
-	EventTypeRefreshToken                        EventType = 11
-	EventTypeRefreshTokenError                   EventType = 12
-	EventTypeIntrospectToken                     EventType = 15
-	EventTypeIntrospectTokenError                EventType = 16
-	EventTypeFederatedIdentityLink               EventType = 17
-	EventTypeFederatedIdentityLinkError          EventType = 18
-	EventTypeRemoveFederatedIdentity             EventType = 19
-	EventTypeRemoveFederatedIdentityError        EventType = 20
-	EventTypeUpdateEmail                         EventType = 21
-	EventTypeUpdateEmailError                    EventType = 22
-	EventTypeUpdateProfile                       EventType = 23
-	EventTypeUpdateProfileError                  EventType = 24
-	EventTypeVerifyEmail                         EventType = 29
-	EventTypeVerifyEmailError                    EventType = 30
-	EventTypeVerifyProfile                       EventType = 31
-	EventTypeVerifyProfileError                  EventType = 32
-	EventTypeGrantConsent                        EventType = 35
-	EventTypeGrantConsentError                   EventType = 36
-	EventTypeUpdateConsent                       EventType = 37
-	EventTypeUpdateConsentError                  EventType = 38
-	EventTypeRevokeGrant                         EventType = 39
-	EventTypeRevokeGrantError                    EventType = 40
-	EventTypeSendVerifyEmail                     EventType = 41
-	EventTypeSendVerifyEmailError                EventType = 42
-	EventTypeSendResetPassword                   EventType = 43
-	EventTypeSendResetPasswordError              EventType = 44
-	EventTypeResetPassword                       EventType = 47
-	EventTypeResetPasswordError                  EventType = 48
-	EventTypeRestartAuthentication               EventType = 49
-	EventTypeRestartAuthenticationError          EventType = 50
-	EventTypeInvalidSignature                    EventType = 51
-	EventTypeInvalidSignatureError               EventType = 52
-	EventTypeRegisterNode                        EventType = 53
-	EventTypeRegisterNodeError                   EventType = 54
-	EventTypeUnregisterNode                      EventType = 55
-	EventTypeUnregisterNodeError                 EventType = 56
-	EventTypeUserInfoRequest                     EventType = 57
-	EventTypeUserInfoRequestError                EventType = 58
-	EventTypeImpersonate                         EventType = 71
-	EventTypeImpersonateError                    EventType = 72
-	EventTypeExecuteActions                      EventType = 75
-	EventTypeExecuteActionsError                 EventType = 76
-	EventTypeExecuteActionToken                  EventType = 77
-	EventTypeExecuteActionTokenError             EventType = 78
-	EventTypeClientInfo                          EventType = 79
-	EventTypeClientInfoError                     EventType = 80
-	EventTypeClientRegister                      EventType = 81
-	EventTypeClientRegisterError                 EventType = 82
-	EventTypeClientUpdate                        EventType = 83
-	EventTypeClientUpdateError                   EventType = 84
-	EventTypeClientDelete                        EventType = 85
-	EventTypeClientDeleteError                   EventType = 86
-	EventTypeTokenExchange                       EventType = 89
-	EventTypeTokenExchangeError                  EventType = 90
-	EventTypeDeleteAccount                       EventType = 101
-	EventTypeDeleteAccountError                  EventType = 102
-	EventTypeUserDisabledByPermanentLockout      EventType = 105
-	EventTypeUserDisabledByPermanentLockoutError EventType = 106
-	EventTypeUserDisabledByTemporaryLockout      EventType = 107
-	EventTypeUserDisabledByTemporaryLockoutError EventType = 108
-	EventTypeUpdateCredential                    EventType = 113
-	EventTypeUpdateCredentialError               EventType = 114
-	EventTypeRemoveCredential                    EventType = 115
-	EventTypeRemoveCredentialError               EventType = 116
+	EventTypeLogin                                       EventType = 1
+	EventTypeLoginError                                  EventType = 2
+	EventTypeRegister                                    EventType = 3
+	EventTypeRegisterError                               EventType = 4
+	EventTypeLogout                                      EventType = 5
+	EventTypeLogoutError                                 EventType = 6
+	EventTypeCodeToToken                                 EventType = 7
+	EventTypeCodeToTokenError                            EventType = 8
+	EventTypeClientLogin                                 EventType = 9
+	EventTypeClientLoginError                            EventType = 10
+	EventTypeRefreshToken                                EventType = 11
+	EventTypeRefreshTokenError                           EventType = 12
+	EventTypeValidateAccessToken                         EventType = 13
+	EventTypeValidateAccessTokenError                    EventType = 14
+	EventTypeIntrospectToken                             EventType = 15
+	EventTypeIntrospectTokenError                        EventType = 16
+	EventTypeFederatedIdentityLink                       EventType = 17
+	EventTypeFederatedIdentityLinkError                  EventType = 18
+	EventTypeRemoveFederatedIdentity                     EventType = 19
+	EventTypeRemoveFederatedIdentityError                EventType = 20
+	EventTypeUpdateEmail                                 EventType = 21
+	EventTypeUpdateEmailError                            EventType = 22
+	EventTypeUpdateProfile                               EventType = 23
+	EventTypeUpdateProfileError                          EventType = 24
+	EventTypeUpdatePassword                              EventType = 25
+	EventTypeUpdatePasswordError                         EventType = 26
+	EventTypeUpdateTotp                                  EventType = 27
+	EventTypeUpdateTotpError                             EventType = 28
+	EventTypeVerifyEmail                                 EventType = 29
+	EventTypeVerifyEmailError                            EventType = 30
+	EventTypeVerifyProfile                               EventType = 31
+	EventTypeVerifyProfileError                          EventType = 32
+	EventTypeRemoveTotp                                  EventType = 33
+	EventTypeRemoveTotpError                             EventType = 34
+	EventTypeGrantConsent                                EventType = 35
+	EventTypeGrantConsentError                           EventType = 36
+	EventTypeUpdateConsent                               EventType = 37
+	EventTypeUpdateConsentError                          EventType = 38
+	EventTypeRevokeGrant                                 EventType = 39
+	EventTypeRevokeGrantError                            EventType = 40
+	EventTypeSendVerifyEmail                             EventType = 41
+	EventTypeSendVerifyEmailError                        EventType = 42
+	EventTypeSendResetPassword                           EventType = 43
+	EventTypeSendResetPasswordError                      EventType = 44
+	EventTypeSendIdentityProviderLink                    EventType = 45
+	EventTypeSendIdentityProviderLinkError               EventType = 46
+	EventTypeResetPassword                               EventType = 47
+	EventTypeResetPasswordError                          EventType = 48
+	EventTypeRestartAuthentication                       EventType = 49
+	EventTypeRestartAuthenticationError                  EventType = 50
+	EventTypeInvalidSignature                            EventType = 51
+	EventTypeInvalidSignatureError                       EventType = 52
+	EventTypeRegisterNode                                EventType = 53
+	EventTypeRegisterNodeError                           EventType = 54
+	EventTypeUnregisterNode                              EventType = 55
+	EventTypeUnregisterNodeError                         EventType = 56
+	EventTypeUserInfoRequest                             EventType = 57
+	EventTypeUserInfoRequestError                        EventType = 58
+	EventTypeIdentityProviderLinkAccount                 EventType = 59
+	EventTypeIdentityProviderLinkAccountError            EventType = 60
+	EventTypeIdentityProviderLogin                       EventType = 61
+	EventTypeIdentityProviderLoginError                  EventType = 62
+	EventTypeIdentityProviderFirstLogin                  EventType = 63
+	EventTypeIdentityProviderFirstLoginError             EventType = 64
+	EventTypeIdentityProviderPostLogin                   EventType = 65
+	EventTypeIdentityProviderPostLoginError              EventType = 66
+	EventTypeIdentityProviderResponse                    EventType = 67
+	EventTypeIdentityProviderResponseError               EventType = 68
+	EventTypeIdentityProviderRetrieveToken               EventType = 69
+	EventTypeIdentityProviderRetrieveTokenError          EventType = 70
+	EventTypeImpersonate                                 EventType = 71
+	EventTypeImpersonateError                            EventType = 72
+	EventTypeCustomRequiredAction                        EventType = 73
+	EventTypeCustomRequiredActionError                   EventType = 74
+	EventTypeExecuteActions                              EventType = 75
+	EventTypeExecuteActionsError                         EventType = 76
+	EventTypeExecuteActionToken                          EventType = 77
+	EventTypeExecuteActionTokenError                     EventType = 78
+	EventTypeClientInfo                                  EventType = 79
+	EventTypeClientInfoError                             EventType = 80
+	EventTypeClientRegister                              EventType = 81
+	EventTypeClientRegisterError                         EventType = 82
+	EventTypeClientUpdate                                EventType = 83
+	EventTypeClientUpdateError                           EventType = 84
+	EventTypeClientDelete                                EventType = 85
+	EventTypeClientDeleteError                           EventType = 86
+	EventTypeClientInitiatedAccountLinking               EventType = 87
+	EventTypeClientInitiatedAccountLinkingError          EventType = 88
+	EventTypeTokenExchange                               EventType = 89
+	EventTypeTokenExchangeError                          EventType = 90
+	EventTypeOAuth2DeviceAuth                            EventType = 91
+	EventTypeOAuth2DeviceAuthError                       EventType = 92
+	EventTypeOAuth2DeviceVerifyUserCode                  EventType = 93
+	EventTypeOAuth2DeviceVerifyUserCodeError             EventType = 94
+	EventTypeOAuth2DeviceCodeToToken                     EventType = 95
+	EventTypeOAuth2DeviceCodeToTokenError                EventType = 96
+	EventTypeAuthReqIdToToken                            EventType = 97
+	EventTypeAuthReqIdToTokenError                       EventType = 98
+	EventTypePermissionToken                             EventType = 99
+	EventTypePermissionTokenError                        EventType = 100
+	EventTypeDeleteAccount                               EventType = 101
+	EventTypeDeleteAccountError                          EventType = 102
+	EventTypePushedAuthorizationRequest                  EventType = 103
+	EventTypePushedAuthorizationRequestError             EventType = 104
+	EventTypeUserDisabledByPermanentLockout              EventType = 105
+	EventTypeUserDisabledByPermanentLockoutError         EventType = 106
+	EventTypeUserDisabledByTemporaryLockout              EventType = 107
+	EventTypeUserDisabledByTemporaryLockoutError         EventType = 108
+	EventTypeOAuth2ExtensionGrant                        EventType = 109
+	EventTypeOAuth2ExtensionGrantError                   EventType = 110
+	EventTypeFederatedIdentityOverrideLink               EventType = 111
+	EventTypeFederatedIdentityOverrideLinkError          EventType = 112
+	EventTypeUpdateCredential                            EventType = 113
+	EventTypeUpdateCredentialError                       EventType = 114
+	EventTypeRemoveCredential                            EventType = 115
+	EventTypeRemoveCredentialError                       EventType = 116
+	EventTypeInviteOrg                                   EventType = 117
+	EventTypeInviteOrgError                              EventType = 118
+	EventTypeUserSessionDeleted                          EventType = 119
+	EventTypeUserSessionDeletedError                     EventType = 120
+	EventTypeVerifiableCredentialRequest                 EventType = 121
+	EventTypeVerifiableCredentialRequestError            EventType = 122
+	EventTypeVerifiableCredentialPreAuthorizedGrant      EventType = 123
+	EventTypeVerifiableCredentialPreAuthorizedGrantError EventType = 124
 )
 
 type AuthDetails struct {
-	RealmId   uuid.UUID
-	RealmName string
-	ClientId  uuid.UUID
-	UserId    uuid.UUID
-	IpAddress string
+	RealmId   uuid.UUID `json:"realm_id"`
+	RealmName string    `json:"realm_name,omitempty"`
+	ClientId  uuid.UUID `json:"client_id"`
+	UserId    uuid.UUID `json:"user_id"`
+	IpAddress string    `json:"ip_address,omitempty"`
 }
 
 type AdminEvent struct {
-	Id             uuid.UUID
-	Time           time.Time
-	RealmId        uuid.UUID
-	RealmName      string
-	AuthDetails    *AuthDetails
-	ResourceType   string
-	OperationType  OperationType
-	ResourcePath   string
-	Representation string
-	Error          string
-	Details        map[string]string
+	Id             uuid.UUID         `json:"id"`
+	Time           time.Time         `json:"time"`
+	RealmId        uuid.UUID         `json:"realm_id"`
+	RealmName      string            `json:"realm_name,omitempty"`
+	AuthDetails    *AuthDetails      `json:"auth_details,omitempty"`
+	ResourceType   string            `json:"resource_type,omitempty"`
+	OperationType  OperationType     `json:"operation_type"`
+	ResourcePath   string            `json:"resource_path,omitempty"`
+	Representation string            `json:"representation,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Details        map[string]string `json:"details,omitempty"`
+	Enrichment     *Enrichment       `json:"enrichment,omitempty"`
 }
 
 type Event struct {
-	Id        uuid.UUID
-	Time      time.Time
-	Type      EventType
-	RealmId   uuid.UUID
-	RealmName string
-	ClientId  string
-	UserId    uuid.UUID
-	SessionId string
-	IpAddress string
-	Error     string
-	Details   map[string]string
+	Id         uuid.UUID         `json:"id"`
+	Time       time.Time         `json:"time"`
+	Type       EventType         `json:"type"`
+	RawType    string            `json:"raw_type,omitempty"`
+	RealmId    uuid.UUID         `json:"realm_id"`
+	RealmName  string            `json:"realm_name,omitempty"`
+	ClientId   string            `json:"client_id,omitempty"`
+	UserId     uuid.UUID         `json:"user_id"`
+	SessionId  string            `json:"session_id,omitempty"`
+	IpAddress  string            `json:"ip_address,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	Enrichment *Enrichment       `json:"enrichment,omitempty"`
+}
+
+// IsAlert проверяет, что событие представляет тревогу детектора
+func (e *Event) IsAlert() bool {
+	return e.Type == EventTypeUnknown && e.RawType == AlertEventType
+}
+
+// TypeName возвращает имя типа события, для неизвестного адаптеру типа — имя, полученное от Keycloak
+func (e *Event) TypeName() string {
+	if e.Type == EventTypeUnknown && e.RawType != "" {
+		return e.RawType
+	}
+
+	return e.Type.String()
 }
 
 type EventKeeper[T Event | AdminEvent] interface {
@@ -159,6 +229,10 @@ func NewEventService(
 }
 
 func (e *EventService) PushAdmin(event *AdminEvent) error {
+	if ref := ParseResourcePath(event.ResourcePath); ref != nil {
+		EnrichmentOf(event).Resource = ref
+	}
+
 	err := e.adminEventStorage.Push(event)
 	if err != nil {
 		return fmt.Errorf("push admin event: %w", err)
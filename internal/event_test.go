@@ -87,6 +87,7 @@ func TestEventService_PushAdmin(t *testing.T) {
 		Error:          "",
 		Details:        map[string]string{"key": "value"},
 	}
+	adminEventPushError := *adminEventCorrect
 
 	type args struct {
 		event *AdminEvent
@@ -110,10 +111,10 @@ func TestEventService_PushAdmin(t *testing.T) {
 		{
 			name: "push_admin_error",
 			args: args{
-				event: adminEventCorrect,
+				event: &adminEventPushError,
 			},
 			prepare: func(adminEventStorage *mock.MockEventKeeper[AdminEvent], eventStorage *mock.MockEventKeeper[Event]) {
-				adminEventStorage.EXPECT().Push(adminEventCorrect).Return(fmt.Errorf("push admin error"))
+				adminEventStorage.EXPECT().Push(&adminEventPushError).Return(fmt.Errorf("push admin error"))
 			},
 			wantErr: true,
 		},
@@ -132,6 +133,10 @@ func TestEventService_PushAdmin(t *testing.T) {
 			if err := e.PushAdmin(tt.args.event); (err != nil) != tt.wantErr {
 				t.Errorf("PushAdmin() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.args.event.Enrichment == nil || tt.args.event.Enrichment.Resource == nil ||
+				tt.args.event.Enrichment.Resource.Kind != "users" {
+				t.Errorf("PushAdmin() resource = %+v, want users", tt.args.event.Enrichment)
+			}
 		})
 	}
 }
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// eventTypeNames канонические имена типов событий, совпадающие с org.keycloak.events.EventType
+var eventTypeNames = map[EventType]string{
+	EventTypeLogin:                                       "LOGIN",
+	EventTypeLoginError:                                  "LOGIN_ERROR",
+	EventTypeRegister:                                    "REGISTER",
+	EventTypeRegisterError:                               "REGISTER_ERROR",
+	EventTypeLogout:                                      "LOGOUT",
+	EventTypeLogoutError:                                 "LOGOUT_ERROR",
+	EventTypeCodeToToken:                                 "CODE_TO_TOKEN",
+	EventTypeCodeToTokenError:                            "CODE_TO_TOKEN_ERROR",
+	EventTypeClientLogin:                                 "CLIENT_LOGIN",
+	EventTypeClientLoginError:                            "CLIENT_LOGIN_ERROR",
+	EventTypeRefreshToken:                                "REFRESH_TOKEN",
+	EventTypeRefreshTokenError:                           "REFRESH_TOKEN_ERROR",
+	EventTypeValidateAccessToken:                         "VALIDATE_ACCESS_TOKEN",
+	EventTypeValidateAccessTokenError:                    "VALIDATE_ACCESS_TOKEN_ERROR",
+	EventTypeIntrospectToken:                             "INTROSPECT_TOKEN",
+	EventTypeIntrospectTokenError:                        "INTROSPECT_TOKEN_ERROR",
+	EventTypeFederatedIdentityLink:                       "FEDERATED_IDENTITY_LINK",
+	EventTypeFederatedIdentityLinkError:                  "FEDERATED_IDENTITY_LINK_ERROR",
+	EventTypeRemoveFederatedIdentity:                     "REMOVE_FEDERATED_IDENTITY",
+	EventTypeRemoveFederatedIdentityError:                "REMOVE_FEDERATED_IDENTITY_ERROR",
+	EventTypeUpdateEmail:                                 "UPDATE_EMAIL",
+	EventTypeUpdateEmailError:                            "UPDATE_EMAIL_ERROR",
+	EventTypeUpdateProfile:                               "UPDATE_PROFILE",
+	EventTypeUpdateProfileError:                          "UPDATE_PROFILE_ERROR",
+	EventTypeUpdatePassword:                              "UPDATE_PASSWORD",
+	EventTypeUpdatePasswordError:                         "UPDATE_PASSWORD_ERROR",
+	EventTypeUpdateTotp:                                  "UPDATE_TOTP",
+	EventTypeUpdateTotpError:                             "UPDATE_TOTP_ERROR",
+	EventTypeVerifyEmail:                                 "VERIFY_EMAIL",
+	EventTypeVerifyEmailError:                            "VERIFY_EMAIL_ERROR",
+	EventTypeVerifyProfile:                               "VERIFY_PROFILE",
+	EventTypeVerifyProfileError:                          "VERIFY_PROFILE_ERROR",
+	EventTypeRemoveTotp:                                  "REMOVE_TOTP",
+	EventTypeRemoveTotpError:                             "REMOVE_TOTP_ERROR",
+	EventTypeGrantConsent:                                "GRANT_CONSENT",
+	EventTypeGrantConsentError:                           "GRANT_CONSENT_ERROR",
+	EventTypeUpdateConsent:                               "UPDATE_CONSENT",
+	EventTypeUpdateConsentError:                          "UPDATE_CONSENT_ERROR",
+	EventTypeRevokeGrant:                                 "REVOKE_GRANT",
+	EventTypeRevokeGrantError:                            "REVOKE_GRANT_ERROR",
+	EventTypeSendVerifyEmail:                             "SEND_VERIFY_EMAIL",
+	EventTypeSendVerifyEmailError:                        "SEND_VERIFY_EMAIL_ERROR",
+	EventTypeSendResetPassword:                           "SEND_RESET_PASSWORD",
+	EventTypeSendResetPasswordError:                      "SEND_RESET_PASSWORD_ERROR",
+	EventTypeSendIdentityProviderLink:                    "SEND_IDENTITY_PROVIDER_LINK",
+	EventTypeSendIdentityProviderLinkError:               "SEND_IDENTITY_PROVIDER_LINK_ERROR",
+	EventTypeResetPassword:                               "RESET_PASSWORD",
+	EventTypeResetPasswordError:                          "RESET_PASSWORD_ERROR",
+	EventTypeRestartAuthentication:                       "RESTART_AUTHENTICATION",
+	EventTypeRestartAuthenticationError:                  "RESTART_AUTHENTICATION_ERROR",
+	EventTypeInvalidSignature:                            "INVALID_SIGNATURE",
+	EventTypeInvalidSignatureError:                       "INVALID_SIGNATURE_ERROR",
+	EventTypeRegisterNode:                                "REGISTER_NODE",
+	EventTypeRegisterNodeError:                           "REGISTER_NODE_ERROR",
+	EventTypeUnregisterNode:                              "UNREGISTER_NODE",
+	EventTypeUnregisterNodeError:                         "UNREGISTER_NODE_ERROR",
+	EventTypeUserInfoRequest:                             "USER_INFO_REQUEST",
+	EventTypeUserInfoRequestError:                        "USER_INFO_REQUEST_ERROR",
+	EventTypeIdentityProviderLinkAccount:                 "IDENTITY_PROVIDER_LINK_ACCOUNT",
+	EventTypeIdentityProviderLinkAccountError:            "IDENTITY_PROVIDER_LINK_ACCOUNT_ERROR",
+	EventTypeIdentityProviderLogin:                       "IDENTITY_PROVIDER_LOGIN",
+	EventTypeIdentityProviderLoginError:                  "IDENTITY_PROVIDER_LOGIN_ERROR",
+	EventTypeIdentityProviderFirstLogin:                  "IDENTITY_PROVIDER_FIRST_LOGIN",
+	EventTypeIdentityProviderFirstLoginError:             "IDENTITY_PROVIDER_FIRST_LOGIN_ERROR",
+	EventTypeIdentityProviderPostLogin:                   "IDENTITY_PROVIDER_POST_LOGIN",
+	EventTypeIdentityProviderPostLoginError:              "IDENTITY_PROVIDER_POST_LOGIN_ERROR",
+	EventTypeIdentityProviderResponse:                    "IDENTITY_PROVIDER_RESPONSE",
+	EventTypeIdentityProviderResponseError:               "IDENTITY_PROVIDER_RESPONSE_ERROR",
+	EventTypeIdentityProviderRetrieveToken:               "IDENTITY_PROVIDER_RETRIEVE_TOKEN",
+	EventTypeIdentityProviderRetrieveTokenError:          "IDENTITY_PROVIDER_RETRIEVE_TOKEN_ERROR",
+	EventTypeImpersonate:                                 "IMPERSONATE",
+	EventTypeImpersonateError:                            "IMPERSONATE_ERROR",
+	EventTypeCustomRequiredAction:                        "CUSTOM_REQUIRED_ACTION",
+	EventTypeCustomRequiredActionError:                   "CUSTOM_REQUIRED_ACTION_ERROR",
+	EventTypeExecuteActions:                              "EXECUTE_ACTIONS",
+	EventTypeExecuteActionsError:                         "EXECUTE_ACTIONS_ERROR",
+	EventTypeExecuteActionToken:                          "EXECUTE_ACTION_TOKEN",
+	EventTypeExecuteActionTokenError:                     "EXECUTE_ACTION_TOKEN_ERROR",
+	EventTypeClientInfo:                                  "CLIENT_INFO",
+	EventTypeClientInfoError:                             "CLIENT_INFO_ERROR",
+	EventTypeClientRegister:                              "CLIENT_REGISTER",
+	EventTypeClientRegisterError:                         "CLIENT_REGISTER_ERROR",
+	EventTypeClientUpdate:                                "CLIENT_UPDATE",
+	EventTypeClientUpdateError:                           "CLIENT_UPDATE_ERROR",
+	EventTypeClientDelete:                                "CLIENT_DELETE",
+	EventTypeClientDeleteError:                           "CLIENT_DELETE_ERROR",
+	EventTypeClientInitiatedAccountLinking:               "CLIENT_INITIATED_ACCOUNT_LINKING",
+	EventTypeClientInitiatedAccountLinkingError:          "CLIENT_INITIATED_ACCOUNT_LINKING_ERROR",
+	EventTypeTokenExchange:                               "TOKEN_EXCHANGE",
+	EventTypeTokenExchangeError:                          "TOKEN_EXCHANGE_ERROR",
+	EventTypeOAuth2DeviceAuth:                            "OAUTH2_DEVICE_AUTH",
+	EventTypeOAuth2DeviceAuthError:                       "OAUTH2_DEVICE_AUTH_ERROR",
+	EventTypeOAuth2DeviceVerifyUserCode:                  "OAUTH2_DEVICE_VERIFY_USER_CODE",
+	EventTypeOAuth2DeviceVerifyUserCodeError:             "OAUTH2_DEVICE_VERIFY_USER_CODE_ERROR",
+	EventTypeOAuth2DeviceCodeToToken:                     "OAUTH2_DEVICE_CODE_TO_TOKEN",
+	EventTypeOAuth2DeviceCodeToTokenError:                "OAUTH2_DEVICE_CODE_TO_TOKEN_ERROR",
+	EventTypeAuthReqIdToToken:                            "AUTHREQID_TO_TOKEN",
+	EventTypeAuthReqIdToTokenError:                       "AUTHREQID_TO_TOKEN_ERROR",
+	EventTypePermissionToken:                             "PERMISSION_TOKEN",
+	EventTypePermissionTokenError:                        "PERMISSION_TOKEN_ERROR",
+	EventTypeDeleteAccount:                               "DELETE_ACCOUNT",
+	EventTypeDeleteAccountError:                          "DELETE_ACCOUNT_ERROR",
+	EventTypePushedAuthorizationRequest:                  "PUSHED_AUTHORIZATION_REQUEST",
+	EventTypePushedAuthorizationRequestError:             "PUSHED_AUTHORIZATION_REQUEST_ERROR",
+	EventTypeUserDisabledByPermanentLockout:              "USER_DISABLED_BY_PERMANENT_LOCKOUT",
+	EventTypeUserDisabledByPermanentLockoutError:         "USER_DISABLED_BY_PERMANENT_LOCKOUT_ERROR",
+	EventTypeUserDisabledByTemporaryLockout:              "USER_DISABLED_BY_TEMPORARY_LOCKOUT",
+	EventTypeUserDisabledByTemporaryLockoutError:         "USER_DISABLED_BY_TEMPORARY_LOCKOUT_ERROR",
+	EventTypeOAuth2ExtensionGrant:                        "OAUTH2_EXTENSION_GRANT",
+	EventTypeOAuth2ExtensionGrantError:                   "OAUTH2_EXTENSION_GRANT_ERROR",
+	EventTypeFederatedIdentityOverrideLink:               "FEDERATED_IDENTITY_OVERRIDE_LINK",
+	EventTypeFederatedIdentityOverrideLinkError:          "FEDERATED_IDENTITY_OVERRIDE_LINK_ERROR",
+	EventTypeUpdateCredential:                            "UPDATE_CREDENTIAL",
+	EventTypeUpdateCredentialError:                       "UPDATE_CREDENTIAL_ERROR",
+	EventTypeRemoveCredential:                            "REMOVE_CREDENTIAL",
+	EventTypeRemoveCredentialError:                       "REMOVE_CREDENTIAL_ERROR",
+	EventTypeInviteOrg:                                   "INVITE_ORG",
+	EventTypeInviteOrgError:                              "INVITE_ORG_ERROR",
+	EventTypeUserSessionDeleted:                          "USER_SESSION_DELETED",
+	EventTypeUserSessionDeletedError:                     "USER_SESSION_DELETED_ERROR",
+	EventTypeVerifiableCredentialRequest:                 "VERIFIABLE_CREDENTIAL_REQUEST",
+	EventTypeVerifiableCredentialRequestError:            "VERIFIABLE_CREDENTIAL_REQUEST_ERROR",
+	EventTypeVerifiableCredentialPreAuthorizedGrant:      "VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT",
+	EventTypeVerifiableCredentialPreAuthorizedGrantError: "VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT_ERROR",
+}
+
+var operationTypeNames = map[OperationType]string{
+	OperationTypeCreate: "CREATE",
+	OperationTypeUpdate: "UPDATE",
+	OperationTypeDelete: "DELETE",
+	OperationTypeAction: "ACTION",
+}
+
+var eventTypeValues = reverseNames(eventTypeNames)
+
+var operationTypeValues = reverseNames(operationTypeNames)
+
+func reverseNames[T ~uint8](names map[T]string) map[string]T {
+	result := make(map[string]T, len(names))
+	for value, name := range names {
+		result[name] = value
+	}
+
+	return result
+}
+
+// String возвращает имя типа события, для неизвестного типа — его номер
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText принимает имя типа события или его номер
+func (t *EventType) UnmarshalText(text []byte) error {
+	value, err := parseName(string(text), eventTypeValues)
+	if err != nil {
+		return fmt.Errorf("invalid event type: %w", err)
+	}
+	*t = value
+
+	return nil
+}
+
+// String возвращает имя типа операции, для неизвестного типа — его номер
+func (t OperationType) String() string {
+	if name, ok := operationTypeNames[t]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+func (t OperationType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText принимает имя типа операции или его номер
+func (t *OperationType) UnmarshalText(text []byte) error {
+	value, err := parseName(string(text), operationTypeValues)
+	if err != nil {
+		return fmt.Errorf("invalid operation type: %w", err)
+	}
+	*t = value
+
+	return nil
+}
+
+// EventTypeNames возвращает отсортированные имена известных адаптеру типов событий
+func EventTypeNames() []string {
+	return slices.Sorted(maps.Values(eventTypeNames))
+}
+
+// OperationTypeNames возвращает отсортированные имена типов операций
+func OperationTypeNames() []string {
+	return slices.Sorted(maps.Values(operationTypeNames))
+}
+
+// ParseEventType возвращает тип события по имени Keycloak
+func ParseEventType(name string) (EventType, bool) {
+	value, ok := eventTypeValues[name]
+	return value, ok
+}
+
+func parseName[T ~uint8](text string, values map[string]T) (T, error) {
+	if value, ok := values[text]; ok {
+		return value, nil
+	}
+
+	number, err := strconv.ParseUint(text, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("unknown name %q", text)
+	}
+
+	return T(number), nil
+}
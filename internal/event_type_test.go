@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventType_Text(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    EventType
+		wantErr bool
+	}{
+		{name: "name", text: "LOGIN_ERROR", want: EventTypeLoginError},
+		{name: "number", text: "7", want: EventTypeCodeToToken},
+		{name: "unknown number", text: "250", want: EventType(250)},
+		{name: "unknown name", text: "LOGIN_FAILED", wantErr: true},
+		{name: "lower case", text: "login", wantErr: true},
+		{name: "out of range", text: "300", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got EventType
+			err := got.UnmarshalText([]byte(tt.text))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEventType_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "LOGIN_ERROR", EventTypeLoginError.String())
+	assert.Equal(t, "OAUTH2_DEVICE_CODE_TO_TOKEN", EventTypeOAuth2DeviceCodeToToken.String())
+	assert.Equal(t, "250", EventType(250).String())
+	assert.Equal(t, "CREATE", OperationTypeCreate.String())
+	assert.Equal(t, "0", OperationType(0).String())
+
+	for eventType, name := range eventTypeNames {
+		var got EventType
+		require.NoError(t, got.UnmarshalText([]byte(name)))
+		assert.Equal(t, eventType, got)
+	}
+
+	assert.Len(t, EventTypeNames(), len(eventTypeNames))
+	assert.Equal(t, []string{"ACTION", "CREATE", "DELETE", "UPDATE"}, OperationTypeNames())
+}
+
+func TestEvent_JSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(&Event{Type: EventTypeRefreshToken})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"REFRESH_TOKEN"`)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(data, &event))
+	assert.Equal(t, EventTypeRefreshToken, event.Type)
+
+	data, err = json.Marshal(&AdminEvent{OperationType: OperationTypeAction})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"operation_type":"ACTION"`)
+
+	var adminEvent AdminEvent
+	require.NoError(t, json.Unmarshal(data, &adminEvent))
+	assert.Equal(t, OperationTypeAction, adminEvent.OperationType)
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindEvents      = "events"
+	KindAdminEvents = "admin_events"
+)
+
+// Meta общие атрибуты событий и событий администрирования
+type Meta struct {
+	Kind       string
+	Id         uuid.UUID
+	Time       time.Time
+	RealmId    uuid.UUID
+	RealmName  string
+	Enrichment *Enrichment
+}
+
+func MetaOf[T Event | AdminEvent](event *T) Meta {
+	switch e := any(event).(type) {
+	case *Event:
+		return Meta{
+			Kind:       KindEvents,
+			Id:         e.Id,
+			Time:       e.Time,
+			RealmId:    e.RealmId,
+			RealmName:  e.RealmName,
+			Enrichment: e.Enrichment,
+		}
+	case *AdminEvent:
+		return Meta{
+			Kind:       KindAdminEvents,
+			Id:         e.Id,
+			Time:       e.Time,
+			RealmId:    e.RealmId,
+			RealmName:  e.RealmName,
+			Enrichment: e.Enrichment,
+		}
+	default:
+		return Meta{}
+	}
+}
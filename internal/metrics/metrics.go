@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "keycloak_events_adapter"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	// BreakerState состояние автомата отправителя: 0 — замкнут, 1 — разомкнут, 2 — полуоткрыт
+	BreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "sink",
+		Name:      "breaker_state",
+		Help:      "Sink circuit breaker state: 0 - closed, 1 - open, 2 - half-open.",
+	}, []string{"sink", "queue"})
+
+	BreakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "sink",
+		Name:      "breaker_transitions_total",
+		Help:      "Sink circuit breaker state transitions.",
+	}, []string{"sink", "queue", "state"})
+
+	// ProcessorEvents результаты обработки событий конвейером: ok, dropped, protected, error
+	ProcessorEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "processor_events_total",
+		Help:      "Events handled by pipeline processors by result: ok, dropped, protected, error.",
+	}, []string{"stage", "kind", "processor", "result"})
+
+	ProcessorDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "processor_duration_seconds",
+		Help:      "Time spent by pipeline processors on a single event.",
+		Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05},
+	}, []string{"stage", "kind", "processor"})
+
+	// RuleDecisions решения правил фильтрации: keep, drop; в режиме dry_run события не отбрасываются
+	RuleDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "rule_decisions_total",
+		Help:      "Filtering rule decisions by rule and decision: keep, drop.",
+	}, []string{"stage", "kind", "processor", "rule", "decision", "dry_run"})
+
+	// RedactUnparsed значения, удаленные обработчиком redact, так как они не разбираются как JSON или URL
+	RedactUnparsed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "redact_unparsed_total",
+		Help:      "Values dropped by redact processor because they can't be parsed as JSON or URL.",
+	}, []string{"kind", "field"})
+
+	// ExprErrors ошибки вычисления выражений CEL условий обработчиков, событие при ошибке не подходит под условие
+	ExprErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "expr_errors_total",
+		Help:      "CEL match expression evaluation errors, the event doesn't match on error.",
+	}, []string{"kind", "expr"})
+
+	// Alerts новые тревоги детекторов, повторные срабатывания активной тревоги не учитываются
+	Alerts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "detect",
+		Name:      "alerts_total",
+		Help:      "New alerts raised by detectors by rule and severity.",
+	}, []string{"rule", "severity"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		BreakerState,
+		BreakerTransitions,
+		ProcessorEvents,
+		ProcessorDuration,
+		RuleDecisions,
+		RedactUnparsed,
+		ExprErrors,
+		Alerts,
+	)
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
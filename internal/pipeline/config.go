@@ -0,0 +1,323 @@
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/detect"
+)
+
+const (
+	TypeGeoIP  = "geoip"
+	TypeFilter = "filter"
+	TypeDrop   = "drop"
+	TypeTag    = "tag"
+	TypeRoute  = "route"
+	TypeRedact = "redact"
+	TypeRules  = "rules"
+	TypePolicy = "policy"
+
+	TypeBruteForce   = "bruteforce"
+	TypeLoginAnomaly = "login_anomaly"
+)
+
+// typeKinds виды событий, которые обрабатывают детекторы; обработчики других типов применяются к любым событиям
+var typeKinds = map[string]string{
+	TypeBruteForce:   internal.KindEvents,
+	TypeLoginAnomaly: internal.KindEvents,
+}
+
+// minHMACKeySize минимальная длина ключа псевдонимизации в байтах
+const minHMACKeySize = 16
+
+// Config конвейеры этапов приема (до помещения в очередь) и доставки (после взятия из очереди)
+type Config struct {
+	Ingest   []StepConfig `yaml:"ingest"`
+	Delivery []StepConfig `yaml:"delivery"`
+}
+
+// StepConfig обработчик конвейера, набор полей зависит от типа
+type StepConfig struct {
+	// Name имя обработчика в метриках и логах, по умолчанию совпадает с типом
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	OnError ErrorPolicy       `yaml:"on_error"`
+	Match   Match             `yaml:"match"`
+	Tags    map[string]string `yaml:"tags"`
+	Route   string            `yaml:"route"`
+
+	Rules []RuleConfig `yaml:"rules"`
+	// Default действие rules для событий, не подошедших ни под одно правило
+	Default RuleAction `yaml:"default"`
+	DryRun  bool       `yaml:"dry_run"`
+	// HMACKeyFile файл ключа псевдонимизации, пробельные символы в начале и конце не учитываются
+	HMACKeyFile string `yaml:"hmac_key_file"`
+	IPv4Prefix  int    `yaml:"ipv4_prefix"`
+	IPv6Prefix  int    `yaml:"ipv6_prefix"`
+
+	// AlertRisk и ProtectRisk уровни риска policy, начиная с которых событие становится тревогой и защищается
+	AlertRisk   internal.AlertSeverity `yaml:"alert_risk"`
+	ProtectRisk internal.AlertSeverity `yaml:"protect_risk"`
+
+	BruteForce   detect.BruteForceOptions   `yaml:"bruteforce"`
+	LoginAnomaly detect.LoginAnomalyOptions `yaml:"login_anomaly"`
+}
+
+// RuleConfig правило обработчика: redact использует field, action, query_params и paths,
+// rules — name, match, action и rate, policy — name, match и risk
+type RuleConfig struct {
+	Name        string                 `yaml:"name"`
+	Field       string                 `yaml:"field"`
+	Match       Match                  `yaml:"match"`
+	Action      string                 `yaml:"action"`
+	Rate        float64                `yaml:"rate"`
+	QueryParams []string               `yaml:"query_params"`
+	Paths       []string               `yaml:"paths"`
+	Risk        internal.AlertSeverity `yaml:"risk"`
+}
+
+// Deps общие ресурсы обработчиков, nil — ресурс не настроен
+type Deps struct {
+	Geo GeoLookup
+	// Windows, History и Alerts хранилища окон и истории входов и приемник тревог детекторов
+	Windows detect.Windows
+	History detect.History
+	Alerts  detect.AlertRaiser
+}
+
+// HasDetectors проверяет, есть ли в конвейерах детекторы и политики с тревогами, которым нужны хранилища
+// и приемник тревог Deps
+func (c *Config) HasDetectors() bool {
+	for _, sc := range slices.Concat(c.Ingest, c.Delivery) {
+		if _, ok := typeKinds[sc.Type]; ok || sc.Type == TypePolicy && sc.AlertRisk != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadConfig читает конфигурацию конвейеров из YAML файла, неизвестные поля считаются ошибкой
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read pipeline config: %w", err)
+	}
+
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("can't parse pipeline config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Build создает конвейер этапа stage. Обработчики, условие которых ограничено другим видом событий, пропускаются.
+// Детекторы допускаются только на этапе приема.
+func Build[T internal.Event | internal.AdminEvent](
+	stage string,
+	steps []StepConfig,
+	deps Deps,
+	logger *zap.Logger,
+) (*Pipeline[T], error) {
+	p := New[T](stage, logger)
+	names := map[string]bool{}
+	for i, sc := range steps {
+		name := sc.Name
+		if name == "" {
+			name = sc.Type
+		}
+		if names[name] {
+			return nil, fmt.Errorf("%s step %d: duplicate processor name %q", stage, i, name)
+		}
+		names[name] = true
+
+		err := sc.validate()
+		if err != nil {
+			return nil, fmt.Errorf("%s step %s: %w", stage, name, err)
+		}
+		// повторная доставка события из очереди снова учитывалась бы в счетчиках детекторов
+		if _, ok := typeKinds[sc.Type]; ok && stage != StageIngest {
+			return nil, fmt.Errorf("%s step %s: detector %s is allowed in %s stage only", stage, name, sc.Type, StageIngest)
+		}
+
+		if kind, ok := typeKinds[sc.Type]; !matchAny(sc.Match.Kinds, p.kind) || (ok && kind != p.kind) {
+			continue
+		}
+		err = compileMatch[T](&sc.Match)
+		if err != nil {
+			return nil, fmt.Errorf("%s step %s: %w", stage, name, err)
+		}
+
+		processor, err := newProcessor[T](stage, name, sc, deps, logger)
+		if err != nil {
+			return nil, fmt.Errorf("%s step %s: %w", stage, name, err)
+		}
+		p.Add(name, processor, sc.OnError)
+	}
+
+	return p, nil
+}
+
+func (sc *StepConfig) validate() error {
+	if sc.OnError != "" {
+		err := sc.OnError.validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return sc.Match.validate()
+}
+
+func newProcessor[T internal.Event | internal.AdminEvent](
+	stage string,
+	name string,
+	sc StepConfig,
+	deps Deps,
+	logger *zap.Logger,
+) (Processor[T], error) {
+	switch sc.Type {
+	case TypeGeoIP:
+		if deps.Geo == nil {
+			return nil, errors.New("geoip database is not configured")
+		}
+		return NewGeoIP[T](deps.Geo), nil
+	case TypeFilter:
+		return NewFilter[T](sc.Match), nil
+	case TypeDrop:
+		return NewDropMatched[T](sc.Match), nil
+	case TypeTag:
+		if len(sc.Tags) == 0 {
+			return nil, errors.New("tags are empty")
+		}
+		return NewTag[T](sc.Match, sc.Tags), nil
+	case TypeRoute:
+		if sc.Route == "" {
+			return nil, errors.New("route is empty")
+		}
+		return NewRoute[T](sc.Match, sc.Route), nil
+	case TypeRedact:
+		var key []byte
+		if sc.HMACKeyFile != "" {
+			data, err := os.ReadFile(sc.HMACKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("can't read hmac key: %w", err)
+			}
+			key = bytes.TrimSpace(data)
+			if len(key) < minHMACKeySize {
+				return nil, fmt.Errorf("hmac key is shorter than %d bytes", minHMACKeySize)
+			}
+		}
+		rules, err := redactRules(sc.Rules)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedact[T](rules, key, sc.IPv4Prefix, sc.IPv6Prefix)
+	case TypeBruteForce:
+		if deps.Windows == nil || deps.Alerts == nil {
+			return nil, errors.New("detectors are not configured")
+		}
+		detector, err := detect.NewBruteForce(sc.BruteForce, deps.Windows, deps.Alerts)
+		if err != nil {
+			return nil, err
+		}
+		return NewDetect[T](sc.Match, detector), nil
+	case TypeLoginAnomaly:
+		if deps.History == nil || deps.Alerts == nil {
+			return nil, errors.New("detectors are not configured")
+		}
+		detector, err := detect.NewLoginAnomaly(sc.LoginAnomaly, deps.History, deps.Alerts)
+		if err != nil {
+			return nil, err
+		}
+		return NewDetect[T](sc.Match, detector), nil
+	case TypePolicy:
+		rules, err := policyRules(sc.Rules)
+		if err != nil {
+			return nil, err
+		}
+		return NewPolicy[T](PolicyOptions{
+			Rules:       rules,
+			AlertRisk:   sc.AlertRisk,
+			ProtectRisk: sc.ProtectRisk,
+		}, deps.Alerts)
+	case TypeRules:
+		rules, err := filterRules(sc.Rules)
+		if err != nil {
+			return nil, err
+		}
+		return NewRules[T](RulesOptions{
+			Stage:   stage,
+			Name:    name,
+			Rules:   rules,
+			Default: sc.Default,
+			DryRun:  sc.DryRun,
+		}, logger)
+	case "":
+		return nil, errors.New("type is empty")
+	default:
+		return nil, fmt.Errorf("unknown type %q", sc.Type)
+	}
+}
+
+func redactRules(configs []RuleConfig) ([]RedactRule, error) {
+	rules := make([]RedactRule, 0, len(configs))
+	for i, rc := range configs {
+		if rc.Name != "" || rc.Rate != 0 || rc.Risk != "" || !reflect.ValueOf(rc.Match).IsZero() {
+			return nil, fmt.Errorf("rule %d: name, match, rate and risk aren't applicable to redact", i)
+		}
+		rules = append(rules, RedactRule{
+			Field:       rc.Field,
+			Action:      RedactAction(rc.Action),
+			QueryParams: rc.QueryParams,
+			Paths:       rc.Paths,
+		})
+	}
+
+	return rules, nil
+}
+
+func filterRules(configs []RuleConfig) ([]Rule, error) {
+	rules := make([]Rule, 0, len(configs))
+	for i, rc := range configs {
+		if rc.Field != "" || len(rc.QueryParams) > 0 || len(rc.Paths) > 0 || rc.Risk != "" {
+			return nil, fmt.Errorf("rule %d: field, query_params, paths and risk aren't applicable to rules", i)
+		}
+		rules = append(rules, Rule{
+			Name:   rc.Name,
+			Match:  rc.Match,
+			Action: RuleAction(rc.Action),
+			Rate:   rc.Rate,
+		})
+	}
+
+	return rules, nil
+}
+
+func policyRules(configs []RuleConfig) ([]PolicyRule, error) {
+	rules := make([]PolicyRule, 0, len(configs))
+	for i, rc := range configs {
+		if rc.Field != "" || rc.Action != "" || rc.Rate != 0 || len(rc.QueryParams) > 0 || len(rc.Paths) > 0 {
+			return nil, fmt.Errorf("rule %d: field, action, rate, query_params and paths aren't applicable to policy", i)
+		}
+		rules = append(rules, PolicyRule{
+			Name:  rc.Name,
+			Match: rc.Match,
+			Risk:  rc.Risk,
+		})
+	}
+
+	return rules, nil
+}
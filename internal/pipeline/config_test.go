@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "pipeline.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(writeConfig(t, `
+ingest:
+  - type: geoip
+    on_error: skip
+  - name: noise
+    type: drop
+    match:
+      kinds: [events]
+      event_types: [REFRESH_TOKEN, CODE_TO_TOKEN]
+delivery:
+  - type: tag
+    tags:
+      env: prod
+  - type: route
+    route: siem
+    match:
+      realms: [master]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Ingest: []StepConfig{
+			{Type: TypeGeoIP, OnError: OnErrorSkip},
+			{
+				Name:  "noise",
+				Type:  TypeDrop,
+				Match: Match{Kinds: []string{internal.KindEvents}, EventTypes: []string{"REFRESH_TOKEN", "CODE_TO_TOKEN"}},
+			},
+		},
+		Delivery: []StepConfig{
+			{Type: TypeTag, Tags: map[string]string{"env": "prod"}},
+			{Type: TypeRoute, Route: "siem", Match: Match{Realms: []string{"master"}}},
+		},
+	}, cfg)
+
+	events, err := Build[internal.Event](StageIngest, cfg.Ingest, Deps{Geo: testGeo}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 2, events.Len())
+
+	adminEvents, err := Build[internal.AdminEvent](StageIngest, cfg.Ingest, Deps{Geo: testGeo}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, adminEvents.Len())
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown field", content: "ingest:\n  - type: tag\n    labels: {env: prod}\n"},
+		{name: "invalid yaml", content: "ingest: [\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestBuild_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []StepConfig
+		deps  Deps
+	}{
+		{name: "empty type", steps: []StepConfig{{}}},
+		{name: "unknown type", steps: []StepConfig{{Type: "enrich"}}},
+		{name: "geoip without database", steps: []StepConfig{{Type: TypeGeoIP}}},
+		{name: "empty tags", steps: []StepConfig{{Type: TypeTag}}},
+		{name: "empty route", steps: []StepConfig{{Type: TypeRoute}}},
+		{name: "unknown policy", steps: []StepConfig{{Type: TypeDrop, OnError: "retry"}}},
+		{name: "unknown kind", steps: []StepConfig{{Type: TypeDrop, Match: Match{Kinds: []string{"users"}}}}},
+		{name: "unknown outcome", steps: []StepConfig{{Type: TypeDrop, Match: Match{Outcome: "failure"}}}},
+		{name: "unknown operation type", steps: []StepConfig{{Type: TypeDrop, Match: Match{OperationTypes: []string{"PATCH"}}}}},
+		{name: "invalid pattern", steps: []StepConfig{{Type: TypeDrop, Match: Match{EventTypes: []string{"[LOGIN"}}}}},
+		{name: "field of rules", steps: []StepConfig{{Type: TypeRules, Rules: []RuleConfig{{Field: FieldUserId, Action: "drop"}}}}},
+		{name: "match of redact", steps: []StepConfig{{Type: TypeRedact, Rules: []RuleConfig{{Match: Match{Realms: []string{"master"}}, Field: FieldUserId, Action: "drop"}}}}},
+		{name: "action of policy", steps: []StepConfig{{Type: TypePolicy, Rules: []RuleConfig{{Risk: "high", Action: "drop"}}}}},
+		{name: "risk of rules", steps: []StepConfig{{Type: TypeRules, Rules: []RuleConfig{{Risk: "high", Action: "drop"}}}}},
+		{name: "policy alerts without alerter", steps: []StepConfig{{Type: TypePolicy, AlertRisk: internal.AlertSeverityHigh}}},
+		{name: "duplicate name", steps: []StepConfig{{Type: TypeDrop}, {Type: TypeDrop}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Build[internal.Event](StageIngest, tt.steps, tt.deps, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
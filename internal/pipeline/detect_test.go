@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/detect"
+)
+
+type alertsFunc func(alert *internal.Alert) error
+
+func (f alertsFunc) Raise(alert *internal.Alert) error {
+	return f(alert)
+}
+
+func TestBuild_BruteForce(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(writeConfig(t, `
+ingest:
+  - type: bruteforce
+    on_error: skip
+    match:
+      realms: [master]
+    bruteforce:
+      user_failures: {count: 2, window: 5m}
+      ip_users: {count: 10, window: 10m}
+      client_failure_ratio: {ratio: 0.5, min_events: 20, window: 5m}
+      cooldown: 30m
+`))
+	require.NoError(t, err)
+	assert.True(t, cfg.HasDetectors())
+	assert.Equal(t, detect.BruteForceOptions{
+		UserFailures:       detect.Threshold{Count: 2, Window: 5 * time.Minute},
+		IPUsers:            detect.Threshold{Count: 10, Window: 10 * time.Minute},
+		ClientFailureRatio: detect.RatioThreshold{Ratio: 0.5, MinEvents: 20, Window: 5 * time.Minute},
+		Cooldown:           30 * time.Minute,
+	}, cfg.Ingest[0].BruteForce)
+
+	var alerts []*internal.Alert
+	deps := Deps{
+		Windows: detect.NewMemoryWindows(),
+		Alerts: alertsFunc(func(alert *internal.Alert) error {
+			alerts = append(alerts, alert)
+			return nil
+		}),
+	}
+
+	events, err := Build[internal.Event](StageIngest, cfg.Ingest, deps, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, events.Len())
+
+	// повторная доставка из очереди снова учитывалась бы в окнах детектора
+	_, err = Build[internal.Event](StageDelivery, cfg.Ingest, deps, zap.NewNop())
+	assert.ErrorContains(t, err, "allowed in ingest stage only")
+
+	adminEvents, err := Build[internal.AdminEvent](StageIngest, cfg.Ingest, deps, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 0, adminEvents.Len())
+
+	userId := uuid.New()
+	for _, realm := range []string{"master", "other", "other", "master"} {
+		result, err := events.Run(&internal.Event{Id: uuid.New(), Type: internal.EventTypeLoginError, RealmName: realm, UserId: userId})
+		require.NoError(t, err)
+		assert.Equal(t, Continue, result)
+	}
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "master/"+userId.String(), alerts[0].Key)
+
+	_, err = Build[internal.Event](StageIngest, cfg.Ingest, Deps{}, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = Build[internal.Event](StageIngest, []StepConfig{{Type: TypeBruteForce}}, deps, zap.NewNop())
+	assert.Error(t, err)
+
+	assert.False(t, (&Config{Ingest: []StepConfig{{Type: TypeGeoIP}}}).HasDetectors())
+}
+
+func TestBuild_LoginAnomaly(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(writeConfig(t, `
+ingest:
+  - type: geoip
+  - type: login_anomaly
+    login_anomaly:
+      max_speed: 900
+      new_country: true
+      history_size: 20
+      retention: 720h
+`))
+	require.NoError(t, err)
+	assert.True(t, cfg.HasDetectors())
+	assert.Equal(t, detect.LoginAnomalyOptions{
+		MaxSpeed:    900,
+		NewCountry:  true,
+		HistorySize: 20,
+		Retention:   720 * time.Hour,
+	}, cfg.Ingest[1].LoginAnomaly)
+
+	locations := map[string]*internal.Geo{
+		"198.51.100.1": {CountryCode: "DE", Latitude: 52.52, Longitude: 13.405},
+		"203.0.113.7":  {CountryCode: "US", Latitude: 40.7128, Longitude: -74.006},
+	}
+	var alerts []*internal.Alert
+	deps := Deps{
+		Geo:     geoLookupFunc(func(ip string) *internal.Geo { return locations[ip] }),
+		History: detect.NewMemoryHistory(),
+		Alerts: alertsFunc(func(alert *internal.Alert) error {
+			alerts = append(alerts, alert)
+			return nil
+		}),
+	}
+
+	events, err := Build[internal.Event](StageIngest, cfg.Ingest, deps, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 2, events.Len())
+
+	_, err = Build[internal.Event](StageDelivery, cfg.Ingest, deps, zap.NewNop())
+	assert.ErrorContains(t, err, "allowed in ingest stage only")
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	userId := uuid.New()
+	for i, ip := range []string{"198.51.100.1", "203.0.113.7"} {
+		result, err := events.Run(&internal.Event{
+			Id:        uuid.New(),
+			Time:      start.Add(time.Duration(i) * time.Hour),
+			Type:      internal.EventTypeLogin,
+			RealmName: "master",
+			UserId:    userId,
+			IpAddress: ip,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, Continue, result)
+	}
+
+	var rules []string
+	for _, alert := range alerts {
+		rules = append(rules, alert.Rule)
+	}
+	assert.Equal(t, []string{detect.RuleImpossibleTravel, detect.RuleNewCountry}, rules)
+
+	_, err = Build[internal.Event](StageIngest, cfg.Ingest, Deps{Geo: deps.Geo, Alerts: deps.Alerts}, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = Build[internal.Event](StageIngest, []StepConfig{{Type: TypeLoginAnomaly}}, deps, zap.NewNop())
+	assert.Error(t, err)
+}
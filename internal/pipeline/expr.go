@@ -0,0 +1,280 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+// exprVariable имя события в выражениях
+const exprVariable = "event"
+
+// Expr выражение CEL, проверенное для одного вида событий
+type Expr struct {
+	source  string
+	program cel.Program
+	// failed ошибки вычисления выражения в условиях обработчиков
+	failed prometheus.Counter
+}
+
+type geoView struct {
+	Scope          string  `cel:"scope"`
+	CountryCode    string  `cel:"country_code"`
+	CountryName    string  `cel:"country_name"`
+	City           string  `cel:"city"`
+	Latitude       float64 `cel:"latitude"`
+	Longitude      float64 `cel:"longitude"`
+	ASN            uint64  `cel:"asn"`
+	ASOrganization string  `cel:"as_organization"`
+}
+
+type resourceSegmentView struct {
+	Kind string `cel:"kind"`
+	Id   string `cel:"id"`
+}
+
+type resourceView struct {
+	Kind           string                `cel:"kind"`
+	Segments       []resourceSegmentView `cel:"segments"`
+	TargetUserId   string                `cel:"target_user_id"`
+	TargetClientId string                `cel:"target_client_id"`
+	TargetGroupId  string                `cel:"target_group_id"`
+}
+
+// eventView событие в выражениях: UUID представлены строками, нулевой UUID — пустой строкой
+type eventView struct {
+	Id        string            `cel:"id"`
+	Time      time.Time         `cel:"time"`
+	Type      string            `cel:"type"`
+	RealmId   string            `cel:"realm_id"`
+	RealmName string            `cel:"realm_name"`
+	ClientId  string            `cel:"client_id"`
+	UserId    string            `cel:"user_id"`
+	SessionId string            `cel:"session_id"`
+	IpAddress string            `cel:"ip_address"`
+	Error     string            `cel:"error"`
+	Details   map[string]string `cel:"details"`
+	Geo       geoView           `cel:"geo"`
+	Route     string            `cel:"route"`
+	Tags      map[string]string `cel:"tags"`
+}
+
+type authDetailsView struct {
+	RealmId   string `cel:"realm_id"`
+	RealmName string `cel:"realm_name"`
+	ClientId  string `cel:"client_id"`
+	UserId    string `cel:"user_id"`
+	IpAddress string `cel:"ip_address"`
+}
+
+type adminEventView struct {
+	Id             string            `cel:"id"`
+	Time           time.Time         `cel:"time"`
+	RealmId        string            `cel:"realm_id"`
+	RealmName      string            `cel:"realm_name"`
+	AuthDetails    authDetailsView   `cel:"auth_details"`
+	ResourceType   string            `cel:"resource_type"`
+	OperationType  string            `cel:"operation_type"`
+	ResourcePath   string            `cel:"resource_path"`
+	Resource       resourceView      `cel:"resource"`
+	Representation string            `cel:"representation"`
+	Error          string            `cel:"error"`
+	Details        map[string]string `cel:"details"`
+	Geo            geoView           `cel:"geo"`
+	Route          string            `cel:"route"`
+	Tags           map[string]string `cel:"tags"`
+}
+
+// Имена типов событий и операций объявлены константами, поэтому в выражениях пишутся без кавычек:
+// event.type == LOGIN_ERROR, event.operation_type == DELETE
+var (
+	eventExprEnv = sync.OnceValues(func() (*cel.Env, error) {
+		return newExprEnv(reflect.TypeFor[eventView](), internal.EventTypeNames())
+	})
+	adminEventExprEnv = sync.OnceValues(func() (*cel.Env, error) {
+		return newExprEnv(reflect.TypeFor[adminEventView](), internal.OperationTypeNames())
+	})
+)
+
+func newExprEnv(view reflect.Type, constants []string) (*cel.Env, error) {
+	opts := []cel.EnvOption{
+		ext.NativeTypes(view, ext.ParseStructTags(true)),
+		cel.Variable(exprVariable, cel.ObjectType(view.String())),
+		ext.Strings(),
+	}
+	for _, name := range constants {
+		opts = append(opts, cel.Constant(name, cel.StringType, types.String(name)))
+	}
+
+	return cel.NewEnv(opts...)
+}
+
+// CompileExpr проверяет типы выражения для вида событий T, выражение должно возвращать bool
+func CompileExpr[T internal.Event | internal.AdminEvent](source string) (*Expr, error) {
+	if source == "" {
+		return nil, errors.New("expression is empty")
+	}
+
+	var (
+		env *cel.Env
+		err error
+	)
+	switch any(new(T)).(type) {
+	case *internal.Event:
+		env, err = eventExprEnv()
+	case *internal.AdminEvent:
+		env, err = adminEventExprEnv()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create expression environment: %w", err)
+	}
+
+	ast, issues := env.Compile(source)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("compile expression for %s: %w", internal.MetaOf(new(T)).Kind, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("expression must return bool, got %s", ast.OutputType())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("create expression program: %w", err)
+	}
+
+	kind := internal.MetaOf(new(T)).Kind
+	return &Expr{
+		source:  source,
+		program: program,
+		failed:  metrics.ExprErrors.WithLabelValues(kind, source),
+	}, nil
+}
+
+// EvalExpr вычисляет выражение, скомпилированное для вида событий T
+func EvalExpr[T internal.Event | internal.AdminEvent](expr *Expr, event *T) (bool, error) {
+	out, _, err := expr.program.Eval(map[string]any{exprVariable: exprView(event)})
+	if err != nil {
+		return false, fmt.Errorf("evaluate %q: %w", expr.source, err)
+	}
+
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("evaluate %q: result %v isn't bool", expr.source, out.Value())
+	}
+
+	return result, nil
+}
+
+func exprView[T internal.Event | internal.AdminEvent](event *T) any {
+	var (
+		geo  geoView
+		meta = internal.MetaOf(event)
+	)
+	if meta.Enrichment != nil && meta.Enrichment.Geo != nil {
+		g := meta.Enrichment.Geo
+		geo = geoView{
+			Scope:          string(g.Scope),
+			CountryCode:    g.CountryCode,
+			CountryName:    g.CountryName,
+			City:           g.City,
+			Latitude:       g.Latitude,
+			Longitude:      g.Longitude,
+			ASN:            uint64(g.ASN),
+			ASOrganization: g.ASOrganization,
+		}
+	}
+	var (
+		route string
+		tags  map[string]string
+	)
+	if meta.Enrichment != nil {
+		route, tags = meta.Enrichment.Route, meta.Enrichment.Tags
+	}
+
+	switch e := any(event).(type) {
+	case *internal.Event:
+		return eventView{
+			Id:        uuidString(e.Id),
+			Time:      e.Time,
+			Type:      e.TypeName(),
+			RealmId:   uuidString(e.RealmId),
+			RealmName: e.RealmName,
+			ClientId:  e.ClientId,
+			UserId:    uuidString(e.UserId),
+			SessionId: e.SessionId,
+			IpAddress: e.IpAddress,
+			Error:     e.Error,
+			Details:   e.Details,
+			Geo:       geo,
+			Route:     route,
+			Tags:      tags,
+		}
+	case *internal.AdminEvent:
+		var authDetails authDetailsView
+		if e.AuthDetails != nil {
+			authDetails = authDetailsView{
+				RealmId:   uuidString(e.AuthDetails.RealmId),
+				RealmName: e.AuthDetails.RealmName,
+				ClientId:  uuidString(e.AuthDetails.ClientId),
+				UserId:    uuidString(e.AuthDetails.UserId),
+				IpAddress: e.AuthDetails.IpAddress,
+			}
+		}
+		return adminEventView{
+			Id:             uuidString(e.Id),
+			Time:           e.Time,
+			RealmId:        uuidString(e.RealmId),
+			RealmName:      e.RealmName,
+			AuthDetails:    authDetails,
+			ResourceType:   e.ResourceType,
+			OperationType:  e.OperationType.String(),
+			ResourcePath:   e.ResourcePath,
+			Resource:       resourceViewOf(internal.ResourceOf(e)),
+			Representation: e.Representation,
+			Error:          e.Error,
+			Details:        e.Details,
+			Geo:            geo,
+			Route:          route,
+			Tags:           tags,
+		}
+	}
+
+	return nil
+}
+
+func resourceViewOf(ref *internal.ResourceRef) resourceView {
+	if ref == nil {
+		return resourceView{}
+	}
+
+	segments := make([]resourceSegmentView, 0, len(ref.Segments))
+	for _, s := range ref.Segments {
+		segments = append(segments, resourceSegmentView{Kind: s.Kind, Id: s.Id})
+	}
+
+	return resourceView{
+		Kind:           ref.Kind,
+		Segments:       segments,
+		TargetUserId:   ref.TargetUserId,
+		TargetClientId: ref.TargetClientId,
+		TargetGroupId:  ref.TargetGroupId,
+	}
+}
+
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+
+	return id.String()
+}
@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+func TestEvalExpr_Event(t *testing.T) {
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Type:      internal.EventTypeLoginError,
+		RealmName: "master",
+		ClientId:  "account",
+		Error:     "invalid_user_credentials",
+		Details:   map[string]string{"error": "invalid_user_credentials", "username": "john"},
+		Enrichment: &internal.Enrichment{
+			Geo:  &internal.Geo{Scope: internal.GeoScopePublic, CountryCode: "GB", ASN: 20712},
+			Tags: map[string]string{"env": "prod"},
+		},
+	}
+
+	tests := []struct {
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{expr: `event.type == LOGIN_ERROR && event.details["error"] == "invalid_user_credentials"`, want: true},
+		{expr: `event.type == LOGIN`},
+		{expr: `event.type.endsWith("_ERROR") && event.realm_name in ["master", "other"]`, want: true},
+		{expr: `event.user_id == "" && event.id != ""`, want: true},
+		{expr: `event.geo.country_code == "GB" && event.geo.asn == 20712u`, want: true},
+		{expr: `event.tags["env"] == "prod" && event.route == ""`, want: true},
+		{expr: `"code_id" in event.details`},
+		{expr: `event.details["code_id"] == "abc"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+
+			expr, err := CompileExpr[internal.Event](tt.expr)
+			require.NoError(t, err)
+
+			got, err := EvalExpr(expr, event)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvalExpr_AdminEvent(t *testing.T) {
+	t.Parallel()
+
+	clientId := uuid.New()
+	expr, err := CompileExpr[internal.AdminEvent](
+		`event.operation_type == DELETE && event.resource_path.startsWith("users/") && event.auth_details.client_id == "` + clientId.String() + `"`,
+	)
+	require.NoError(t, err)
+
+	got, err := EvalExpr(expr, &internal.AdminEvent{
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeDelete,
+		ResourcePath:  "users/1",
+		AuthDetails:   &internal.AuthDetails{ClientId: clientId},
+	})
+	require.NoError(t, err)
+	assert.True(t, got)
+
+	got, err = EvalExpr(expr, &internal.AdminEvent{OperationType: internal.OperationTypeDelete, ResourcePath: "users/1"})
+	require.NoError(t, err)
+	assert.False(t, got)
+
+	expr, err = CompileExpr[internal.AdminEvent](
+		`event.resource.kind == "users/role-mappings/clients" && event.resource.target_user_id == "1" && ` +
+			`event.resource.segments[2].id == event.resource.target_client_id`,
+	)
+	require.NoError(t, err)
+
+	got, err = EvalExpr(expr, &internal.AdminEvent{ResourcePath: "users/1/role-mappings/clients/2"})
+	require.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestCompileExpr_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "empty"},
+		{name: "syntax", expr: `event.type ==`},
+		{name: "unknown field", expr: `event.resource_type == "USER"`},
+		{name: "unknown constant", expr: `event.type == LOGIN_FAILED`},
+		{name: "type mismatch", expr: `event.realm_name == 1`},
+		{name: "not bool", expr: `event.realm_name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := CompileExpr[internal.Event](tt.expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBuild_Expr(t *testing.T) {
+	t.Parallel()
+
+	steps := []StepConfig{
+		{Name: "errors", Type: TypeRoute, Route: "siem", Match: Match{Kinds: []string{internal.KindEvents}, Expr: `event.error != ""`}},
+		{Name: "deletes", Type: TypeDrop, Match: Match{Kinds: []string{internal.KindAdminEvents}, Expr: `event.operation_type != DELETE`}},
+	}
+
+	events, err := Build[internal.Event](StageDelivery, steps, Deps{}, zap.NewNop())
+	require.NoError(t, err)
+	event := &internal.Event{Error: "expired_code"}
+	result, err := events.Run(event)
+	require.NoError(t, err)
+	assert.Equal(t, Continue, result)
+	assert.Equal(t, "siem", event.Enrichment.Route)
+
+	adminEvents, err := Build[internal.AdminEvent](StageDelivery, steps, Deps{}, zap.NewNop())
+	require.NoError(t, err)
+	result, err = adminEvents.Run(&internal.AdminEvent{OperationType: internal.OperationTypeUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, Drop, result)
+
+	// выражение проверяется для каждого вида событий, к которому применяется обработчик
+	_, err = Build[internal.AdminEvent](StageDelivery, []StepConfig{{Type: TypeDrop, Match: Match{Expr: `event.type == LOGIN`}}}, Deps{}, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestMatches_ExprError(t *testing.T) {
+	t.Parallel()
+
+	source := `event.details["expr_error_test"] == "x"`
+	m := Match{Expr: source}
+	require.NoError(t, compileMatch[internal.Event](&m))
+
+	failed := metrics.ExprErrors.WithLabelValues(internal.KindEvents, source)
+	before := testutil.ToFloat64(failed)
+
+	assert.False(t, Matches(&m, &internal.Event{Id: uuid.New()}))
+	assert.Equal(t, before+1, testutil.ToFloat64(failed))
+
+	assert.True(t, Matches(&m, &internal.Event{Id: uuid.New(), Details: map[string]string{"expr_error_test": "x"}}))
+	assert.Equal(t, before+1, testutil.ToFloat64(failed))
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathElem элемент пути: ключ объекта, индекс массива или все элементы массива ([*])
+type pathElem struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parsePath разбирает путь вида credentials[*].value, attributes.email[0] или [*].id
+func parsePath(path string) ([]pathElem, error) {
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+
+	var elems []pathElem
+	for i, part := range strings.Split(path, ".") {
+		name, rest, hasIndex := strings.Cut(part, "[")
+		if name == "" && (i > 0 || !hasIndex) {
+			return nil, fmt.Errorf("empty key in path %q", path)
+		}
+		if name != "" {
+			elems = append(elems, pathElem{key: name})
+		}
+
+		for hasIndex {
+			index, tail, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("unclosed [ in path %q", path)
+			}
+
+			switch {
+			case index == "*":
+				elems = append(elems, pathElem{wildcard: true})
+			default:
+				n, err := strconv.Atoi(index)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid index [%s] in path %q", index, path)
+				}
+				elems = append(elems, pathElem{index: n, isIndex: true})
+			}
+
+			if tail != "" && !strings.HasPrefix(tail, "[") {
+				return nil, fmt.Errorf("unexpected %q in path %q", tail, path)
+			}
+			rest, hasIndex = strings.CutPrefix(tail, "[")
+		}
+	}
+
+	return elems, nil
+}
+
+// walkPath применяет fn к значениям по пути. fn возвращает новое значение или false, если значение нужно удалить:
+// ключ удаляется из объекта, элемент — из массива. Отсутствующие ключи и индексы пропускаются.
+func walkPath(node any, path []pathElem, fn func(value any) (any, bool)) any {
+	if len(path) == 0 {
+		return node
+	}
+	elem, rest := path[0], path[1:]
+
+	apply := func(value any) (any, bool) {
+		if len(rest) == 0 {
+			return fn(value)
+		}
+		return walkPath(value, rest, fn), true
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		if elem.isIndex || elem.wildcard {
+			return node
+		}
+		value, ok := n[elem.key]
+		if !ok {
+			return node
+		}
+		if value, ok = apply(value); ok {
+			n[elem.key] = value
+		} else {
+			delete(n, elem.key)
+		}
+	case []any:
+		if !elem.isIndex && !elem.wildcard {
+			return node
+		}
+		result := n[:0:0]
+		for i, value := range n {
+			if elem.isIndex && i != elem.index {
+				result = append(result, value)
+				continue
+			}
+			if value, ok := apply(value); ok {
+				result = append(result, value)
+			}
+		}
+		return result
+	}
+
+	return node
+}
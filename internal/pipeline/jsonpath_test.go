@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathElem
+		wantErr bool
+	}{
+		{path: "email", want: []pathElem{{key: "email"}}},
+		{path: "credentials[*].value", want: []pathElem{{key: "credentials"}, {wildcard: true}, {key: "value"}}},
+		{path: "attributes.phone[0]", want: []pathElem{{key: "attributes"}, {key: "phone"}, {index: 0, isIndex: true}}},
+		{path: "[*].id", want: []pathElem{{wildcard: true}, {key: "id"}}},
+		{path: "matrix[1][*]", want: []pathElem{{key: "matrix"}, {index: 1, isIndex: true}, {wildcard: true}}},
+		{path: "", wantErr: true},
+		{path: "a..b", wantErr: true},
+		{path: "a[", wantErr: true},
+		{path: "a[x]", wantErr: true},
+		{path: "a[-1]", wantErr: true},
+		{path: "a[0]b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parsePath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWalkPath(t *testing.T) {
+	const doc = `{"username":"john","credentials":[{"type":"password","value":"secret"},{"type":"otp","value":"123"}],"attributes":{"phone":["+1","+2"]}}`
+
+	drop := func(any) (any, bool) { return nil, false }
+	mask := func(any) (any, bool) { return "***", true }
+
+	tests := []struct {
+		name string
+		path string
+		fn   func(any) (any, bool)
+		want string
+	}{
+		{
+			name: "drop wildcard",
+			path: "credentials[*].value",
+			fn:   drop,
+			want: `{"username":"john","credentials":[{"type":"password"},{"type":"otp"}],"attributes":{"phone":["+1","+2"]}}`,
+		},
+		{
+			name: "mask index",
+			path: "attributes.phone[1]",
+			fn:   mask,
+			want: `{"username":"john","credentials":[{"type":"password","value":"secret"},{"type":"otp","value":"123"}],"attributes":{"phone":["+1","***"]}}`,
+		},
+		{
+			name: "drop array element",
+			path: "credentials[0]",
+			fn:   drop,
+			want: `{"username":"john","credentials":[{"type":"otp","value":"123"}],"attributes":{"phone":["+1","+2"]}}`,
+		},
+		{
+			name: "missing key",
+			path: "email",
+			fn:   drop,
+			want: doc,
+		},
+		{
+			name: "type mismatch",
+			path: "username[*]",
+			fn:   drop,
+			want: doc,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var node any
+			require.NoError(t, json.Unmarshal([]byte(doc), &node))
+			path, err := parsePath(tt.path)
+			require.NoError(t, err)
+
+			got, err := json.Marshal(walkPath(node, path, tt.fn))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
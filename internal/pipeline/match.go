@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"keycloak-events-adapter/internal"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Match условие обработчика: событие подходит, если каждое заданное поле содержит его значение.
+// Пустое условие подходит для любого события. Типы событий и ресурсов задаются шаблонами вида *_ERROR.
+type Match struct {
+	Kinds  []string `yaml:"kinds"`
+	Realms []string `yaml:"realms"`
+	// Clients client_id события или auth_details.client_id события администрирования
+	Clients    []string `yaml:"clients"`
+	EventTypes []string `yaml:"event_types"`
+	// Outcome error — у события есть ошибка, success — ошибки нет
+	Outcome        string   `yaml:"outcome"`
+	ResourceTypes  []string `yaml:"resource_types"`
+	OperationTypes []string `yaml:"operation_types"`
+	// ResourcePaths шаблоны пути ресурса вида users/*/role-mappings/*, * не включает /
+	ResourcePaths []string `yaml:"resource_paths"`
+	// ResourceKinds шаблоны цепочки типов ресурсов пути без идентификаторов вида users/role-mappings/*
+	ResourceKinds []string `yaml:"resource_kinds"`
+	// TargetUsers, TargetClients и TargetGroups идентификаторы пользователя, клиента и группы из пути ресурса
+	TargetUsers   []string `yaml:"target_users"`
+	TargetClients []string `yaml:"target_clients"`
+	TargetGroups  []string `yaml:"target_groups"`
+	// Expr выражение CEL, проверяется вместе с остальными полями
+	Expr string `yaml:"expr"`
+
+	expr *Expr
+}
+
+func (m *Match) validate() error {
+	for _, kind := range m.Kinds {
+		if kind != internal.KindEvents && kind != internal.KindAdminEvents {
+			return fmt.Errorf("unknown kind %q", kind)
+		}
+	}
+
+	for _, pattern := range slices.Concat(m.EventTypes, m.ResourceTypes, m.ResourcePaths, m.ResourceKinds) {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	// типы операций приводятся к именам, с которыми сравниваются при проверке
+	operationTypes := make([]string, 0, len(m.OperationTypes))
+	for _, name := range m.OperationTypes {
+		var operationType internal.OperationType
+		err := operationType.UnmarshalText([]byte(name))
+		if err != nil {
+			return err
+		}
+		operationTypes = append(operationTypes, operationType.String())
+	}
+	if len(operationTypes) > 0 {
+		m.OperationTypes = operationTypes
+	}
+
+	if m.Outcome != "" && m.Outcome != OutcomeSuccess && m.Outcome != OutcomeError {
+		return fmt.Errorf("unknown outcome %q", m.Outcome)
+	}
+
+	return nil
+}
+
+// compileMatch компилирует выражение условия для вида событий T
+func compileMatch[T internal.Event | internal.AdminEvent](m *Match) error {
+	if m.Expr == "" {
+		return nil
+	}
+
+	expr, err := CompileExpr[T](m.Expr)
+	if err != nil {
+		return err
+	}
+	m.expr = expr
+
+	return nil
+}
+
+// Matches проверяет событие. Условия по полям, которых у события нет (тип события у события
+// администрирования, тип ресурса у события), не выполняются. Выражение, не скомпилированное
+// compileMatch или завершившееся ошибкой, тоже не выполняется.
+func Matches[T internal.Event | internal.AdminEvent](m *Match, event *T) bool {
+	meta := internal.MetaOf(event)
+	if !matchAny(m.Kinds, meta.Kind) || !matchAny(m.Realms, meta.RealmName) {
+		return false
+	}
+
+	var clientId, eventError string
+	switch e := any(event).(type) {
+	case *internal.Event:
+		if len(m.ResourceTypes) > 0 || len(m.OperationTypes) > 0 || len(m.ResourcePaths) > 0 || m.matchesResource() {
+			return false
+		}
+		if !matchPattern(m.EventTypes, e.TypeName()) {
+			return false
+		}
+		clientId, eventError = e.ClientId, e.Error
+	case *internal.AdminEvent:
+		if len(m.EventTypes) > 0 {
+			return false
+		}
+		if !matchPattern(m.ResourceTypes, e.ResourceType) || !matchAny(m.OperationTypes, e.OperationType.String()) ||
+			!matchPattern(m.ResourcePaths, e.ResourcePath) {
+			return false
+		}
+		if m.matchesResource() && !m.matchResource(internal.ResourceOf(e)) {
+			return false
+		}
+		if e.AuthDetails != nil {
+			clientId = e.AuthDetails.ClientId.String()
+		}
+		eventError = e.Error
+	}
+
+	if !matchAny(m.Clients, clientId) {
+		return false
+	}
+
+	switch m.Outcome {
+	case OutcomeSuccess:
+		if eventError != "" {
+			return false
+		}
+	case OutcomeError:
+		if eventError == "" {
+			return false
+		}
+	}
+
+	if m.Expr == "" {
+		return true
+	}
+	if m.expr == nil {
+		return false
+	}
+	// ошибка вычисления, например обращение к отсутствующему ключу details, считается несовпадением
+	ok, err := EvalExpr(m.expr, event)
+	if err != nil {
+		m.expr.failed.Inc()
+		return false
+	}
+
+	return ok
+}
+
+// matchesResource сообщает, есть ли в условии поля разобранного пути ресурса
+func (m *Match) matchesResource() bool {
+	return len(m.ResourceKinds) > 0 || len(m.TargetUsers) > 0 || len(m.TargetClients) > 0 || len(m.TargetGroups) > 0
+}
+
+func (m *Match) matchResource(ref *internal.ResourceRef) bool {
+	if ref == nil {
+		ref = &internal.ResourceRef{}
+	}
+
+	return matchPattern(m.ResourceKinds, ref.Kind) && matchAny(m.TargetUsers, ref.TargetUserId) &&
+		matchAny(m.TargetClients, ref.TargetClientId) && matchAny(m.TargetGroups, ref.TargetGroupId)
+}
+
+func matchAny(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, value)
+}
+
+// matchPattern проверяет значение по шаблонам path.Match, шаблоны проверены в validate
+func matchPattern(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+const (
+	StageIngest   = "ingest"
+	StageDelivery = "delivery"
+)
+
+type Result uint8
+
+const (
+	// Continue событие передается следующему обработчику
+	Continue Result = iota
+	// Drop событие отбрасывается и не доставляется в приемник
+	Drop
+)
+
+// Processor обработчик конвейера. Реализации общие для событий и событий администрирования.
+type Processor[T internal.Event | internal.AdminEvent] interface {
+	Process(event *T) (Result, error)
+}
+
+// ErrorPolicy поведение конвейера при ошибке обработчика
+type ErrorPolicy string
+
+const (
+	// OnErrorFail ошибка возвращается отправителю события при приеме или задача возвращается в очередь при доставке
+	OnErrorFail ErrorPolicy = "fail"
+	// OnErrorSkip обработчик пропускается, событие передается дальше
+	OnErrorSkip ErrorPolicy = "skip"
+	// OnErrorDrop событие отбрасывается
+	OnErrorDrop ErrorPolicy = "drop"
+)
+
+func (p ErrorPolicy) validate() error {
+	switch p {
+	case OnErrorFail, OnErrorSkip, OnErrorDrop:
+		return nil
+	default:
+		return fmt.Errorf("unknown error policy %q", p)
+	}
+}
+
+type step[T internal.Event | internal.AdminEvent] struct {
+	name      string
+	processor Processor[T]
+	onError   ErrorPolicy
+
+	ok        prometheus.Counter
+	dropped   prometheus.Counter
+	protected prometheus.Counter
+	failed    prometheus.Counter
+	duration  prometheus.Observer
+}
+
+// Pipeline упорядоченный список обработчиков одного этапа
+type Pipeline[T internal.Event | internal.AdminEvent] struct {
+	stage  string
+	kind   string
+	steps  []step[T]
+	logger *zap.Logger
+}
+
+func New[T internal.Event | internal.AdminEvent](stage string, logger *zap.Logger) *Pipeline[T] {
+	return &Pipeline[T]{
+		stage:  stage,
+		kind:   internal.MetaOf(new(T)).Kind,
+		logger: logger,
+	}
+}
+
+// Add добавляет обработчик в конец конвейера
+func (p *Pipeline[T]) Add(name string, processor Processor[T], onError ErrorPolicy) *Pipeline[T] {
+	if onError == "" {
+		onError = OnErrorFail
+	}
+
+	p.steps = append(p.steps, step[T]{
+		name:      name,
+		processor: processor,
+		onError:   onError,
+		ok:        metrics.ProcessorEvents.WithLabelValues(p.stage, p.kind, name, "ok"),
+		dropped:   metrics.ProcessorEvents.WithLabelValues(p.stage, p.kind, name, "dropped"),
+		protected: metrics.ProcessorEvents.WithLabelValues(p.stage, p.kind, name, "protected"),
+		failed:    metrics.ProcessorEvents.WithLabelValues(p.stage, p.kind, name, "error"),
+		duration:  metrics.ProcessorDuration.WithLabelValues(p.stage, p.kind, name),
+	})
+
+	return p
+}
+
+func (p *Pipeline[T]) Len() int {
+	return len(p.steps)
+}
+
+// Run пропускает событие через обработчики. Возвращает Drop, если событие не нужно доставлять,
+// и ошибку, только если ошибся обработчик с политикой fail. Защищенное политикой событие
+// обработчики не отбрасывают, но отбрасывает ошибка с политикой drop.
+func (p *Pipeline[T]) Run(event *T) (Result, error) {
+	for _, s := range p.steps {
+		start := time.Now()
+		result, err := s.processor.Process(event)
+		s.duration.Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			s.failed.Inc()
+
+			switch s.onError {
+			case OnErrorSkip:
+				p.logger.Warn("pipeline processor failed, skipped", p.fields(s, event, err)...)
+				continue
+			case OnErrorDrop:
+				p.logger.Warn("pipeline processor failed, event dropped", p.fields(s, event, err)...)
+				return Drop, nil
+			default:
+				return Continue, fmt.Errorf("processor %s: %w", s.name, err)
+			}
+		}
+
+		if result == Drop {
+			if enrichment := internal.MetaOf(event).Enrichment; enrichment != nil && enrichment.Protected {
+				s.protected.Inc()
+				continue
+			}
+			s.dropped.Inc()
+			return Drop, nil
+		}
+		s.ok.Inc()
+	}
+
+	return Continue, nil
+}
+
+func (p *Pipeline[T]) fields(s step[T], event *T, err error) []zap.Field {
+	return []zap.Field{
+		zap.String("stage", p.stage),
+		zap.String("processor", s.name),
+		zap.Stringer("event_id", internal.MetaOf(event).Id),
+		zap.Error(err),
+	}
+}
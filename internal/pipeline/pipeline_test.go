@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+type processorFunc[T internal.Event | internal.AdminEvent] func(event *T) (Result, error)
+
+func (f processorFunc[T]) Process(event *T) (Result, error) {
+	return f(event)
+}
+
+var errProcessor = errors.New("processor error")
+
+func failing[T internal.Event | internal.AdminEvent]() Processor[T] {
+	return processorFunc[T](func(*T) (Result, error) {
+		return Continue, errProcessor
+	})
+}
+
+func TestPipeline_Run(t *testing.T) {
+	tag := NewTag[internal.Event](Match{}, map[string]string{"env": "prod"})
+
+	tests := []struct {
+		name       string
+		pipeline   *Pipeline[internal.Event]
+		wantResult Result
+		wantErr    bool
+		wantTags   bool
+	}{
+		{
+			name:       "empty",
+			pipeline:   New[internal.Event](StageIngest, zap.NewNop()),
+			wantResult: Continue,
+		},
+		{
+			name:       "all processors",
+			pipeline:   New[internal.Event](StageIngest, zap.NewNop()).Add("route", NewRoute[internal.Event](Match{}, "siem"), "").Add("tag", tag, ""),
+			wantResult: Continue,
+			wantTags:   true,
+		},
+		{
+			name:       "drop stops pipeline",
+			pipeline:   New[internal.Event](StageIngest, zap.NewNop()).Add("drop", NewDropMatched[internal.Event](Match{}), "").Add("tag", tag, ""),
+			wantResult: Drop,
+		},
+		{
+			name:     "error fail",
+			pipeline: New[internal.Event](StageIngest, zap.NewNop()).Add("failing", failing[internal.Event](), OnErrorFail).Add("tag", tag, ""),
+			wantErr:  true,
+		},
+		{
+			name:     "error with default policy",
+			pipeline: New[internal.Event](StageIngest, zap.NewNop()).Add("failing", failing[internal.Event](), ""),
+			wantErr:  true,
+		},
+		{
+			name:       "error skip",
+			pipeline:   New[internal.Event](StageIngest, zap.NewNop()).Add("failing", failing[internal.Event](), OnErrorSkip).Add("tag", tag, ""),
+			wantResult: Continue,
+			wantTags:   true,
+		},
+		{
+			name:       "error drop",
+			pipeline:   New[internal.Event](StageIngest, zap.NewNop()).Add("failing", failing[internal.Event](), OnErrorDrop).Add("tag", tag, ""),
+			wantResult: Drop,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			event := &internal.Event{Id: uuid.New()}
+			result, err := tt.pipeline.Run(event)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errProcessor)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResult, result)
+			if tt.wantTags {
+				require.NotNil(t, event.Enrichment)
+				assert.Equal(t, map[string]string{"env": "prod"}, event.Enrichment.Tags)
+			}
+		})
+	}
+}
+
+func TestPipeline_RunKeepsAlerts(t *testing.T) {
+	t.Parallel()
+
+	pipeline := New[internal.Event](StageDelivery, zap.NewNop()).
+		Add("filter", NewFilter[internal.Event](Match{EventTypes: []string{"LOGIN"}}), "").
+		Add("drop", NewDropMatched[internal.Event](Match{}), "")
+
+	alert := &internal.Alert{Id: uuid.New(), Rule: "bruteforce", Severity: internal.AlertSeverityHigh}
+	result, err := pipeline.Run(alert.Event())
+	require.NoError(t, err)
+	assert.Equal(t, Continue, result)
+}
+
+func TestPipeline_Metrics(t *testing.T) {
+	t.Parallel()
+
+	p := New[internal.AdminEvent](StageDelivery, zap.NewNop()).
+		Add("metrics-filter", NewFilter[internal.AdminEvent](Match{Realms: []string{"master"}}), "").
+		Add("metrics-failing", failing[internal.AdminEvent](), OnErrorSkip)
+
+	for _, realm := range []string{"master", "master", "other"} {
+		_, err := p.Run(&internal.AdminEvent{RealmName: realm})
+		require.NoError(t, err)
+	}
+
+	counter := func(processor, result string) float64 {
+		return testutil.ToFloat64(metrics.ProcessorEvents.WithLabelValues(StageDelivery, internal.KindAdminEvents, processor, result))
+	}
+	assert.Equal(t, float64(2), counter("metrics-filter", "ok"))
+	assert.Equal(t, float64(1), counter("metrics-filter", "dropped"))
+	assert.Equal(t, float64(2), counter("metrics-failing", "error"))
+}
@@ -0,0 +1,270 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/detect"
+)
+
+// PolicyAlertRule правило тревог политики привилегированных операций
+const PolicyAlertRule = "privileged_operation"
+
+// Метки, которыми политика отмечает классифицированные события
+const (
+	TagRisk     = "risk"
+	TagRiskRule = "risk_rule"
+)
+
+// policyAlertCooldown время активности тревоги политики. Ключ тревоги — идентификатор события,
+// поэтому повторная доставка события в течение этого времени не создает новую тревогу.
+const policyAlertCooldown = 15 * time.Minute
+
+// PolicyRule правило политики: подходящее событие получает уровень риска Risk
+type PolicyRule struct {
+	// Name имя правила в метке risk_rule, по умолчанию номер правила
+	Name  string
+	Match Match
+	Risk  internal.AlertSeverity
+}
+
+// DefaultPolicyRules правила политики, если правила не заданы: выдача ролей администратора,
+// имперсонация, выпуск секрета клиента, изменения поставщиков удостоверений, потоков аутентификации,
+// настроек realm и учетных данных пользователей
+func DefaultPolicyRules() []PolicyRule {
+	return []PolicyRule{
+		{
+			Name: "realm_admin_grant",
+			Match: Match{
+				Kinds:          []string{internal.KindAdminEvents},
+				ResourceTypes:  []string{"CLIENT_ROLE_MAPPING"},
+				OperationTypes: []string{"CREATE"},
+				Expr:           `event.representation.contains('"realm-admin"')`,
+			},
+			Risk: internal.AlertSeverityCritical,
+		},
+		{
+			Name: "master_admin_grant",
+			Match: Match{
+				Kinds:          []string{internal.KindAdminEvents},
+				Realms:         []string{"master"},
+				ResourceTypes:  []string{"REALM_ROLE_MAPPING"},
+				OperationTypes: []string{"CREATE"},
+				Expr:           `event.representation.contains('"name":"admin"')`,
+			},
+			Risk: internal.AlertSeverityCritical,
+		},
+		{
+			Name:  "impersonation",
+			Match: Match{EventTypes: []string{"IMPERSONATE"}},
+			Risk:  internal.AlertSeverityHigh,
+		},
+		{
+			Name:  "admin_impersonation",
+			Match: Match{ResourcePaths: []string{"users/*/impersonation"}},
+			Risk:  internal.AlertSeverityHigh,
+		},
+		{
+			Name:  "client_secret",
+			Match: Match{ResourcePaths: []string{"clients/*/client-secret", "clients/*/client-secret/rotated"}},
+			Risk:  internal.AlertSeverityHigh,
+		},
+		{
+			Name:  "identity_provider",
+			Match: Match{ResourceTypes: []string{"IDENTITY_PROVIDER*"}},
+			Risk:  internal.AlertSeverityHigh,
+		},
+		{
+			Name:  "role_mapping",
+			Match: Match{ResourceTypes: []string{"*ROLE_MAPPING"}},
+			Risk:  internal.AlertSeverityMedium,
+		},
+		{
+			Name:  "authentication",
+			Match: Match{ResourceTypes: []string{"AUTH*", "REQUIRED_ACTION"}},
+			Risk:  internal.AlertSeverityMedium,
+		},
+		{
+			Name:  "realm_settings",
+			Match: Match{ResourceTypes: []string{"REALM", "COMPONENT"}},
+			Risk:  internal.AlertSeverityMedium,
+		},
+		{
+			Name:  "user_credentials",
+			Match: Match{ResourcePaths: []string{"users/*/reset-password", "users/*/credentials/*"}},
+			Risk:  internal.AlertSeverityMedium,
+		},
+	}
+}
+
+// PolicyOptions параметры обработчика policy
+type PolicyOptions struct {
+	// Rules правила политики, пустые — DefaultPolicyRules
+	Rules []PolicyRule
+	// AlertRisk уровень риска, начиная с которого событие сразу становится тревогой, пустой — без тревог
+	AlertRisk internal.AlertSeverity
+	// ProtectRisk уровень риска, начиная с которого событие не отбрасывают следующие обработчики, пустой — не защищать
+	ProtectRisk internal.AlertSeverity
+}
+
+// Policy классифицирует привилегированные операции: событие получает уровень риска самого рискованного
+// подходящего правила в метках risk и risk_rule, рискованные события становятся тревогами и защищаются
+// от фильтров и прореживания
+type Policy[T internal.Event | internal.AdminEvent] struct {
+	rules       []PolicyRule
+	alertRank   int
+	protectRank int
+	alerts      detect.AlertRaiser
+}
+
+func NewPolicy[T internal.Event | internal.AdminEvent](opts PolicyOptions, alerts detect.AlertRaiser) (*Policy[T], error) {
+	if len(opts.Rules) == 0 {
+		opts.Rules = DefaultPolicyRules()
+	}
+
+	alertRank, err := riskRank(opts.AlertRisk)
+	if err != nil {
+		return nil, fmt.Errorf("alert_risk: %w", err)
+	}
+	if alertRank > 0 && alerts == nil {
+		return nil, errors.New("alerts are not configured")
+	}
+	protectRank, err := riskRank(opts.ProtectRisk)
+	if err != nil {
+		return nil, fmt.Errorf("protect_risk: %w", err)
+	}
+
+	kind := internal.MetaOf(new(T)).Kind
+	rules := make([]PolicyRule, 0, len(opts.Rules))
+	for i, r := range opts.Rules {
+		if r.Name == "" {
+			r.Name = strconv.Itoa(i)
+		}
+		if r.Risk.Rank() == 0 {
+			return nil, fmt.Errorf("rule %s: unknown risk %q", r.Name, r.Risk)
+		}
+		err = r.Match.validate()
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		if !matchAny(r.Match.Kinds, kind) {
+			continue
+		}
+		err = compileMatch[T](&r.Match)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		rules = append(rules, r)
+	}
+
+	return &Policy[T]{
+		rules:       rules,
+		alertRank:   alertRank,
+		protectRank: protectRank,
+		alerts:      alerts,
+	}, nil
+}
+
+func riskRank(risk internal.AlertSeverity) (int, error) {
+	if risk == "" {
+		return 0, nil
+	}
+	if risk.Rank() == 0 {
+		return 0, fmt.Errorf("unknown risk %q", risk)
+	}
+
+	return risk.Rank(), nil
+}
+
+func (p *Policy[T]) Process(event *T) (Result, error) {
+	if isAlert(event) {
+		return Continue, nil
+	}
+
+	var matched *PolicyRule
+	for i := range p.rules {
+		r := &p.rules[i]
+		if (matched == nil || r.Risk.Rank() > matched.Risk.Rank()) && Matches(&r.Match, event) {
+			matched = r
+		}
+	}
+	if matched == nil {
+		return Continue, nil
+	}
+
+	enrichment := internal.EnrichmentOf(event)
+	if enrichment.Tags == nil {
+		enrichment.Tags = map[string]string{}
+	}
+	enrichment.Tags[TagRisk] = string(matched.Risk)
+	enrichment.Tags[TagRiskRule] = matched.Name
+
+	rank := matched.Risk.Rank()
+	if p.protectRank > 0 && rank >= p.protectRank {
+		enrichment.Protected = true
+	}
+	if p.alertRank > 0 && rank >= p.alertRank {
+		alert := policyAlert(event, matched)
+		alert.Threshold = float64(p.alertRank)
+		return Continue, p.alerts.Raise(alert)
+	}
+
+	return Continue, nil
+}
+
+// policyAlert создает тревогу о событии. Представление ресурса в тревогу не попадает: оно может содержать секреты.
+func policyAlert[T internal.Event | internal.AdminEvent](event *T, r *PolicyRule) *internal.Alert {
+	meta := internal.MetaOf(event)
+	at := meta.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	realm := meta.RealmName
+	if realm == "" {
+		realm = meta.RealmId.String()
+	}
+
+	alert := &internal.Alert{
+		Id:        uuid.New(),
+		Rule:      PolicyAlertRule,
+		Severity:  r.Risk,
+		Key:       meta.Id.String(),
+		RealmId:   meta.RealmId,
+		RealmName: meta.RealmName,
+		IpAddress: internal.IpAddressOf(event),
+		// Value и Threshold — ранги риска события и порога тревог
+		Value:     float64(r.Risk.Rank()),
+		Details:   map[string]string{},
+		FirstSeen: at,
+		LastSeen:  at,
+		ExpiresAt: at.Add(policyAlertCooldown),
+		// метки события уже содержат уровень риска политики
+		Enrichment: internal.AlertEnrichmentOf(event),
+	}
+
+	switch e := any(event).(type) {
+	case *internal.Event:
+		maps.Copy(alert.Details, e.Details)
+		alert.Details["event_type"] = e.TypeName()
+		alert.ClientId, alert.UserId = e.ClientId, e.UserId
+		alert.Description = fmt.Sprintf("%s in realm %s", e.TypeName(), realm)
+	case *internal.AdminEvent:
+		maps.Copy(alert.Details, e.Details)
+		alert.Details["resource_type"] = e.ResourceType
+		alert.Details["operation_type"] = e.OperationType.String()
+		alert.Details["resource_path"] = e.ResourcePath
+		if e.AuthDetails != nil {
+			alert.ClientId, alert.UserId = e.AuthDetails.ClientId.String(), e.AuthDetails.UserId
+		}
+		alert.Description = fmt.Sprintf("%s %s in realm %s", e.OperationType, e.ResourceType, realm)
+	}
+	alert.Details["policy_rule"] = r.Name
+	alert.Details["event_kind"] = meta.Kind
+
+	return alert
+}
@@ -0,0 +1,322 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+)
+
+func TestPolicy_DefaultRules(t *testing.T) {
+	userPath := "users/9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a"
+	clientPath := "clients/3b4a1d7e-8f2c-4c6a-9d1e-5f7a8b9c0d1e"
+
+	adminTests := []struct {
+		name     string
+		event    internal.AdminEvent
+		wantRisk string
+		wantRule string
+	}{
+		{
+			name: "realm-admin grant",
+			event: internal.AdminEvent{
+				RealmName:      "acme",
+				ResourceType:   "CLIENT_ROLE_MAPPING",
+				OperationType:  internal.OperationTypeCreate,
+				ResourcePath:   userPath + "/role-mappings/clients/7c0e5f1a-2b3d-4e5f-8a9b-0c1d2e3f4a5b",
+				Representation: `[{"id":"1","name":"realm-admin","composite":true,"clientRole":true}]`,
+			},
+			wantRisk: "critical",
+			wantRule: "realm_admin_grant",
+		},
+		{
+			name: "other client role grant",
+			event: internal.AdminEvent{
+				RealmName:      "acme",
+				ResourceType:   "CLIENT_ROLE_MAPPING",
+				OperationType:  internal.OperationTypeCreate,
+				ResourcePath:   userPath + "/role-mappings/clients/7c0e5f1a-2b3d-4e5f-8a9b-0c1d2e3f4a5b",
+				Representation: `[{"id":"1","name":"view-users","clientRole":true}]`,
+			},
+			wantRisk: "medium",
+			wantRule: "role_mapping",
+		},
+		{
+			name: "master admin grant",
+			event: internal.AdminEvent{
+				RealmName:      "master",
+				ResourceType:   "REALM_ROLE_MAPPING",
+				OperationType:  internal.OperationTypeCreate,
+				ResourcePath:   userPath + "/role-mappings/realm",
+				Representation: `[{"id":"1","name":"admin","composite":true}]`,
+			},
+			wantRisk: "critical",
+			wantRule: "master_admin_grant",
+		},
+		{
+			name: "admin role of other realm",
+			event: internal.AdminEvent{
+				RealmName:      "acme",
+				ResourceType:   "REALM_ROLE_MAPPING",
+				OperationType:  internal.OperationTypeCreate,
+				ResourcePath:   userPath + "/role-mappings/realm",
+				Representation: `[{"id":"1","name":"admin"}]`,
+			},
+			wantRisk: "medium",
+			wantRule: "role_mapping",
+		},
+		{
+			name:     "client secret regeneration",
+			event:    internal.AdminEvent{ResourceType: "CLIENT", OperationType: internal.OperationTypeAction, ResourcePath: clientPath + "/client-secret"},
+			wantRisk: "high",
+			wantRule: "client_secret",
+		},
+		{
+			name:     "rotated secret removal",
+			event:    internal.AdminEvent{ResourceType: "CLIENT", OperationType: internal.OperationTypeDelete, ResourcePath: clientPath + "/client-secret/rotated"},
+			wantRisk: "high",
+			wantRule: "client_secret",
+		},
+		{
+			name:     "identity provider",
+			event:    internal.AdminEvent{ResourceType: "IDENTITY_PROVIDER", OperationType: internal.OperationTypeUpdate, ResourcePath: "identity-provider/instances/google"},
+			wantRisk: "high",
+			wantRule: "identity_provider",
+		},
+		{
+			name:     "identity provider mapper",
+			event:    internal.AdminEvent{ResourceType: "IDENTITY_PROVIDER_MAPPER", OperationType: internal.OperationTypeCreate, ResourcePath: "identity-provider/instances/google/mappers/1"},
+			wantRisk: "high",
+			wantRule: "identity_provider",
+		},
+		{
+			name:     "impersonation by admin",
+			event:    internal.AdminEvent{ResourceType: "USER", OperationType: internal.OperationTypeAction, ResourcePath: userPath + "/impersonation"},
+			wantRisk: "high",
+			wantRule: "admin_impersonation",
+		},
+		{
+			name:     "password reset",
+			event:    internal.AdminEvent{ResourceType: "USER", OperationType: internal.OperationTypeAction, ResourcePath: userPath + "/reset-password"},
+			wantRisk: "medium",
+			wantRule: "user_credentials",
+		},
+		{
+			name:     "authentication flow",
+			event:    internal.AdminEvent{ResourceType: "AUTH_EXECUTION", OperationType: internal.OperationTypeUpdate, ResourcePath: "authentication/flows/browser/executions"},
+			wantRisk: "medium",
+			wantRule: "authentication",
+		},
+		{
+			name:  "user update",
+			event: internal.AdminEvent{ResourceType: "USER", OperationType: internal.OperationTypeUpdate, ResourcePath: userPath},
+		},
+	}
+	for _, tt := range adminTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := NewPolicy[internal.AdminEvent](PolicyOptions{}, nil)
+			require.NoError(t, err)
+
+			event := tt.event
+			result, err := policy.Process(&event)
+			require.NoError(t, err)
+			assert.Equal(t, Continue, result)
+			if tt.wantRisk == "" {
+				assert.Nil(t, event.Enrichment)
+				return
+			}
+			require.NotNil(t, event.Enrichment)
+			assert.Equal(t, map[string]string{TagRisk: tt.wantRisk, TagRiskRule: tt.wantRule}, event.Enrichment.Tags)
+			assert.False(t, event.Enrichment.Protected)
+		})
+	}
+
+	t.Run("impersonate event", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := NewPolicy[internal.Event](PolicyOptions{}, nil)
+		require.NoError(t, err)
+
+		event := &internal.Event{Type: internal.EventTypeImpersonate}
+		_, err = policy.Process(event)
+		require.NoError(t, err)
+		require.NotNil(t, event.Enrichment)
+		assert.Equal(t, map[string]string{TagRisk: "high", TagRiskRule: "impersonation"}, event.Enrichment.Tags)
+
+		login := &internal.Event{Type: internal.EventTypeLogin}
+		_, err = policy.Process(login)
+		require.NoError(t, err)
+		assert.Nil(t, login.Enrichment)
+	})
+}
+
+func TestPolicy_Alerts(t *testing.T) {
+	t.Parallel()
+
+	var alerts []*internal.Alert
+	raiser := alertsFunc(func(alert *internal.Alert) error {
+		alerts = append(alerts, alert)
+		return nil
+	})
+	policy, err := NewPolicy[internal.AdminEvent](PolicyOptions{
+		Rules: []PolicyRule{
+			{Name: "users", Match: Match{ResourceTypes: []string{"USER"}}, Risk: internal.AlertSeverityMedium},
+			{Name: "deletes", Match: Match{OperationTypes: []string{"DELETE"}}, Risk: internal.AlertSeverityHigh},
+		},
+		AlertRisk:   internal.AlertSeverityHigh,
+		ProtectRisk: internal.AlertSeverityMedium,
+	}, raiser)
+	require.NoError(t, err)
+
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	adminId := uuid.New()
+	update := &internal.AdminEvent{Id: uuid.New(), Time: at, RealmName: "master", ResourceType: "USER", OperationType: internal.OperationTypeUpdate}
+	_, err = policy.Process(update)
+	require.NoError(t, err)
+	assert.Equal(t, "users", update.Enrichment.Tags[TagRiskRule])
+	assert.True(t, update.Enrichment.Protected)
+	assert.Empty(t, alerts)
+
+	// правило с большим риском выбирается независимо от порядка
+	remove := &internal.AdminEvent{
+		Id:             uuid.New(),
+		Time:           at,
+		RealmName:      "master",
+		AuthDetails:    &internal.AuthDetails{UserId: adminId, IpAddress: "10.0.0.1"},
+		ResourceType:   "USER",
+		OperationType:  internal.OperationTypeDelete,
+		ResourcePath:   "users/1",
+		Representation: `{"secret":"value"}`,
+		Details:        map[string]string{"reason": "cleanup"},
+	}
+	_, err = policy.Process(remove)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{TagRisk: "high", TagRiskRule: "deletes"}, remove.Enrichment.Tags)
+	require.Len(t, alerts, 1)
+
+	alert := alerts[0]
+	assert.Equal(t, PolicyAlertRule, alert.Rule)
+	assert.Equal(t, internal.AlertSeverityHigh, alert.Severity)
+	assert.Equal(t, remove.Id.String(), alert.Key)
+	assert.Equal(t, adminId, alert.UserId)
+	assert.Equal(t, "10.0.0.1", alert.IpAddress)
+	assert.Equal(t, at.Add(policyAlertCooldown), alert.ExpiresAt)
+	assert.Equal(t, map[string]string{
+		"reason":         "cleanup",
+		"resource_type":  "USER",
+		"operation_type": "DELETE",
+		"resource_path":  "users/1",
+		"policy_rule":    "deletes",
+		"event_kind":     internal.KindAdminEvents,
+	}, alert.Details)
+}
+
+func TestPolicy_SkipsAlerts(t *testing.T) {
+	t.Parallel()
+
+	var alerts []*internal.Alert
+	raiser := alertsFunc(func(alert *internal.Alert) error {
+		alerts = append(alerts, alert)
+		return nil
+	})
+	policy, err := NewPolicy[internal.Event](PolicyOptions{
+		Rules:     []PolicyRule{{Name: "all", Match: Match{EventTypes: []string{"*"}}, Risk: internal.AlertSeverityHigh}},
+		AlertRisk: internal.AlertSeverityHigh,
+	}, raiser)
+	require.NoError(t, err)
+
+	login := &internal.Event{
+		Id:         uuid.New(),
+		Time:       time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Type:       internal.EventTypeLogin,
+		RealmName:  "master",
+		Enrichment: &internal.Enrichment{Geo: &internal.Geo{CountryCode: "DE"}},
+	}
+	_, err = policy.Process(login)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "DE", alerts[0].Enrichment.Geo.CountryCode)
+	assert.Equal(t, "all", alerts[0].Enrichment.Tags[TagRiskRule])
+
+	// тревога, доставляемая событием, сохраняет обогащение и не порождает новую тревогу
+	event := alerts[0].Event()
+	assert.True(t, event.IsAlert())
+	assert.Equal(t, alerts[0].Enrichment.Geo, event.Enrichment.Geo)
+	assert.Equal(t, alerts[0].Enrichment.Tags, event.Enrichment.Tags)
+	assert.True(t, event.Enrichment.Protected)
+
+	result, err := policy.Process(event)
+	require.NoError(t, err)
+	assert.Equal(t, Continue, result)
+	assert.Len(t, alerts, 1)
+}
+
+func TestBuild_PolicyProtects(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(writeConfig(t, `
+ingest:
+  - type: policy
+    protect_risk: high
+    alert_risk: critical
+    rules:
+      - {name: secrets, match: {resource_paths: ["clients/*/client-secret"]}, risk: high}
+      - {name: clients, match: {resource_types: [CLIENT]}, risk: low}
+  - type: rules
+    default: drop
+    rules:
+      - {match: {realms: [other]}, action: keep}
+`))
+	require.NoError(t, err)
+	assert.True(t, cfg.HasDetectors())
+
+	var alerts []*internal.Alert
+	deps := Deps{Alerts: alertsFunc(func(alert *internal.Alert) error {
+		alerts = append(alerts, alert)
+		return nil
+	})}
+	p, err := Build[internal.AdminEvent](StageIngest, cfg.Ingest, deps, zap.NewNop())
+	require.NoError(t, err)
+
+	secret := &internal.AdminEvent{Id: uuid.New(), RealmName: "master", ResourceType: "CLIENT", ResourcePath: "clients/1/client-secret"}
+	result, err := p.Run(secret)
+	require.NoError(t, err)
+	assert.Equal(t, Continue, result)
+	assert.True(t, secret.Enrichment.Protected)
+
+	client := &internal.AdminEvent{Id: uuid.New(), RealmName: "master", ResourceType: "CLIENT", ResourcePath: "clients/1"}
+	result, err = p.Run(client)
+	require.NoError(t, err)
+	assert.Equal(t, Drop, result)
+	assert.Empty(t, alerts)
+
+	assert.False(t, (&Config{Ingest: []StepConfig{{Type: TypePolicy}}}).HasDetectors())
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PolicyOptions
+	}{
+		{name: "unknown risk", opts: PolicyOptions{Rules: []PolicyRule{{Risk: "severe"}}}},
+		{name: "no risk", opts: PolicyOptions{Rules: []PolicyRule{{Match: Match{Realms: []string{"master"}}}}}},
+		{name: "invalid match", opts: PolicyOptions{Rules: []PolicyRule{{Match: Match{ResourcePaths: []string{"[users"}}, Risk: "low"}}}},
+		{name: "unknown alert risk", opts: PolicyOptions{AlertRisk: "severe"}},
+		{name: "unknown protect risk", opts: PolicyOptions{ProtectRisk: "severe"}},
+		{name: "alerts without alerter", opts: PolicyOptions{AlertRisk: internal.AlertSeverityHigh}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewPolicy[internal.AdminEvent](tt.opts, nil)
+			assert.Error(t, err)
+		})
+	}
+}
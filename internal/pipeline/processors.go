@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"maps"
+
+	"keycloak-events-adapter/internal"
+)
+
+// GeoLookup определяет геолокацию IP адреса, nil — адрес пустой или некорректный
+type GeoLookup interface {
+	Lookup(ip string) *internal.Geo
+}
+
+// GeoIP добавляет геолокацию IP адреса события
+type GeoIP[T internal.Event | internal.AdminEvent] struct {
+	geo GeoLookup
+}
+
+func NewGeoIP[T internal.Event | internal.AdminEvent](geo GeoLookup) *GeoIP[T] {
+	return &GeoIP[T]{geo: geo}
+}
+
+func (p *GeoIP[T]) Process(event *T) (Result, error) {
+	if geo := p.geo.Lookup(internal.IpAddressOf(event)); geo != nil {
+		internal.EnrichmentOf(event).Geo = geo
+	}
+
+	return Continue, nil
+}
+
+// Filter пропускает дальше только подходящие события
+type Filter[T internal.Event | internal.AdminEvent] struct {
+	match Match
+}
+
+func NewFilter[T internal.Event | internal.AdminEvent](match Match) *Filter[T] {
+	return &Filter[T]{match: match}
+}
+
+func (p *Filter[T]) Process(event *T) (Result, error) {
+	if Matches(&p.match, event) {
+		return Continue, nil
+	}
+
+	return Drop, nil
+}
+
+// DropMatched отбрасывает подходящие события
+type DropMatched[T internal.Event | internal.AdminEvent] struct {
+	match Match
+}
+
+func NewDropMatched[T internal.Event | internal.AdminEvent](match Match) *DropMatched[T] {
+	return &DropMatched[T]{match: match}
+}
+
+func (p *DropMatched[T]) Process(event *T) (Result, error) {
+	if Matches(&p.match, event) {
+		return Drop, nil
+	}
+
+	return Continue, nil
+}
+
+// Tag добавляет метки подходящим событиям, существующие метки с теми же именами заменяются
+type Tag[T internal.Event | internal.AdminEvent] struct {
+	match Match
+	tags  map[string]string
+}
+
+func NewTag[T internal.Event | internal.AdminEvent](match Match, tags map[string]string) *Tag[T] {
+	return &Tag[T]{match: match, tags: tags}
+}
+
+func (p *Tag[T]) Process(event *T) (Result, error) {
+	if !Matches(&p.match, event) {
+		return Continue, nil
+	}
+
+	enrichment := internal.EnrichmentOf(event)
+	if enrichment.Tags == nil {
+		enrichment.Tags = make(map[string]string, len(p.tags))
+	}
+	maps.Copy(enrichment.Tags, p.tags)
+
+	return Continue, nil
+}
+
+// Route назначает маршрут подходящим событиям, маршрут доступен приемникам в шаблоне {route}
+type Route[T internal.Event | internal.AdminEvent] struct {
+	match Match
+	route string
+}
+
+func NewRoute[T internal.Event | internal.AdminEvent](match Match, route string) *Route[T] {
+	return &Route[T]{match: match, route: route}
+}
+
+func (p *Route[T]) Process(event *T) (Result, error) {
+	if Matches(&p.match, event) {
+		internal.EnrichmentOf(event).Route = p.route
+	}
+
+	return Continue, nil
+}
+
+// EventDetector наблюдает события, не изменяя их, и сообщает о найденных угрозах
+type EventDetector interface {
+	Observe(event *internal.Event) error
+}
+
+// Detect передает детектору подходящие события и пропускает их дальше
+type Detect[T internal.Event | internal.AdminEvent] struct {
+	match    Match
+	detector EventDetector
+}
+
+func NewDetect[T internal.Event | internal.AdminEvent](match Match, detector EventDetector) *Detect[T] {
+	return &Detect[T]{match: match, detector: detector}
+}
+
+func (p *Detect[T]) Process(event *T) (Result, error) {
+	e, ok := any(event).(*internal.Event)
+	if !ok || isAlert(event) || !Matches(&p.match, event) {
+		return Continue, nil
+	}
+
+	return Continue, p.detector.Observe(e)
+}
+
+// isAlert проверяет, что событие — тревога детектора. Тревоги проходят конвейеры как события,
+// но детекторы и политика их пропускают, чтобы тревога не порождала новые тревоги.
+func isAlert[T internal.Event | internal.AdminEvent](event *T) bool {
+	e, ok := any(event).(*internal.Event)
+	return ok && e.IsAlert()
+}
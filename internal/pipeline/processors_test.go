@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"keycloak-events-adapter/internal"
+)
+
+type geoLookupFunc func(ip string) *internal.Geo
+
+func (f geoLookupFunc) Lookup(ip string) *internal.Geo {
+	return f(ip)
+}
+
+var testGeo = geoLookupFunc(func(ip string) *internal.Geo {
+	switch ip {
+	case "81.2.69.142":
+		return &internal.Geo{Scope: internal.GeoScopePublic, CountryCode: "GB"}
+	case "10.0.0.1":
+		return &internal.Geo{Scope: internal.GeoScopePrivate}
+	default:
+		return nil
+	}
+})
+
+func TestGeoIP_Process(t *testing.T) {
+	tests := []struct {
+		name      string
+		ipAddress string
+		want      *internal.Enrichment
+	}{
+		{
+			name:      "public",
+			ipAddress: "81.2.69.142",
+			want:      &internal.Enrichment{Geo: &internal.Geo{Scope: internal.GeoScopePublic, CountryCode: "GB"}},
+		},
+		{
+			name:      "private",
+			ipAddress: "10.0.0.1",
+			want:      &internal.Enrichment{Geo: &internal.Geo{Scope: internal.GeoScopePrivate}},
+		},
+		{name: "no address", ipAddress: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			event := &internal.Event{IpAddress: tt.ipAddress}
+			result, err := NewGeoIP[internal.Event](testGeo).Process(event)
+			require.NoError(t, err)
+			assert.Equal(t, Continue, result)
+			assert.Equal(t, tt.want, event.Enrichment)
+		})
+	}
+}
+
+func TestGeoIP_ProcessAdmin(t *testing.T) {
+	t.Parallel()
+
+	adminEvent := &internal.AdminEvent{AuthDetails: &internal.AuthDetails{IpAddress: "81.2.69.142"}}
+	_, err := NewGeoIP[internal.AdminEvent](testGeo).Process(adminEvent)
+	require.NoError(t, err)
+	require.NotNil(t, adminEvent.Enrichment)
+	assert.Equal(t, "GB", adminEvent.Enrichment.Geo.CountryCode)
+}
+
+func TestMatches(t *testing.T) {
+	login := &internal.Event{RealmName: "master", Type: internal.EventTypeLogin, ClientId: "account"}
+	raw := &internal.Event{RealmName: "master", RawType: "JWT_AUTHORIZATION_GRANT", Error: "invalid_grant"}
+	admin := &internal.AdminEvent{
+		RealmName:     "master",
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		ResourcePath:  "users/9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a/role-mappings/realm",
+	}
+
+	tests := []struct {
+		name      string
+		match     Match
+		wantEvent bool
+		wantRaw   bool
+		wantAdmin bool
+	}{
+		{name: "empty", match: Match{}, wantEvent: true, wantRaw: true, wantAdmin: true},
+		{name: "kind", match: Match{Kinds: []string{internal.KindAdminEvents}}, wantAdmin: true},
+		{name: "realm", match: Match{Realms: []string{"master", "other"}}, wantEvent: true, wantRaw: true, wantAdmin: true},
+		{name: "other realm", match: Match{Realms: []string{"other"}}},
+		{name: "event type", match: Match{EventTypes: []string{"LOGIN"}}, wantEvent: true},
+		{name: "raw event type", match: Match{EventTypes: []string{"JWT_AUTHORIZATION_GRANT"}}, wantRaw: true},
+		{name: "event type pattern", match: Match{EventTypes: []string{"LOGIN*"}}, wantEvent: true},
+		{name: "client", match: Match{Clients: []string{"account"}}, wantEvent: true},
+		{name: "outcome error", match: Match{Outcome: OutcomeError}, wantRaw: true},
+		{name: "outcome success", match: Match{Outcome: OutcomeSuccess}, wantEvent: true, wantAdmin: true},
+		{name: "resource type", match: Match{ResourceTypes: []string{"US*"}}, wantAdmin: true},
+		{name: "operation type", match: Match{OperationTypes: []string{"CREATE"}}, wantAdmin: true},
+		{name: "other operation type", match: Match{OperationTypes: []string{"DELETE"}}},
+		{name: "resource path", match: Match{ResourcePaths: []string{"users/*/role-mappings/*"}}, wantAdmin: true},
+		{name: "other resource path", match: Match{ResourcePaths: []string{"users/*"}}},
+		{name: "resource kind", match: Match{ResourceKinds: []string{"users/role-mappings/*"}}, wantAdmin: true},
+		{name: "other resource kind", match: Match{ResourceKinds: []string{"users"}}},
+		{name: "target user", match: Match{TargetUsers: []string{"9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a"}}, wantAdmin: true},
+		{name: "target client", match: Match{TargetClients: []string{"account"}}},
+		{name: "all fields", match: Match{Kinds: []string{internal.KindEvents}, Realms: []string{"master"}, EventTypes: []string{"LOGIN"}}, wantEvent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantEvent, Matches(&tt.match, login))
+			assert.Equal(t, tt.wantRaw, Matches(&tt.match, raw))
+			assert.Equal(t, tt.wantAdmin, Matches(&tt.match, admin))
+		})
+	}
+}
+
+func TestTag_Process(t *testing.T) {
+	t.Parallel()
+
+	event := &internal.Event{
+		RealmName:  "master",
+		Enrichment: &internal.Enrichment{Tags: map[string]string{"env": "dev", "team": "iam"}},
+	}
+	_, err := NewTag[internal.Event](Match{Realms: []string{"master"}}, map[string]string{"env": "prod"}).Process(event)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "iam"}, event.Enrichment.Tags)
+
+	other := &internal.Event{RealmName: "other"}
+	_, err = NewTag[internal.Event](Match{Realms: []string{"master"}}, map[string]string{"env": "prod"}).Process(other)
+	require.NoError(t, err)
+	assert.Nil(t, other.Enrichment)
+}
+
+func TestFilter_Process(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilter[internal.Event](Match{EventTypes: []string{"LOGIN_ERROR"}})
+	drop := NewDropMatched[internal.Event](Match{EventTypes: []string{"LOGIN_ERROR"}})
+
+	loginError := &internal.Event{Type: internal.EventTypeLoginError}
+	refresh := &internal.Event{Type: internal.EventTypeRefreshToken}
+
+	result, _ := filter.Process(loginError)
+	assert.Equal(t, Continue, result)
+	result, _ = filter.Process(refresh)
+	assert.Equal(t, Drop, result)
+	result, _ = drop.Process(loginError)
+	assert.Equal(t, Drop, result)
+	result, _ = drop.Process(refresh)
+	assert.Equal(t, Continue, result)
+}
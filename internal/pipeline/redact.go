@@ -0,0 +1,390 @@
+package pipeline
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+type RedactAction string
+
+const (
+	// RedactDrop удаляет значение
+	RedactDrop RedactAction = "drop"
+	// RedactMask заменяет значение на ***
+	RedactMask RedactAction = "mask"
+	// RedactTruncateIP обнуляет младшие биты IP адреса (по умолчанию до /24 для IPv4 и /48 для IPv6)
+	RedactTruncateIP RedactAction = "truncate_ip"
+	// RedactHMAC заменяет значение псевдонимом HMAC-SHA256: одно значение всегда дает один псевдоним
+	RedactHMAC RedactAction = "hmac"
+)
+
+const maskValue = "***"
+
+const (
+	FieldIpAddress      = "ip_address"
+	FieldUserId         = "user_id"
+	FieldSessionId      = "session_id"
+	FieldResourcePath   = "resource_path"
+	FieldRepresentation = "representation"
+	FieldDetailsPrefix  = "details."
+)
+
+// RedactRule правило обработчика redact. Поля ip_address и user_id относятся к автору действия:
+// для событий администрирования это auth_details.ip_address и auth_details.user_id.
+type RedactRule struct {
+	Field  string       `yaml:"field"`
+	Action RedactAction `yaml:"action"`
+	// QueryParams параметры URL в значении поля, к которым применяется действие, например для details.redirect_uri
+	QueryParams []string `yaml:"query_params"`
+	// Paths пути внутри JSON representation события администрирования, например credentials[*].value
+	Paths []string `yaml:"paths"`
+}
+
+type redactRule struct {
+	RedactRule
+	paths [][]pathElem
+}
+
+// Redact удаляет и псевдонимизирует персональные данные по правилам
+type Redact[T internal.Event | internal.AdminEvent] struct {
+	rules      []redactRule
+	key        []byte
+	ipv4Prefix int
+	ipv6Prefix int
+}
+
+func NewRedact[T internal.Event | internal.AdminEvent](rules []RedactRule, key []byte, ipv4Prefix, ipv6Prefix int) (*Redact[T], error) {
+	if len(rules) == 0 {
+		return nil, errors.New("rules are empty")
+	}
+	if ipv4Prefix == 0 {
+		ipv4Prefix = 24
+	}
+	if ipv6Prefix == 0 {
+		ipv6Prefix = 48
+	}
+	if ipv4Prefix < 0 || ipv4Prefix > 32 || ipv6Prefix < 0 || ipv6Prefix > 128 {
+		return nil, errors.New("invalid ip prefix length")
+	}
+
+	r := &Redact[T]{key: key, ipv4Prefix: ipv4Prefix, ipv6Prefix: ipv6Prefix}
+	for i, rule := range rules {
+		compiled, err := r.compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, rule.Field, err)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+
+	return r, nil
+}
+
+func (r *Redact[T]) compile(rule RedactRule) (redactRule, error) {
+	compiled := redactRule{RedactRule: rule}
+
+	switch rule.Action {
+	case RedactDrop, RedactMask, RedactTruncateIP:
+	case RedactHMAC:
+		if len(r.key) == 0 {
+			return compiled, errors.New("hmac key is not set")
+		}
+	default:
+		return compiled, fmt.Errorf("unknown action %q", rule.Action)
+	}
+
+	switch {
+	case rule.Field == FieldIpAddress, rule.Field == FieldSessionId, rule.Field == FieldResourcePath:
+	case rule.Field == FieldUserId:
+		if rule.Action == RedactMask || rule.Action == RedactTruncateIP {
+			return compiled, fmt.Errorf("action %s isn't applicable to user_id", rule.Action)
+		}
+	case rule.Field == FieldRepresentation:
+	case strings.HasPrefix(rule.Field, FieldDetailsPrefix) && len(rule.Field) > len(FieldDetailsPrefix):
+	default:
+		return compiled, fmt.Errorf("unknown field %q", rule.Field)
+	}
+
+	if len(rule.QueryParams) > 0 && (rule.Field == FieldUserId || rule.Field == FieldRepresentation) {
+		return compiled, errors.New("query_params are applicable to string fields only")
+	}
+	if len(rule.Paths) > 0 && rule.Field != FieldRepresentation {
+		return compiled, errors.New("paths are applicable to representation only")
+	}
+
+	for _, path := range rule.Paths {
+		elems, err := parsePath(path)
+		if err != nil {
+			return compiled, err
+		}
+		compiled.paths = append(compiled.paths, elems)
+	}
+
+	return compiled, nil
+}
+
+func (r *Redact[T]) Process(event *T) (Result, error) {
+	var errs []error
+	for _, rule := range r.rules {
+		err := r.apply(event, rule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rule.Field, err))
+		}
+	}
+
+	return Continue, errors.Join(errs...)
+}
+
+func (r *Redact[T]) apply(event *T, rule redactRule) error {
+	if rule.Field == FieldUserId {
+		if id := userIdOf(event); id != nil && *id != uuid.Nil {
+			*id = r.uuidValue(rule.Action, *id)
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(rule.Field, FieldDetailsPrefix) {
+		details := detailsOf(event)
+		key := strings.TrimPrefix(rule.Field, FieldDetailsPrefix)
+		value, ok := details[key]
+		if !ok {
+			return nil
+		}
+
+		value, keep := r.stringValue(rule, value)
+		if keep {
+			details[key] = value
+		} else {
+			delete(details, key)
+		}
+		return nil
+	}
+
+	field := stringFieldOf(event, rule.Field)
+	if field == nil || *field == "" {
+		return nil
+	}
+
+	if rule.Field == FieldRepresentation && len(rule.paths) > 0 {
+		value, err := r.representation(rule, *field)
+		*field = value
+		return err
+	}
+
+	*field, _ = r.stringValue(rule, *field)
+	if rule.Field == FieldResourcePath {
+		refreshResource(event)
+	}
+
+	return nil
+}
+
+// refreshResource заменяет разобранный путь ресурса в обогащении, чтобы в нем не остались исходные идентификаторы
+func refreshResource[T internal.Event | internal.AdminEvent](event *T) {
+	e, ok := any(event).(*internal.AdminEvent)
+	if ok && e.Enrichment != nil && e.Enrichment.Resource != nil {
+		e.Enrichment.Resource = internal.ParseResourcePath(e.ResourcePath)
+	}
+}
+
+// stringValue применяет действие к строке или к параметрам URL в ней. false — значение нужно удалить.
+// Значение, которое не разбирается как URL, удаляется целиком и учитывается в метрике.
+func (r *Redact[T]) stringValue(rule redactRule, value string) (string, bool) {
+	if len(rule.QueryParams) == 0 {
+		return r.value(rule.Action, value)
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		r.unparsed(rule)
+		return "", false
+	}
+
+	query := u.Query()
+	for _, param := range rule.QueryParams {
+		values, ok := query[param]
+		if !ok {
+			continue
+		}
+
+		redacted := values[:0]
+		for _, v := range values {
+			if v, keep := r.value(rule.Action, v); keep {
+				redacted = append(redacted, v)
+			}
+		}
+		if len(redacted) == 0 {
+			query.Del(param)
+		} else {
+			query[param] = redacted
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), true
+}
+
+func (r *Redact[T]) unparsed(rule redactRule) {
+	metrics.RedactUnparsed.WithLabelValues(internal.MetaOf(new(T)).Kind, rule.Field).Inc()
+}
+
+func (r *Redact[T]) value(action RedactAction, value string) (string, bool) {
+	switch action {
+	case RedactMask:
+		return maskValue, true
+	case RedactTruncateIP:
+		return r.truncateIP(value), true
+	case RedactHMAC:
+		return r.token(value), true
+	default:
+		return "", false
+	}
+}
+
+func (r *Redact[T]) uuidValue(action RedactAction, id uuid.UUID) uuid.UUID {
+	if action != RedactHMAC {
+		return uuid.Nil
+	}
+
+	// псевдоним остается UUID: первые 16 байт HMAC с версией 8 (пользовательский формат RFC 9562)
+	var token uuid.UUID
+	copy(token[:], r.sum(id.String()))
+	token[6] = (token[6] & 0x0f) | 0x80
+	token[8] = (token[8] & 0x3f) | 0x80
+
+	return token
+}
+
+// truncateIP оставляет префикс адреса, некорректный адрес удаляется
+func (r *Redact[T]) truncateIP(value string) string {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	bits := r.ipv6Prefix
+	if addr.Is4() {
+		bits = r.ipv4Prefix
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+
+	return prefix.Addr().String()
+}
+
+func (r *Redact[T]) token(value string) string {
+	return hex.EncodeToString(r.sum(value)[:16])
+}
+
+func (r *Redact[T]) sum(value string) []byte {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+
+	return mac.Sum(nil)
+}
+
+// representation применяет действие к значениям по путям JSON. Строки обрабатываются как строки,
+// остальные значения при псевдонимизации берутся в виде JSON. Не JSON удаляется целиком и учитывается в метрике.
+func (r *Redact[T]) representation(rule redactRule, value string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+
+	var doc any
+	err := decoder.Decode(&doc)
+	if err != nil {
+		r.unparsed(rule)
+		return "", nil
+	}
+
+	redact := func(v any) (any, bool) {
+		if rule.Action == RedactDrop {
+			return nil, false
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			raw, _ := json.Marshal(v)
+			s = string(raw)
+		}
+		result, keep := r.value(rule.Action, s)
+
+		return result, keep
+	}
+	for _, path := range rule.paths {
+		doc = walkPath(doc, path, redact)
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(doc)
+	if err != nil {
+		return "", fmt.Errorf("can't encode representation: %w", err)
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func userIdOf[T internal.Event | internal.AdminEvent](event *T) *uuid.UUID {
+	switch e := any(event).(type) {
+	case *internal.Event:
+		return &e.UserId
+	case *internal.AdminEvent:
+		if e.AuthDetails != nil {
+			return &e.AuthDetails.UserId
+		}
+	}
+
+	return nil
+}
+
+func detailsOf[T internal.Event | internal.AdminEvent](event *T) map[string]string {
+	switch e := any(event).(type) {
+	case *internal.Event:
+		return e.Details
+	case *internal.AdminEvent:
+		return e.Details
+	}
+
+	return nil
+}
+
+// stringFieldOf возвращает строковое поле события, nil — у события такого поля нет
+func stringFieldOf[T internal.Event | internal.AdminEvent](event *T, field string) *string {
+	switch e := any(event).(type) {
+	case *internal.Event:
+		switch field {
+		case FieldIpAddress:
+			return &e.IpAddress
+		case FieldSessionId:
+			return &e.SessionId
+		}
+	case *internal.AdminEvent:
+		switch field {
+		case FieldIpAddress:
+			if e.AuthDetails != nil {
+				return &e.AuthDetails.IpAddress
+			}
+		case FieldResourcePath:
+			return &e.ResourcePath
+		case FieldRepresentation:
+			return &e.Representation
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestRedact_Process(t *testing.T) {
+	t.Parallel()
+
+	redact, err := NewRedact[internal.Event]([]RedactRule{
+		{Field: FieldIpAddress, Action: RedactTruncateIP},
+		{Field: FieldUserId, Action: RedactHMAC},
+		{Field: FieldSessionId, Action: RedactDrop},
+		{Field: "details.username", Action: RedactHMAC},
+		{Field: "details.email", Action: RedactMask},
+		{Field: "details.code_id", Action: RedactDrop},
+		{Field: "details.redirect_uri", Action: RedactDrop, QueryParams: []string{"code", "session_state"}},
+		{Field: "details.missing", Action: RedactDrop},
+	}, testKey, 0, 0)
+	require.NoError(t, err)
+
+	userId := uuid.New()
+	newEvent := func() *internal.Event {
+		return &internal.Event{
+			UserId:    userId,
+			IpAddress: "81.2.69.142",
+			SessionId: "session",
+			Details: map[string]string{
+				"username":     "john",
+				"email":        "john@example.com",
+				"code_id":      "abc",
+				"redirect_uri": "https://app.example.com/cb?code=xyz&session_state=s1&lang=en",
+				"auth_method":  "openid-connect",
+			},
+		}
+	}
+
+	event := newEvent()
+	result, err := redact.Process(event)
+	require.NoError(t, err)
+	assert.Equal(t, Continue, result)
+
+	assert.Equal(t, "81.2.69.0", event.IpAddress)
+	assert.Empty(t, event.SessionId)
+	assert.NotEqual(t, userId, event.UserId)
+	assert.Equal(t, uuid.Version(8), event.UserId.Version())
+	assert.Len(t, event.Details["username"], 32)
+	assert.NotEqual(t, "john", event.Details["username"])
+	assert.Equal(t, "***", event.Details["email"])
+	assert.NotContains(t, event.Details, "code_id")
+	assert.Equal(t, "https://app.example.com/cb?lang=en", event.Details["redirect_uri"])
+	assert.Equal(t, "openid-connect", event.Details["auth_method"])
+
+	// псевдоним стабилен между событиями
+	other := newEvent()
+	_, err = redact.Process(other)
+	require.NoError(t, err)
+	assert.Equal(t, event.UserId, other.UserId)
+	assert.Equal(t, event.Details["username"], other.Details["username"])
+}
+
+func TestRedact_ProcessAdmin(t *testing.T) {
+	t.Parallel()
+
+	redact, err := NewRedact[internal.AdminEvent]([]RedactRule{
+		{Field: FieldIpAddress, Action: RedactTruncateIP},
+		{Field: FieldUserId, Action: RedactDrop},
+		{Field: FieldRepresentation, Action: RedactDrop, Paths: []string{"credentials[*].value", "attributes.phone"}},
+		{Field: FieldRepresentation, Action: RedactHMAC, Paths: []string{"email"}},
+	}, testKey, 0, 0)
+	require.NoError(t, err)
+
+	adminEvent := &internal.AdminEvent{
+		AuthDetails:    &internal.AuthDetails{UserId: uuid.New(), IpAddress: "2a02:6b8:1:2::1"},
+		Representation: `{"username":"john","email":"john@example.com","credentials":[{"type":"password","value":"secret"}],"attributes":{"phone":["+1"]}}`,
+	}
+	_, err = redact.Process(adminEvent)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2a02:6b8:1::", adminEvent.AuthDetails.IpAddress)
+	assert.Equal(t, uuid.Nil, adminEvent.AuthDetails.UserId)
+	assert.NotContains(t, adminEvent.Representation, "secret")
+	assert.NotContains(t, adminEvent.Representation, "phone")
+	assert.NotContains(t, adminEvent.Representation, "john@example.com")
+	assert.Contains(t, adminEvent.Representation, `"credentials":[{"type":"password"}]`)
+	assert.Contains(t, adminEvent.Representation, `"username":"john"`)
+}
+
+func TestRedact_ProcessResourcePath(t *testing.T) {
+	t.Parallel()
+
+	redact, err := NewRedact[internal.AdminEvent]([]RedactRule{
+		{Field: FieldResourcePath, Action: RedactMask},
+	}, testKey, 0, 0)
+	require.NoError(t, err)
+
+	adminEvent := &internal.AdminEvent{ResourcePath: "users/1/role-mappings/realm"}
+	internal.EnrichmentOf(adminEvent).Resource = internal.ParseResourcePath(adminEvent.ResourcePath)
+	_, err = redact.Process(adminEvent)
+	require.NoError(t, err)
+
+	assert.Equal(t, "***", adminEvent.ResourcePath)
+	assert.Equal(t, "***", adminEvent.Enrichment.Resource.Kind)
+	assert.Empty(t, adminEvent.Enrichment.Resource.TargetUserId)
+}
+
+func TestRedact_ProcessUnparsed(t *testing.T) {
+	t.Parallel()
+
+	redact, err := NewRedact[internal.AdminEvent]([]RedactRule{
+		{Field: FieldRepresentation, Action: RedactDrop, Paths: []string{"credentials"}},
+		{Field: "details.redirect_uri", Action: RedactMask, QueryParams: []string{"code"}},
+	}, nil, 0, 0)
+	require.NoError(t, err)
+
+	adminEvent := &internal.AdminEvent{
+		Representation: `{"credentials":`,
+		Details:        map[string]string{"redirect_uri": "http://[::1"},
+	}
+	// значения, которые не удалось разобрать, удаляются без ошибки обработчика
+	_, err = redact.Process(adminEvent)
+	require.NoError(t, err)
+	assert.Empty(t, adminEvent.Representation)
+	assert.NotContains(t, adminEvent.Details, "redirect_uri")
+
+	unparsed := func(field string) float64 {
+		return testutil.ToFloat64(metrics.RedactUnparsed.WithLabelValues(internal.KindAdminEvents, field))
+	}
+	assert.Equal(t, float64(1), unparsed(FieldRepresentation))
+	assert.Equal(t, float64(1), unparsed("details.redirect_uri"))
+}
+
+func TestRedact_TruncateIP(t *testing.T) {
+	tests := []struct {
+		ip         string
+		ipv4Prefix int
+		ipv6Prefix int
+		want       string
+	}{
+		{ip: "81.2.69.142", want: "81.2.69.0"},
+		{ip: "81.2.69.142", ipv4Prefix: 16, want: "81.2.0.0"},
+		{ip: "::ffff:81.2.69.142", want: "81.2.69.0"},
+		{ip: "2a02:6b8:1:2::1", want: "2a02:6b8:1::"},
+		{ip: "2a02:6b8:1:2::1", ipv6Prefix: 64, want: "2a02:6b8:1:2::"},
+		{ip: "unknown", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			t.Parallel()
+
+			redact, err := NewRedact[internal.Event]([]RedactRule{{Field: FieldIpAddress, Action: RedactTruncateIP}}, nil, tt.ipv4Prefix, tt.ipv6Prefix)
+			require.NoError(t, err)
+
+			event := &internal.Event{IpAddress: tt.ip}
+			_, err = redact.Process(event)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, event.IpAddress)
+		})
+	}
+}
+
+func TestNewRedact_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []RedactRule
+		key   []byte
+	}{
+		{name: "no rules"},
+		{name: "unknown action", rules: []RedactRule{{Field: FieldIpAddress, Action: "hash"}}},
+		{name: "unknown field", rules: []RedactRule{{Field: "username", Action: RedactDrop}}},
+		{name: "empty details key", rules: []RedactRule{{Field: "details.", Action: RedactDrop}}},
+		{name: "hmac without key", rules: []RedactRule{{Field: FieldUserId, Action: RedactHMAC}}},
+		{name: "mask uuid", rules: []RedactRule{{Field: FieldUserId, Action: RedactMask}}},
+		{name: "paths of string field", rules: []RedactRule{{Field: "details.email", Action: RedactDrop, Paths: []string{"a"}}}},
+		{name: "query params of representation", rules: []RedactRule{{Field: FieldRepresentation, Action: RedactDrop, QueryParams: []string{"code"}}}},
+		{name: "invalid path", rules: []RedactRule{{Field: FieldRepresentation, Action: RedactDrop, Paths: []string{"a["}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewRedact[internal.Event](tt.rules, tt.key, 0, 0)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := NewRedact[internal.Event]([]RedactRule{{Field: FieldIpAddress, Action: RedactTruncateIP}}, nil, 33, 0)
+	assert.Error(t, err)
+}
+
+func TestBuild_Redact(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, append(testKey, '\n'), 0o600))
+	shortKeyFile := filepath.Join(dir, "short")
+	require.NoError(t, os.WriteFile(shortKeyFile, []byte("short"), 0o600))
+
+	rules := []RuleConfig{{Field: FieldUserId, Action: string(RedactHMAC)}}
+
+	p, err := Build[internal.Event](StageIngest, []StepConfig{{Type: TypeRedact, Rules: rules, HMACKeyFile: keyFile}}, Deps{}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.Len())
+
+	_, err = Build[internal.Event](StageIngest, []StepConfig{{Type: TypeRedact, Rules: rules, HMACKeyFile: shortKeyFile}}, Deps{}, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = Build[internal.Event](StageIngest, []StepConfig{{Type: TypeRedact, Rules: rules, HMACKeyFile: filepath.Join(dir, "missing")}}, Deps{}, zap.NewNop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+type RuleAction string
+
+const (
+	RuleKeep   RuleAction = "keep"
+	RuleDrop   RuleAction = "drop"
+	RuleSample RuleAction = "sample"
+)
+
+// defaultRule имя решения по умолчанию в метриках
+const defaultRule = "default"
+
+// Rule правило фильтрации: действие первого подходящего правила определяет судьбу события
+type Rule struct {
+	// Name имя правила в метриках, по умолчанию номер правила
+	Name   string     `yaml:"name"`
+	Match  Match      `yaml:"match"`
+	Action RuleAction `yaml:"action"`
+	// Rate доля сохраняемых событий для sample, от 0 до 1
+	Rate float64 `yaml:"rate"`
+}
+
+type rule struct {
+	Rule
+	// threshold событие сохраняется, если хеш его идентификатора меньше порога
+	threshold uint64
+	kept      prometheus.Counter
+	dropped   prometheus.Counter
+}
+
+// Rules отбрасывает, сохраняет или прореживает события по правилам. Прореживание детерминировано:
+// решение зависит только от идентификатора события и не меняется при повторной обработке.
+// В режиме dryRun события не отбрасываются, решения только учитываются в метриках.
+type Rules[T internal.Event | internal.AdminEvent] struct {
+	rules    []rule
+	fallback rule
+	dryRun   bool
+	logger   *zap.Logger
+}
+
+// RulesOptions параметры обработчика rules, stage и name используются в метках метрик
+type RulesOptions struct {
+	Stage   string
+	Name    string
+	Rules   []Rule
+	Default RuleAction
+	DryRun  bool
+}
+
+func NewRules[T internal.Event | internal.AdminEvent](opts RulesOptions, logger *zap.Logger) (*Rules[T], error) {
+	if len(opts.Rules) == 0 {
+		return nil, errors.New("rules are empty")
+	}
+	if opts.Default == "" {
+		opts.Default = RuleKeep
+	}
+	if opts.Default != RuleKeep && opts.Default != RuleDrop {
+		return nil, fmt.Errorf("default action must be keep or drop, got %q", opts.Default)
+	}
+
+	kind := internal.MetaOf(new(T)).Kind
+	dryRun := strconv.FormatBool(opts.DryRun)
+	compile := func(r Rule) (rule, error) {
+		err := r.Match.validate()
+		if err != nil {
+			return rule{}, err
+		}
+		// выражение правила другого вида событий не компилируется: правило не выполняется по kinds
+		if matchAny(r.Match.Kinds, kind) {
+			err = compileMatch[T](&r.Match)
+			if err != nil {
+				return rule{}, err
+			}
+		}
+
+		compiled := rule{
+			Rule:    r,
+			kept:    metrics.RuleDecisions.WithLabelValues(opts.Stage, kind, opts.Name, r.Name, string(RuleKeep), dryRun),
+			dropped: metrics.RuleDecisions.WithLabelValues(opts.Stage, kind, opts.Name, r.Name, string(RuleDrop), dryRun),
+		}
+
+		switch r.Action {
+		case RuleKeep, RuleDrop:
+		case RuleSample:
+			if r.Rate < 0 || r.Rate > 1 || math.IsNaN(r.Rate) {
+				return compiled, fmt.Errorf("rate %v is out of [0, 1]", r.Rate)
+			}
+			compiled.threshold = sampleThreshold(r.Rate)
+		default:
+			return compiled, fmt.Errorf("unknown action %q", r.Action)
+		}
+
+		return compiled, nil
+	}
+
+	p := &Rules[T]{dryRun: opts.DryRun, logger: logger}
+	names := map[string]bool{defaultRule: true}
+	for i, r := range opts.Rules {
+		if r.Name == "" {
+			r.Name = strconv.Itoa(i)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		names[r.Name] = true
+
+		compiled, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		p.rules = append(p.rules, compiled)
+	}
+
+	var err error
+	p.fallback, err = compile(Rule{Name: defaultRule, Action: opts.Default})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Rules[T]) Process(event *T) (Result, error) {
+	r := p.match(event)
+
+	keep := true
+	switch r.Action {
+	case RuleDrop:
+		keep = false
+	case RuleSample:
+		keep = sampleHash(internal.MetaOf(event).Id) < r.threshold
+	}
+
+	if keep {
+		r.kept.Inc()
+		return Continue, nil
+	}
+
+	r.dropped.Inc()
+	if p.dryRun {
+		p.logger.Debug("event would be dropped by rule",
+			zap.String("rule", r.Name),
+			zap.Stringer("event_id", internal.MetaOf(event).Id),
+		)
+		return Continue, nil
+	}
+
+	return Drop, nil
+}
+
+func (p *Rules[T]) match(event *T) *rule {
+	for i := range p.rules {
+		if Matches(&p.rules[i].Match, event) {
+			return &p.rules[i]
+		}
+	}
+
+	return &p.fallback
+}
+
+// sampleThreshold переводит долю в порог хеша: rate = 1 сохраняет все события, rate = 0 — ни одного
+func sampleThreshold(rate float64) uint64 {
+	threshold := rate * math.Ldexp(1, 64)
+	if threshold >= math.Ldexp(1, 64) {
+		return math.MaxUint64
+	}
+
+	return uint64(threshold)
+}
+
+// sampleHash равномерно распределенный хеш идентификатора события (FNV-1a)
+func sampleHash(id uuid.UUID) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(id[:])
+
+	return h.Sum64()
+}
@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/metrics"
+)
+
+func TestRules_Process(t *testing.T) {
+	t.Parallel()
+
+	rules, err := NewRules[internal.Event](RulesOptions{
+		Stage: StageIngest,
+		Name:  "test-rules",
+		Rules: []Rule{
+			{Name: "errors", Match: Match{EventTypes: []string{"*_ERROR"}}, Action: RuleKeep},
+			{Name: "noise", Match: Match{EventTypes: []string{"REFRESH_TOKEN", "CODE_TO_TOKEN"}}, Action: RuleDrop},
+			{Name: "service", Match: Match{Clients: []string{"service"}}, Action: RuleSample, Rate: 0},
+			{Name: "master", Match: Match{Realms: []string{"master"}, Outcome: OutcomeSuccess}, Action: RuleSample, Rate: 1},
+		},
+		Default: RuleDrop,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		event *internal.Event
+		want  Result
+	}{
+		{name: "first match wins", event: &internal.Event{Type: internal.EventTypeRefreshTokenError, ClientId: "service"}, want: Continue},
+		{name: "drop", event: &internal.Event{Type: internal.EventTypeRefreshToken, RealmName: "master"}, want: Drop},
+		{name: "sample none", event: &internal.Event{Type: internal.EventTypeLogin, ClientId: "service", RealmName: "master"}, want: Drop},
+		{name: "sample all", event: &internal.Event{Type: internal.EventTypeLogin, RealmName: "master"}, want: Continue},
+		{name: "default", event: &internal.Event{Type: internal.EventTypeLogin, RealmName: "other"}, want: Drop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.event.Id = uuid.New()
+			result, err := rules.Process(tt.event)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestRules_ProcessAdmin(t *testing.T) {
+	t.Parallel()
+
+	rules, err := NewRules[internal.AdminEvent](RulesOptions{
+		Rules: []Rule{
+			{Match: Match{ResourceTypes: []string{"USER_SESSION"}, OperationTypes: []string{"DELETE"}}, Action: RuleDrop},
+			{Match: Match{EventTypes: []string{"*"}}, Action: RuleDrop},
+			{Match: Match{Kinds: []string{internal.KindEvents}, Expr: `event.type == LOGIN`}, Action: RuleDrop},
+			{Match: Match{Expr: `event.resource_type == "CLIENT"`}, Action: RuleDrop},
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	result, err := rules.Process(&internal.AdminEvent{ResourceType: "USER_SESSION", OperationType: internal.OperationTypeDelete})
+	require.NoError(t, err)
+	assert.Equal(t, Drop, result)
+
+	// условие по типу события не выполняется для события администрирования
+	result, err = rules.Process(&internal.AdminEvent{ResourceType: "USER", OperationType: internal.OperationTypeDelete})
+	require.NoError(t, err)
+	assert.Equal(t, Continue, result)
+
+	result, err = rules.Process(&internal.AdminEvent{ResourceType: "CLIENT", OperationType: internal.OperationTypeUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, Drop, result)
+}
+
+func TestRules_Sample(t *testing.T) {
+	t.Parallel()
+
+	rules, err := NewRules[internal.Event](RulesOptions{
+		Rules: []Rule{{Action: RuleSample, Rate: 0.1}},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	const total = 10000
+	kept := 0
+	for range total {
+		event := &internal.Event{Id: uuid.New()}
+		result, err := rules.Process(event)
+		require.NoError(t, err)
+		if result == Continue {
+			kept++
+		}
+
+		// решение для события не меняется при повторной обработке
+		again, err := rules.Process(event)
+		require.NoError(t, err)
+		assert.Equal(t, result, again)
+	}
+	assert.InDelta(t, total/10, kept, total/50)
+}
+
+func TestRules_DryRun(t *testing.T) {
+	t.Parallel()
+
+	rules, err := NewRules[internal.Event](RulesOptions{
+		Stage:  StageDelivery,
+		Name:   "dry-run-rules",
+		Rules:  []Rule{{Name: "noise", Match: Match{EventTypes: []string{"REFRESH_TOKEN"}}, Action: RuleDrop}},
+		DryRun: true,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	for _, eventType := range []internal.EventType{internal.EventTypeRefreshToken, internal.EventTypeRefreshToken, internal.EventTypeLogin} {
+		result, err := rules.Process(&internal.Event{Type: eventType})
+		require.NoError(t, err)
+		assert.Equal(t, Continue, result)
+	}
+
+	counter := func(rule, decision string) float64 {
+		return testutil.ToFloat64(metrics.RuleDecisions.WithLabelValues(StageDelivery, internal.KindEvents, "dry-run-rules", rule, decision, "true"))
+	}
+	assert.Equal(t, float64(2), counter("noise", "drop"))
+	assert.Equal(t, float64(1), counter("default", "keep"))
+}
+
+func TestNewRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RulesOptions
+	}{
+		{name: "no rules"},
+		{name: "unknown action", opts: RulesOptions{Rules: []Rule{{Action: "pass"}}}},
+		{name: "rate above one", opts: RulesOptions{Rules: []Rule{{Action: RuleSample, Rate: 1.5}}}},
+		{name: "negative rate", opts: RulesOptions{Rules: []Rule{{Action: RuleSample, Rate: -0.1}}}},
+		{name: "sample default", opts: RulesOptions{Rules: []Rule{{Action: RuleKeep}}, Default: RuleSample}},
+		{name: "duplicate name", opts: RulesOptions{Rules: []Rule{{Name: "a", Action: RuleKeep}, {Name: "a", Action: RuleDrop}}}},
+		{name: "reserved name", opts: RulesOptions{Rules: []Rule{{Name: defaultRule, Action: RuleKeep}}}},
+		{name: "invalid match", opts: RulesOptions{Rules: []Rule{{Match: Match{Outcome: "failed"}, Action: RuleKeep}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewRules[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
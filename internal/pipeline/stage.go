@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+
+	"keycloak-events-adapter/internal"
+)
+
+// Keeper выполняет конвейер приема перед помещением события в очередь.
+// Отброшенное событие не попадает в очередь, а отправитель получает успешный ответ.
+type Keeper[T internal.Event | internal.AdminEvent] struct {
+	next     internal.EventKeeper[T]
+	pipeline *Pipeline[T]
+}
+
+func NewKeeper[T internal.Event | internal.AdminEvent](next internal.EventKeeper[T], pipeline *Pipeline[T]) *Keeper[T] {
+	return &Keeper[T]{
+		next:     next,
+		pipeline: pipeline,
+	}
+}
+
+func (k *Keeper[T]) Push(event *T) error {
+	result, err := k.pipeline.Run(event)
+	if err != nil {
+		return err
+	}
+	if result == Drop {
+		return nil
+	}
+
+	return k.next.Push(event)
+}
+
+func (k *Keeper[T]) Process(ctx context.Context) {
+	k.next.Process(ctx)
+}
+
+// Sender выполняет конвейер доставки перед отправкой события в приемник.
+// Отброшенное событие подтверждается в очереди без отправки, ошибка возвращает задачу в очередь.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	next     internal.EventSender[T]
+	pipeline *Pipeline[T]
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](next internal.EventSender[T], pipeline *Pipeline[T]) *Sender[T] {
+	return &Sender[T]{
+		next:     next,
+		pipeline: pipeline,
+	}
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	result, err := s.pipeline.Run(event)
+	if err != nil {
+		return err
+	}
+	if result == Drop {
+		return nil
+	}
+
+	return s.next.Send(event)
+}
+
+// BatchSender выполняет конвейер доставки для каждого события пачки и отправляет оставшиеся события
+type BatchSender[T internal.Event | internal.AdminEvent] struct {
+	*Sender[T]
+	next internal.BatchSender[T]
+}
+
+// NewBatchSender оборачивает отправителя, поддерживающего пачки, отдельные события отправляются через Send
+func NewBatchSender[T internal.Event | internal.AdminEvent, S interface {
+	internal.EventSender[T]
+	internal.BatchSender[T]
+}](next S, pipeline *Pipeline[T]) *BatchSender[T] {
+	return &BatchSender[T]{
+		Sender: NewSender[T](next, pipeline),
+		next:   next,
+	}
+}
+
+func (s *BatchSender[T]) SendBatch(events []*T) error {
+	kept := make([]*T, 0, len(events))
+	for _, event := range events {
+		result, err := s.pipeline.Run(event)
+		if err != nil {
+			return err
+		}
+		if result == Continue {
+			kept = append(kept, event)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return s.next.SendBatch(kept)
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/mock"
+)
+
+func dropRealm[T internal.Event | internal.AdminEvent](realm string) *Pipeline[T] {
+	return New[T](StageIngest, zap.NewNop()).Add("drop", NewDropMatched[T](Match{Realms: []string{realm}}), "")
+}
+
+func TestKeeper_Push(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline *Pipeline[internal.Event]
+		pushed   bool
+		wantErr  bool
+	}{
+		{name: "pushed", pipeline: dropRealm[internal.Event]("other"), pushed: true},
+		{name: "dropped", pipeline: dropRealm[internal.Event]("master")},
+		{
+			name:     "processor error",
+			pipeline: New[internal.Event](StageIngest, zap.NewNop()).Add("failing", failing[internal.Event](), OnErrorFail),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			next := mock.NewMockEventKeeper[internal.Event](ctrl)
+			event := &internal.Event{Id: uuid.New(), RealmName: "master"}
+			if tt.pushed {
+				next.EXPECT().Push(event).Return(nil)
+			}
+
+			err := NewKeeper[internal.Event](next, tt.pipeline).Push(event)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	next := mock.NewMockEventSender[internal.AdminEvent](ctrl)
+	sender := NewSender[internal.AdminEvent](next, dropRealm[internal.AdminEvent]("test"))
+
+	kept := &internal.AdminEvent{Id: uuid.New(), RealmName: "master"}
+	next.EXPECT().Send(kept).Return(errors.New("sink error"))
+
+	assert.Error(t, sender.Send(kept))
+	assert.NoError(t, sender.Send(&internal.AdminEvent{Id: uuid.New(), RealmName: "test"}))
+}
+
+type batchSender struct {
+	*mock.MockEventSender[internal.Event]
+	*mock.MockBatchSender[internal.Event]
+}
+
+func TestBatchSender_SendBatch(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	next := batchSender{
+		MockEventSender: mock.NewMockEventSender[internal.Event](ctrl),
+		MockBatchSender: mock.NewMockBatchSender[internal.Event](ctrl),
+	}
+	sender := NewBatchSender[internal.Event](next, dropRealm[internal.Event]("test"))
+
+	first := &internal.Event{Id: uuid.New(), RealmName: "master"}
+	dropped := &internal.Event{Id: uuid.New(), RealmName: "test"}
+	second := &internal.Event{Id: uuid.New(), RealmName: "other"}
+	next.MockBatchSender.EXPECT().SendBatch([]*internal.Event{first, second}).Return(nil)
+
+	assert.NoError(t, sender.SendBatch([]*internal.Event{first, dropped, second}))
+	assert.NoError(t, sender.SendBatch([]*internal.Event{dropped}))
+
+	next.MockEventSender.EXPECT().Send(first).Return(nil)
+	assert.NoError(t, sender.Send(first))
+}
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Типы идентификаторов пути ресурса, по которым заполняются целевые ресурсы ResourceRef
+const (
+	resourceIdUser   = "user"
+	resourceIdClient = "client"
+	resourceIdGroup  = "group"
+	resourceIdOther  = "id"
+)
+
+// resourceShapes формы путей ресурсов Admin REST API Keycloak относительно realm. {} — идентификатор
+// ресурса предыдущего сегмента, {user}, {client} и {group} — идентификаторы пользователя, клиента и группы.
+var resourceShapes = []string{
+	"users/{user}/role-mappings/realm",
+	"users/{user}/role-mappings/clients/{client}",
+	"users/{user}/groups/{group}",
+	"users/{user}/credentials/{}/moveAfter/{}",
+	"users/{user}/credentials/{}/moveToFirst",
+	"users/{user}/credentials/{}/userLabel",
+	"users/{user}/federated-identity/{}",
+	"users/{user}/consents/{}",
+	"users/{user}/reset-password",
+	"users/{user}/reset-password-email",
+	"users/{user}/execute-actions-email",
+	"users/{user}/send-verify-email",
+	"users/{user}/disable-credential-types",
+	"users/{user}/impersonation",
+	"users/{user}/logout",
+	"groups/{group}/children/{group}",
+	"groups/{group}/role-mappings/realm",
+	"groups/{group}/role-mappings/clients/{client}",
+	"groups/{group}/management/permissions",
+	"clients/{client}/client-secret/rotated",
+	"clients/{client}/registration-access-token",
+	"clients/{client}/roles/{}/composites/realm",
+	"clients/{client}/roles/{}/composites/clients/{client}",
+	"clients/{client}/protocol-mappers/models/{}",
+	"clients/{client}/protocol-mappers/add-models",
+	"clients/{client}/scope-mappings/realm",
+	"clients/{client}/scope-mappings/clients/{client}",
+	"clients/{client}/default-client-scopes/{}",
+	"clients/{client}/optional-client-scopes/{}",
+	"clients/{client}/certificates/{}/generate",
+	"clients/{client}/certificates/{}/upload",
+	"clients/{client}/certificates/{}/upload-certificate",
+	"clients/{client}/nodes/{}",
+	"clients/{client}/push-revocation",
+	"clients/{client}/management/permissions",
+	"clients/{client}/authz/resource-server/resource/{}",
+	"clients/{client}/authz/resource-server/scope/{}",
+	"clients/{client}/authz/resource-server/policy/{}",
+	"clients/{client}/authz/resource-server/permission/{}",
+	"clients/{client}/authz/resource-server/import",
+	"clients/{client}/authz/resource-server/settings",
+	"client-scopes/{}/protocol-mappers/models/{}",
+	"client-scopes/{}/protocol-mappers/add-models",
+	"client-scopes/{}/scope-mappings/realm",
+	"client-scopes/{}/scope-mappings/clients/{client}",
+	"roles/{}/composites/realm",
+	"roles/{}/composites/clients/{client}",
+	"roles-by-id/{}/composites/realm",
+	"roles-by-id/{}/composites/clients/{client}",
+	"identity-provider/instances/{}/mappers/{}",
+	"identity-provider/import-config",
+	"components/{}",
+	"authentication/flows/{}/copy",
+	"authentication/flows/{}/executions/execution",
+	"authentication/flows/{}/executions/flow",
+	"authentication/executions/{}/config",
+	"authentication/executions/{}/raise-priority",
+	"authentication/executions/{}/lower-priority",
+	"authentication/config/{}",
+	"authentication/required-actions/{}/config",
+	"authentication/required-actions/{}/raise-priority",
+	"authentication/required-actions/{}/lower-priority",
+	"authentication/register-required-action",
+	"default-groups/{group}",
+	"default-default-client-scopes/{}",
+	"default-optional-client-scopes/{}",
+	"clients-initial-access/{}",
+	"organizations/{}/members/{user}",
+	"organizations/{}/identity-providers/{}",
+	"attack-detection/brute-force/users/{user}",
+	"sessions/{}",
+}
+
+type resourceNode struct {
+	// id тип идентификатора, следующего за сегментом, пустой — сегмент без идентификатора
+	id       string
+	children map[string]*resourceNode
+}
+
+var resourceTree = newResourceTree(resourceShapes)
+
+func newResourceTree(shapes []string) *resourceNode {
+	root := &resourceNode{children: map[string]*resourceNode{}}
+	for _, shape := range shapes {
+		node := root
+		for _, part := range strings.Split(shape, "/") {
+			if strings.HasPrefix(part, "{") {
+				node.id = strings.Trim(part, "{}")
+				if node.id == "" {
+					node.id = resourceIdOther
+				}
+				continue
+			}
+
+			child, ok := node.children[part]
+			if !ok {
+				child = &resourceNode{children: map[string]*resourceNode{}}
+				node.children[part] = child
+			}
+			node = child
+		}
+	}
+
+	return root
+}
+
+// ResourceSegment ресурс в пути: тип (сегмент пути) и идентификатор, если он есть
+type ResourceSegment struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id,omitempty"`
+}
+
+// ResourceRef разобранный путь ресурса события администрирования
+type ResourceRef struct {
+	// Kind цепочка типов ресурсов без идентификаторов, например users/role-mappings/clients
+	Kind     string            `json:"kind"`
+	Segments []ResourceSegment `json:"segments"`
+	// TargetUserId, TargetClientId и TargetGroupId идентификаторы первых в пути пользователя, клиента и группы
+	TargetUserId   string `json:"target_user_id,omitempty"`
+	TargetClientId string `json:"target_client_id,omitempty"`
+	TargetGroupId  string `json:"target_group_id,omitempty"`
+}
+
+// ParseResourcePath разбирает путь ресурса по формам Admin REST API Keycloak, пустой путь — nil.
+// Сегмент неизвестной формы считается типом ресурса, а UUID после него — идентификатором.
+func ParseResourcePath(resourcePath string) *ResourceRef {
+	parts := strings.FieldsFunc(resourcePath, func(r rune) bool { return r == '/' })
+	if len(parts) == 0 {
+		return nil
+	}
+
+	ref := &ResourceRef{Segments: make([]ResourceSegment, 0, len(parts))}
+	kinds := make([]string, 0, len(parts))
+	node := resourceTree
+	for i := 0; i < len(parts); i++ {
+		part := parts[i]
+		child, ok := node.children[part]
+		if !ok {
+			last := len(ref.Segments) - 1
+			if last >= 0 && ref.Segments[last].Id == "" && uuid.Validate(part) == nil {
+				ref.Segments[last].Id = part
+				continue
+			}
+			child = &resourceNode{}
+		}
+
+		segment := ResourceSegment{Kind: part}
+		if child.id != "" && i+1 < len(parts) {
+			i++
+			segment.Id = parts[i]
+			ref.setTarget(child.id, segment.Id)
+		}
+		ref.Segments = append(ref.Segments, segment)
+		kinds = append(kinds, part)
+		node = child
+	}
+	ref.Kind = strings.Join(kinds, "/")
+
+	return ref
+}
+
+func (r *ResourceRef) setTarget(idType, id string) {
+	var target *string
+	switch idType {
+	case resourceIdUser:
+		target = &r.TargetUserId
+	case resourceIdClient:
+		target = &r.TargetClientId
+	case resourceIdGroup:
+		target = &r.TargetGroupId
+	default:
+		return
+	}
+
+	if *target == "" {
+		*target = id
+	}
+}
+
+// ResourceOf возвращает разобранный путь ресурса из обогащения события, а если его там нет — разбирает путь
+func ResourceOf(event *AdminEvent) *ResourceRef {
+	if event.Enrichment != nil && event.Enrichment.Resource != nil {
+		return event.Enrichment.Resource
+	}
+
+	return ParseResourcePath(event.ResourcePath)
+}
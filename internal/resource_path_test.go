@@ -0,0 +1,287 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResourcePath(t *testing.T) {
+	const (
+		user   = "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a"
+		client = "7c0e5f1a-2b3d-4e5f-8a9b-0c1d2e3f4a5b"
+		group  = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+		child  = "5d4c3b2a-1f0e-4d9c-8b7a-6f5e4d3c2b1a"
+		id     = "0f1e2d3c-4b5a-4968-8776-5a4b3c2d1e0f"
+	)
+
+	tests := []struct {
+		name string
+		path string
+		want *ResourceRef
+	}{
+		{name: "empty", path: "", want: nil},
+		{name: "slashes", path: "//", want: nil},
+		{
+			name: "users",
+			path: "/users",
+			want: &ResourceRef{Kind: "users", Segments: []ResourceSegment{{Kind: "users"}}},
+		},
+		{
+			name: "user",
+			path: "users/" + user,
+			want: &ResourceRef{Kind: "users", Segments: []ResourceSegment{{Kind: "users", Id: user}}, TargetUserId: user},
+		},
+		{
+			name: "user realm role mapping",
+			path: "users/" + user + "/role-mappings/realm",
+			want: &ResourceRef{
+				Kind:         "users/role-mappings/realm",
+				Segments:     []ResourceSegment{{Kind: "users", Id: user}, {Kind: "role-mappings"}, {Kind: "realm"}},
+				TargetUserId: user,
+			},
+		},
+		{
+			name: "user client role mapping",
+			path: "users/" + user + "/role-mappings/clients/" + client,
+			want: &ResourceRef{
+				Kind: "users/role-mappings/clients",
+				Segments: []ResourceSegment{
+					{Kind: "users", Id: user}, {Kind: "role-mappings"}, {Kind: "clients", Id: client},
+				},
+				TargetUserId:   user,
+				TargetClientId: client,
+			},
+		},
+		{
+			name: "user group membership",
+			path: "users/" + user + "/groups/" + group,
+			want: &ResourceRef{
+				Kind:          "users/groups",
+				Segments:      []ResourceSegment{{Kind: "users", Id: user}, {Kind: "groups", Id: group}},
+				TargetUserId:  user,
+				TargetGroupId: group,
+			},
+		},
+		{
+			name: "user credential",
+			path: "users/" + user + "/credentials/" + id + "/moveToFirst",
+			want: &ResourceRef{
+				Kind:         "users/credentials/moveToFirst",
+				Segments:     []ResourceSegment{{Kind: "users", Id: user}, {Kind: "credentials", Id: id}, {Kind: "moveToFirst"}},
+				TargetUserId: user,
+			},
+		},
+		{
+			name: "user federated identity",
+			path: "users/" + user + "/federated-identity/google",
+			want: &ResourceRef{
+				Kind:         "users/federated-identity",
+				Segments:     []ResourceSegment{{Kind: "users", Id: user}, {Kind: "federated-identity", Id: "google"}},
+				TargetUserId: user,
+			},
+		},
+		{
+			name: "user action",
+			path: "users/" + user + "/reset-password",
+			want: &ResourceRef{
+				Kind:         "users/reset-password",
+				Segments:     []ResourceSegment{{Kind: "users", Id: user}, {Kind: "reset-password"}},
+				TargetUserId: user,
+			},
+		},
+		{
+			name: "child group keeps parent as target",
+			path: "groups/" + group + "/children/" + child,
+			want: &ResourceRef{
+				Kind:          "groups/children",
+				Segments:      []ResourceSegment{{Kind: "groups", Id: group}, {Kind: "children", Id: child}},
+				TargetGroupId: group,
+			},
+		},
+		{
+			name: "group client role mapping",
+			path: "groups/" + group + "/role-mappings/clients/" + client,
+			want: &ResourceRef{
+				Kind: "groups/role-mappings/clients",
+				Segments: []ResourceSegment{
+					{Kind: "groups", Id: group}, {Kind: "role-mappings"}, {Kind: "clients", Id: client},
+				},
+				TargetClientId: client,
+				TargetGroupId:  group,
+			},
+		},
+		{
+			name: "client secret",
+			path: "clients/" + client + "/client-secret",
+			want: &ResourceRef{
+				Kind:           "clients/client-secret",
+				Segments:       []ResourceSegment{{Kind: "clients", Id: client}, {Kind: "client-secret"}},
+				TargetClientId: client,
+			},
+		},
+		{
+			name: "client role by name",
+			path: "clients/" + client + "/roles/manage-users",
+			want: &ResourceRef{
+				Kind:           "clients/roles",
+				Segments:       []ResourceSegment{{Kind: "clients", Id: client}, {Kind: "roles", Id: "manage-users"}},
+				TargetClientId: client,
+			},
+		},
+		{
+			name: "client protocol mapper",
+			path: "clients/" + client + "/protocol-mappers/models/" + id,
+			want: &ResourceRef{
+				Kind: "clients/protocol-mappers/models",
+				Segments: []ResourceSegment{
+					{Kind: "clients", Id: client}, {Kind: "protocol-mappers"}, {Kind: "models", Id: id},
+				},
+				TargetClientId: client,
+			},
+		},
+		{
+			name: "client authorization policy",
+			path: "clients/" + client + "/authz/resource-server/policy/" + id,
+			want: &ResourceRef{
+				Kind: "clients/authz/resource-server/policy",
+				Segments: []ResourceSegment{
+					{Kind: "clients", Id: client}, {Kind: "authz"}, {Kind: "resource-server"}, {Kind: "policy", Id: id},
+				},
+				TargetClientId: client,
+			},
+		},
+		{
+			name: "client scope mapper",
+			path: "client-scopes/" + id + "/protocol-mappers/models/" + child,
+			want: &ResourceRef{
+				Kind: "client-scopes/protocol-mappers/models",
+				Segments: []ResourceSegment{
+					{Kind: "client-scopes", Id: id}, {Kind: "protocol-mappers"}, {Kind: "models", Id: child},
+				},
+			},
+		},
+		{
+			name: "realm role composites",
+			path: "roles-by-id/" + id + "/composites/clients/" + client,
+			want: &ResourceRef{
+				Kind: "roles-by-id/composites/clients",
+				Segments: []ResourceSegment{
+					{Kind: "roles-by-id", Id: id}, {Kind: "composites"}, {Kind: "clients", Id: client},
+				},
+				TargetClientId: client,
+			},
+		},
+		{
+			name: "identity provider mapper",
+			path: "identity-provider/instances/google/mappers/" + id,
+			want: &ResourceRef{
+				Kind: "identity-provider/instances/mappers",
+				Segments: []ResourceSegment{
+					{Kind: "identity-provider"}, {Kind: "instances", Id: "google"}, {Kind: "mappers", Id: id},
+				},
+			},
+		},
+		{
+			name: "component",
+			path: "components/" + id,
+			want: &ResourceRef{Kind: "components", Segments: []ResourceSegment{{Kind: "components", Id: id}}},
+		},
+		{
+			name: "authentication flow execution",
+			path: "authentication/flows/browser/executions/execution",
+			want: &ResourceRef{
+				Kind: "authentication/flows/executions/execution",
+				Segments: []ResourceSegment{
+					{Kind: "authentication"}, {Kind: "flows", Id: "browser"}, {Kind: "executions"}, {Kind: "execution"},
+				},
+			},
+		},
+		{
+			name: "authentication execution config",
+			path: "authentication/executions/" + id + "/config",
+			want: &ResourceRef{
+				Kind:     "authentication/executions/config",
+				Segments: []ResourceSegment{{Kind: "authentication"}, {Kind: "executions", Id: id}, {Kind: "config"}},
+			},
+		},
+		{
+			name: "required action",
+			path: "authentication/required-actions/CONFIGURE_TOTP",
+			want: &ResourceRef{
+				Kind:     "authentication/required-actions",
+				Segments: []ResourceSegment{{Kind: "authentication"}, {Kind: "required-actions", Id: "CONFIGURE_TOTP"}},
+			},
+		},
+		{
+			name: "default group",
+			path: "default-groups/" + group,
+			want: &ResourceRef{
+				Kind:          "default-groups",
+				Segments:      []ResourceSegment{{Kind: "default-groups", Id: group}},
+				TargetGroupId: group,
+			},
+		},
+		{
+			name: "organization member",
+			path: "organizations/" + id + "/members/" + user,
+			want: &ResourceRef{
+				Kind:         "organizations/members",
+				Segments:     []ResourceSegment{{Kind: "organizations", Id: id}, {Kind: "members", Id: user}},
+				TargetUserId: user,
+			},
+		},
+		{
+			name: "brute force lockout",
+			path: "attack-detection/brute-force/users/" + user,
+			want: &ResourceRef{
+				Kind: "attack-detection/brute-force/users",
+				Segments: []ResourceSegment{
+					{Kind: "attack-detection"}, {Kind: "brute-force"}, {Kind: "users", Id: user},
+				},
+				TargetUserId: user,
+			},
+		},
+		{
+			name: "unknown shape with uuid",
+			path: "workflows/" + id + "/steps",
+			want: &ResourceRef{
+				Kind:     "workflows/steps",
+				Segments: []ResourceSegment{{Kind: "workflows", Id: id}, {Kind: "steps"}},
+			},
+		},
+		{
+			name: "unknown segment under known shape",
+			path: "users/" + user + "/profile",
+			want: &ResourceRef{
+				Kind:         "users/profile",
+				Segments:     []ResourceSegment{{Kind: "users", Id: user}, {Kind: "profile"}},
+				TargetUserId: user,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, ParseResourcePath(tt.path))
+		})
+	}
+}
+
+func TestResourceOf(t *testing.T) {
+	t.Parallel()
+
+	event := &AdminEvent{ResourcePath: "clients/1"}
+	assert.Equal(t, &ResourceRef{
+		Kind:           "clients",
+		Segments:       []ResourceSegment{{Kind: "clients", Id: "1"}},
+		TargetClientId: "1",
+	}, ResourceOf(event))
+
+	ref := &ResourceRef{Kind: "users"}
+	event.Enrichment = &Enrichment{Resource: ref}
+	assert.Same(t, ref, ResourceOf(event))
+
+	assert.Nil(t, ResourceOf(&AdminEvent{}))
+}
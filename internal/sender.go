@@ -1,11 +1,72 @@
 package internal
 
-import "go.uber.org/zap"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrUnavailable отмечает ошибки недоступности приемника. Только они размыкают цепь автомата очереди:
+// ошибка конвейера доставки или отклонение отдельного события означают, что приемник доступен.
+var ErrUnavailable = errors.New("sink is unavailable")
+
+// Unavailable отмечает err как ошибку недоступности приемника
+func Unavailable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
+
+// ErrRejected отмечает окончательный отказ приемника принять событие. Такое событие не возвращается
+// в очередь: повторная отправка завершится тем же отказом.
+var ErrRejected = errors.New("event is rejected by sink")
+
+// Rejected отмечает err как окончательный отказ приемника принять событие
+func Rejected(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrRejected, err)
+}
+
+// UnavailableGRPC сообщает, что ошибка gRPC вызова вызвана недоступностью или перегрузкой сервера,
+// а не содержимым запроса
+func UnavailableGRPC(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return false
+	}
+
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnavailableStatus сообщает, что HTTP статус означает недоступность или перегрузку приемника,
+// а не ошибку в содержимом запроса
+func UnavailableStatus(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+}
 
 type EventSender[T Event | AdminEvent] interface {
 	Send(event *T) error
 }
 
+// BatchSender отправляет пачку событий целиком: при ошибке ни одно событие пачки не считается доставленным
+type BatchSender[T Event | AdminEvent] interface {
+	SendBatch(events []*T) error
+}
+
 type Dummy[T Event | AdminEvent] struct {
 	logger *zap.Logger
 }
@@ -0,0 +1,41 @@
+package amqp
+
+import (
+	"context"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+type channel interface {
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) error
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp091.Confirmation) chan amqp091.Confirmation
+	NotifyReturn(returns chan amqp091.Return) chan amqp091.Return
+	IsClosed() bool
+	Close() error
+}
+
+type connection interface {
+	Channel() (channel, error)
+	IsClosed() bool
+	Close() error
+}
+
+type dialFunc func(url string) (connection, error)
+
+type amqpConnection struct {
+	*amqp091.Connection
+}
+
+func (c amqpConnection) Channel() (channel, error) {
+	return c.Connection.Channel()
+}
+
+func dial(url string) (connection, error) {
+	conn, err := amqp091.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return amqpConnection{conn}, nil
+}
@@ -0,0 +1,267 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+	"keycloak-events-adapter/internal/sink/template"
+)
+
+var (
+	errChannelClosed = errors.New("channel closed")
+	// errReturned брокер вернул сообщение, которое не удалось маршрутизировать, сам брокер доступен
+	errReturned = errors.New("message returned")
+)
+
+type Options struct {
+	URL        string
+	Exchange   string
+	RoutingKey string
+	Mandatory  bool
+	Timeout    time.Duration
+	// CloudEvents режим CloudEvents, в бинарном режиме атрибуты передаются заголовками cloudEvents_*
+	CloudEvents cloudevents.Mode
+}
+
+// Sender публикует события в exchange RabbitMQ с подтверждениями издателя (publisher confirms).
+// Send возвращает nil только после ack брокера; nack и возврат mandatory-сообщения считаются ошибкой.
+// Публикации на канале выполняются последовательно, закрытый канал или соединение открываются заново.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	opts       Options
+	routingKey *template.Template
+	dial       dialFunc
+	logger     *zap.Logger
+
+	mu       sync.Mutex
+	conn     connection
+	ch       channel
+	confirms chan amqp091.Confirmation
+	returns  chan amqp091.Return
+	tag      uint64
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	return newSender[T](opts, dial, logger)
+}
+
+func newSender[T internal.Event | internal.AdminEvent](opts Options, dial dialFunc, logger *zap.Logger) (*Sender[T], error) {
+	if opts.URL == "" {
+		return nil, errors.New("url is empty")
+	}
+
+	routingKey, err := template.Parse(opts.RoutingKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid routing key: %w", err)
+	}
+
+	err = opts.CloudEvents.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	s := &Sender[T]{
+		opts:       opts,
+		routingKey: routingKey,
+		dial:       dial,
+		logger:     logger,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	encoded, err := cloudevents.Encode(event, s.opts.CloudEvents, cloudevents.BindingAMQP)
+	if err != nil {
+		return err
+	}
+
+	var headers amqp091.Table
+	if len(encoded.Headers) > 0 {
+		headers = amqp091.Table{}
+		for name, value := range encoded.Headers {
+			headers[name] = value
+		}
+	}
+
+	meta := internal.MetaOf(event)
+	key := s.routingKey.Render(template.Fields(event), escapeWord)
+	msg := amqp091.Publishing{
+		ContentType:  encoded.ContentType,
+		Headers:      headers,
+		DeliveryMode: amqp091.Persistent,
+		MessageId:    meta.Id.String(),
+		Timestamp:    meta.Time,
+		Type:         meta.Kind,
+		AppId:        "keycloak-events-adapter",
+		Body:         encoded.Data,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.publish(key, msg)
+	if errors.Is(err, errChannelClosed) {
+		s.logger.Warn("amqp channel closed, reopening", zap.Error(err))
+		err = s.publish(key, msg)
+	}
+	if err != nil && !errors.Is(err, errReturned) {
+		return internal.Unavailable(err)
+	}
+
+	return err
+}
+
+func (s *Sender[T]) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closeChannel()
+	if s.conn != nil && !s.conn.IsClosed() {
+		return s.conn.Close()
+	}
+
+	return nil
+}
+
+// open вызывается под s.mu
+func (s *Sender[T]) open() error {
+	if s.conn == nil || s.conn.IsClosed() {
+		conn, err := s.dial(s.opts.URL)
+		if err != nil {
+			return fmt.Errorf("can't connect amqp: %w", err)
+		}
+		s.conn = conn
+	}
+
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("can't open channel: %w", err)
+	}
+
+	err = ch.Confirm(false)
+	if err != nil {
+		_ = ch.Close()
+		return fmt.Errorf("can't enable publisher confirms: %w", err)
+	}
+
+	s.ch = ch
+	s.tag = 0
+	s.confirms = ch.NotifyPublish(make(chan amqp091.Confirmation, 16))
+	s.returns = ch.NotifyReturn(make(chan amqp091.Return, 16))
+
+	return nil
+}
+
+// closeChannel вызывается под s.mu
+func (s *Sender[T]) closeChannel() {
+	if s.ch != nil && !s.ch.IsClosed() {
+		_ = s.ch.Close()
+	}
+	s.ch = nil
+}
+
+// publish вызывается под s.mu
+func (s *Sender[T]) publish(key string, msg amqp091.Publishing) error {
+	if s.ch == nil || s.ch.IsClosed() {
+		s.closeChannel()
+		err := s.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	// возвраты от публикаций, не дождавшихся подтверждения, к текущей публикации не относятся
+	for len(s.returns) > 0 {
+		<-s.returns
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	err := s.ch.PublishWithContext(ctx, s.opts.Exchange, key, s.opts.Mandatory, false, msg)
+	if err != nil {
+		s.closeChannel()
+		if errors.Is(err, amqp091.ErrClosed) {
+			return fmt.Errorf("%w: %w", errChannelClosed, err)
+		}
+		return fmt.Errorf("can't publish to %s: %w", key, err)
+	}
+	s.tag++
+
+	var returned *amqp091.Return
+	returns := s.returns
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			if ret.MessageId == msg.MessageId {
+				returned = &ret
+			}
+		case confirm, ok := <-s.confirms:
+			if !ok {
+				s.closeChannel()
+				return fmt.Errorf("can't get confirmation for %s: %w", key, errChannelClosed)
+			}
+			if confirm.DeliveryTag < s.tag {
+				continue
+			}
+			if !confirm.Ack {
+				return fmt.Errorf("broker rejected message for %s", key)
+			}
+
+			// basic.return приходит до basic.ack той же публикации
+			select {
+			case ret, ok := <-returns:
+				if ok && ret.MessageId == msg.MessageId {
+					returned = &ret
+				}
+			default:
+			}
+			if returned != nil {
+				return fmt.Errorf("%w for %s: %d %s", errReturned, key, returned.ReplyCode, returned.ReplyText)
+			}
+
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("confirmation timeout for %s: %w", key, ctx.Err())
+		}
+	}
+}
+
+// escapeWord приводит значение к слову ключа маршрутизации
+func escapeWord(value string) string {
+	if value == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ' ', '\t', '\r', '\n':
+			return '_'
+		default:
+			return r
+		}
+	}, value)
+}
@@ -0,0 +1,172 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"keycloak-events-adapter/internal"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	ContentTypeJSON       = "application/json"
+	ContentTypeStructured = "application/cloudevents+json"
+)
+
+type Mode string
+
+const (
+	ModeNone       Mode = "none"
+	ModeStructured Mode = "structured"
+	ModeBinary     Mode = "binary"
+)
+
+// Validate проверяет режим, пустой режим означает ModeNone
+func (m Mode) Validate() error {
+	switch m {
+	case "", ModeNone, ModeStructured, ModeBinary:
+		return nil
+	default:
+		return fmt.Errorf("unknown cloudevents mode: %s", m)
+	}
+}
+
+// Binding описывает, как атрибуты передаются в заголовках в бинарном режиме
+type Binding struct {
+	// Prefix префикс имен заголовков атрибутов
+	Prefix string
+	// ContentTypeHeader заголовок типа содержимого, пустой — тип передается свойством сообщения протокола
+	ContentTypeHeader string
+}
+
+// Привязки протоколов приемников с заголовками сообщений. Приемникам без заголовков
+// у отдельного события доступен только структурированный режим с пустой привязкой.
+// AMQP использует префикс cloudEvents_ привязки AMQP 1.0, HTTP и Kafka привязки вне области поддержки.
+var (
+	BindingNATS = Binding{Prefix: "ce-", ContentTypeHeader: "content-type"}
+	BindingAMQP = Binding{Prefix: "cloudEvents_"}
+	BindingMQTT = Binding{}
+)
+
+// Event CloudEvent 1.0 с событием Keycloak в data
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// New создает CloudEvent: source — keycloak/<realm_name>, type — org.keycloak.event.<event_type>
+// или org.keycloak.admin.<resource_type>.<operation_type>, subject — пользователь или путь ресурса
+func New[T internal.Event | internal.AdminEvent](event *T) (*Event, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal event: %w", err)
+	}
+
+	meta := internal.MetaOf(event)
+
+	realm := meta.RealmName
+	if realm == "" {
+		realm = meta.RealmId.String()
+	}
+
+	ce := &Event{
+		SpecVersion:     SpecVersion,
+		Id:              meta.Id.String(),
+		Source:          "keycloak/" + realm,
+		DataContentType: ContentTypeJSON,
+		Data:            data,
+	}
+	if !meta.Time.IsZero() {
+		ce.Time = meta.Time.UTC().Format(time.RFC3339Nano)
+	}
+
+	switch e := any(event).(type) {
+	case *internal.Event:
+		ce.Type = "org.keycloak.event." + e.TypeName()
+		if e.UserId != uuid.Nil {
+			ce.Subject = e.UserId.String()
+		}
+	case *internal.AdminEvent:
+		ce.Type = "org.keycloak.admin." + e.ResourceType + "." + e.OperationType.String()
+		ce.Subject = e.ResourcePath
+	}
+
+	return ce, nil
+}
+
+// attributes возвращает атрибуты контекста без data и datacontenttype
+func (e *Event) attributes() map[string]string {
+	attrs := map[string]string{
+		"specversion": e.SpecVersion,
+		"id":          e.Id,
+		"source":      e.Source,
+		"type":        e.Type,
+	}
+	if e.Subject != "" {
+		attrs["subject"] = e.Subject
+	}
+	if e.Time != "" {
+		attrs["time"] = e.Time
+	}
+
+	return attrs
+}
+
+// Message полезная нагрузка и заголовки сообщения
+type Message struct {
+	Data        []byte
+	ContentType string
+	Headers     map[string]string
+}
+
+// Encode кодирует событие в сообщение для указанного режима и привязки протокола.
+// Без CloudEvents данные — событие в JSON, в структурированном режиме — CloudEvent в JSON,
+// в бинарном режиме данные — событие в JSON, а атрибуты передаются заголовками.
+func Encode[T internal.Event | internal.AdminEvent](event *T, mode Mode, binding Binding) (*Message, error) {
+	if mode == "" || mode == ModeNone {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal event: %w", err)
+		}
+
+		return &Message{Data: data, ContentType: ContentTypeJSON}, nil
+	}
+
+	ce, err := New(event)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{Headers: map[string]string{}}
+	switch mode {
+	case ModeStructured:
+		msg.Data, err = json.Marshal(ce)
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal cloudevent: %w", err)
+		}
+		msg.ContentType = ContentTypeStructured
+	case ModeBinary:
+		msg.Data = ce.Data
+		msg.ContentType = ce.DataContentType
+		for name, value := range ce.attributes() {
+			msg.Headers[binding.Prefix+name] = value
+		}
+	default:
+		return nil, fmt.Errorf("unknown cloudevents mode: %s", mode)
+	}
+
+	if binding.ContentTypeHeader != "" {
+		msg.Headers[binding.ContentTypeHeader] = msg.ContentType
+	}
+
+	return msg, nil
+}
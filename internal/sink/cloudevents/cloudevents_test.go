@@ -0,0 +1,161 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"keycloak-events-adapter/internal"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	userId := uuid.New()
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Time:      time.Date(2024, 1, 15, 10, 30, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		Type:      internal.EventTypeLogin,
+		RealmName: "master",
+		UserId:    userId,
+	}
+
+	ce, err := New(event)
+	require.NoError(t, err)
+	assert.Equal(t, SpecVersion, ce.SpecVersion)
+	assert.Equal(t, event.Id.String(), ce.Id)
+	assert.Equal(t, "keycloak/master", ce.Source)
+	assert.Equal(t, "org.keycloak.event.LOGIN", ce.Type)
+	assert.Equal(t, userId.String(), ce.Subject)
+	assert.Equal(t, "2024-01-15T07:30:00Z", ce.Time)
+
+	var data internal.Event
+	require.NoError(t, json.Unmarshal(ce.Data, &data))
+	assert.Equal(t, event.Id, data.Id)
+}
+
+func TestNew_AdminEvent(t *testing.T) {
+	t.Parallel()
+
+	realmId := uuid.New()
+	ce, err := New(&internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmId:       realmId,
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		ResourcePath:  "users/1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "keycloak/"+realmId.String(), ce.Source)
+	assert.Equal(t, "org.keycloak.admin.USER.CREATE", ce.Type)
+	assert.Equal(t, "users/1", ce.Subject)
+	assert.Empty(t, ce.Time)
+}
+
+func TestNew_EventTypeName(t *testing.T) {
+	t.Parallel()
+
+	// тип события берется по имени, в том числе для типов, которых нет в каталоге
+	ce, err := New(&internal.Event{Id: uuid.New(), RawType: internal.AlertEventType})
+	require.NoError(t, err)
+	assert.Equal(t, "org.keycloak.event."+internal.AlertEventType, ce.Type)
+
+	ce, err = New(&internal.AdminEvent{Id: uuid.New(), ResourceType: "CLIENT", OperationType: internal.OperationTypeAction})
+	require.NoError(t, err)
+	assert.Equal(t, "org.keycloak.admin.CLIENT.ACTION", ce.Type)
+}
+
+func TestEncode(t *testing.T) {
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Time:      time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Type:      internal.EventTypeLogin,
+		RealmName: "master",
+	}
+
+	tests := []struct {
+		name            string
+		mode            Mode
+		binding         Binding
+		wantContentType string
+		wantHeaders     map[string]string
+	}{
+		{
+			name:            "none",
+			mode:            ModeNone,
+			binding:         BindingNATS,
+			wantContentType: ContentTypeJSON,
+		},
+		{
+			name:            "structured nats",
+			mode:            ModeStructured,
+			binding:         BindingNATS,
+			wantContentType: ContentTypeStructured,
+			wantHeaders:     map[string]string{"content-type": ContentTypeStructured},
+		},
+		{
+			name:            "binary nats",
+			mode:            ModeBinary,
+			binding:         BindingNATS,
+			wantContentType: ContentTypeJSON,
+			wantHeaders: map[string]string{
+				"content-type":   ContentTypeJSON,
+				"ce-specversion": SpecVersion,
+				"ce-id":          event.Id.String(),
+				"ce-source":      "keycloak/master",
+				"ce-type":        "org.keycloak.event.LOGIN",
+				"ce-time":        "2024-01-15T10:30:00Z",
+			},
+		},
+		{
+			name:            "binary amqp",
+			mode:            ModeBinary,
+			binding:         BindingAMQP,
+			wantContentType: ContentTypeJSON,
+			wantHeaders: map[string]string{
+				"cloudEvents_specversion": SpecVersion,
+				"cloudEvents_id":          event.Id.String(),
+				"cloudEvents_source":      "keycloak/master",
+				"cloudEvents_type":        "org.keycloak.event.LOGIN",
+				"cloudEvents_time":        "2024-01-15T10:30:00Z",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg, err := Encode(event, tt.mode, tt.binding)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContentType, msg.ContentType)
+			if tt.wantHeaders == nil {
+				assert.Empty(t, msg.Headers)
+			} else {
+				assert.Equal(t, tt.wantHeaders, msg.Headers)
+			}
+
+			if tt.mode == ModeStructured {
+				var ce Event
+				require.NoError(t, json.Unmarshal(msg.Data, &ce))
+				assert.Equal(t, event.Id.String(), ce.Id)
+				assert.Equal(t, ContentTypeJSON, ce.DataContentType)
+				return
+			}
+
+			var data internal.Event
+			require.NoError(t, json.Unmarshal(msg.Data, &data))
+			assert.Equal(t, event.Id, data.Id)
+		})
+	}
+}
+
+func TestEncode_UnknownMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := Encode(&internal.Event{Id: uuid.New()}, "batch", BindingNATS)
+	assert.Error(t, err)
+	assert.Error(t, Mode("batch").Validate())
+}
@@ -0,0 +1,52 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	writer      *Writer
+	cloudEvents cloudevents.Mode
+}
+
+// NewSender создает отправителя в JSONL файлы. CloudEvents поддерживаются только в структурированном режиме,
+// так как у строки файла нет заголовков.
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	err := opts.CloudEvents.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if opts.CloudEvents == cloudevents.ModeBinary {
+		return nil, errors.New("cloudevents binary mode is not supported by file sink")
+	}
+
+	writer, err := NewWriter(opts, logger.With(zap.String("file", opts.Name)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sender[T]{writer: writer, cloudEvents: opts.CloudEvents}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	msg, err := cloudevents.Encode(event, s.cloudEvents, cloudevents.Binding{})
+	if err != nil {
+		return err
+	}
+
+	err = s.writer.Write(append(msg.Data, '\n'))
+	if err != nil {
+		return internal.Unavailable(fmt.Errorf("can't write event: %w", err))
+	}
+
+	return nil
+}
+
+func (s *Sender[T]) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,337 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+func newTestEvent() *internal.Event {
+	return &internal.Event{
+		Id:        uuid.New(),
+		Time:      time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Type:      internal.EventTypeLogin,
+		RealmId:   uuid.New(),
+		RealmName: "test",
+		ClientId:  "client id",
+		UserId:    uuid.New(),
+		SessionId: "session id",
+		IpAddress: "127.0.0.1",
+		Details:   map[string]string{"key": "value"},
+	}
+}
+
+func readLines(t *testing.T, r io.Reader) []string {
+	t.Helper()
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+
+	return lines
+}
+
+func rotatedFiles(t *testing.T, dir, suffix string) []string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "events-*"+suffix))
+	require.NoError(t, err)
+
+	return matches
+}
+
+func TestSender_Send(t *testing.T) {
+	tests := []struct {
+		name       string
+		durability Durability
+	}{
+		{name: "durability none", durability: DurabilityNone},
+		{name: "durability always", durability: DurabilityAlways},
+		{name: "durability interval", durability: DurabilityInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			sender, err := NewSender[internal.Event](Options{
+				Dir:          dir,
+				Name:         "events",
+				Durability:   tt.durability,
+				SyncInterval: 10 * time.Millisecond,
+			}, zap.NewNop())
+			require.NoError(t, err)
+
+			events := make([]*internal.Event, 10)
+			wg := sync.WaitGroup{}
+			for i := range events {
+				events[i] = newTestEvent()
+				wg.Add(1)
+				go func(event *internal.Event) {
+					defer wg.Done()
+					assert.NoError(t, sender.Send(event))
+				}(events[i])
+			}
+			wg.Wait()
+			require.NoError(t, sender.Close())
+
+			f, err := os.Open(filepath.Join(dir, "events.jsonl"))
+			require.NoError(t, err)
+			defer f.Close()
+
+			lines := readLines(t, f)
+			require.Len(t, lines, len(events))
+
+			got := map[uuid.UUID]bool{}
+			for _, line := range lines {
+				var event internal.Event
+				require.NoError(t, json.Unmarshal([]byte(line), &event))
+				assert.Equal(t, "test", event.RealmName)
+				assert.Equal(t, internal.EventTypeLogin, event.Type)
+				got[event.Id] = true
+			}
+			for _, event := range events {
+				assert.True(t, got[event.Id])
+			}
+		})
+	}
+}
+
+func TestSender_SendAdmin(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sender, err := NewSender[internal.AdminEvent](Options{Dir: dir, Name: "admin_events"}, zap.NewNop())
+	require.NoError(t, err)
+
+	adminEvent := &internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmId:       uuid.New(),
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		ResourcePath:  "users/1",
+		AuthDetails:   &internal.AuthDetails{IpAddress: "10.0.0.1"},
+	}
+	require.NoError(t, sender.Send(adminEvent))
+	require.NoError(t, sender.Close())
+	assert.Error(t, sender.Send(adminEvent))
+
+	data, err := os.ReadFile(filepath.Join(dir, "admin_events.jsonl"))
+	require.NoError(t, err)
+
+	var got internal.AdminEvent
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, adminEvent.Id, got.Id)
+	assert.Equal(t, "10.0.0.1", got.AuthDetails.IpAddress)
+	assert.Equal(t, internal.OperationTypeCreate, got.OperationType)
+}
+
+func TestSender_SendCloudEvents(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sender, err := NewSender[internal.Event](Options{
+		Dir:         dir,
+		Name:        "events",
+		CloudEvents: cloudevents.ModeStructured,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	event := newTestEvent()
+	require.NoError(t, sender.Send(event))
+	require.NoError(t, sender.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, "events.jsonl"))
+	require.NoError(t, err)
+
+	var got cloudevents.Event
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, event.Id.String(), got.Id)
+	assert.Equal(t, "keycloak/test", got.Source)
+
+	_, err = NewSender[internal.Event](Options{Dir: dir, Name: "binary", CloudEvents: cloudevents.ModeBinary}, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestWriter_Rotate(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression Compression
+		suffix      string
+		open        func(r io.Reader) (io.Reader, error)
+	}{
+		{
+			name:        "no compression",
+			compression: CompressionNone,
+			suffix:      ".jsonl",
+			open:        func(r io.Reader) (io.Reader, error) { return r, nil },
+		},
+		{
+			name:        "gzip",
+			compression: CompressionGzip,
+			suffix:      ".jsonl.gz",
+			open:        func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			name:        "zstd",
+			compression: CompressionZstd,
+			suffix:      ".jsonl.zst",
+			open: func(r io.Reader) (io.Reader, error) {
+				return zstd.NewReader(r)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			w, err := NewWriter(Options{
+				Dir:         dir,
+				Name:        "events",
+				MaxSize:     10,
+				Compression: tt.compression,
+			}, zap.NewNop())
+			require.NoError(t, err)
+
+			require.NoError(t, w.Write([]byte("first line\n")))
+			require.NoError(t, w.Write([]byte("second line\n")))
+			require.NoError(t, w.Close())
+
+			files := rotatedFiles(t, dir, tt.suffix)
+			require.Len(t, files, 1)
+
+			f, err := os.Open(files[0])
+			require.NoError(t, err)
+			defer f.Close()
+
+			r, err := tt.open(f)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"first line"}, readLines(t, r))
+
+			active, err := os.ReadFile(filepath.Join(dir, "events.jsonl"))
+			require.NoError(t, err)
+			assert.Equal(t, "second line\n", string(active))
+		})
+	}
+}
+
+func TestWriter_RotateInterval(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w, err := NewWriter(Options{
+		Dir:            dir,
+		Name:           "events",
+		RotateInterval: 50 * time.Millisecond,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, w.Write([]byte("line\n")))
+
+	assert.Eventually(t, func() bool {
+		return len(rotatedFiles(t, dir, ".jsonl")) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestWriter_Prune(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	w, err := NewWriter(Options{
+		Dir:          dir,
+		Name:         "events",
+		MaxSize:      1,
+		MaxTotalSize: 20,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.Write([]byte("line "+strings.Repeat("x", i)+"\n")))
+	}
+	require.NoError(t, w.Close())
+
+	files := rotatedFiles(t, dir, ".jsonl")
+	require.Len(t, files, 2)
+
+	var contents []string
+	for _, path := range files {
+		data, errR := os.ReadFile(path)
+		require.NoError(t, errR)
+		contents = append(contents, string(data))
+	}
+	assert.Equal(t, []string{"line xx\n", "line xxx\n"}, contents)
+}
+
+func TestWriter_PruneKeepsOtherWriters(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	other := []string{
+		"events-admin-20240115T103000.000000000.jsonl",
+		"events-admin-20240115T103000.000000000.jsonl.zst",
+		"events-admin.jsonl",
+		"events-backup.jsonl",
+	}
+	for _, name := range other {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", 100)), 0o600))
+	}
+
+	w, err := NewWriter(Options{
+		Dir:          dir,
+		Name:         "events",
+		MaxSize:      1,
+		MaxTotalSize: 20,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.Write([]byte("line\n")))
+	}
+	require.NoError(t, w.Close())
+
+	// файлы писателя events-admin начинаются с того же префикса, но не удаляются
+	for _, name := range other {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+}
+
+func TestNewWriter_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty dir", opts: Options{Name: "events"}},
+		{name: "empty name", opts: Options{Dir: "dir"}},
+		{name: "unknown compression", opts: Options{Dir: "dir", Name: "events", Compression: "lz4"}},
+		{name: "unknown durability", opts: Options{Dir: "dir", Name: "events", Durability: "sometimes"}},
+		{name: "interval without period", opts: Options{Dir: "dir", Name: "events", Durability: DurabilityInterval}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewWriter(tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,557 @@
+package file
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+type Durability string
+
+const (
+	// DurabilityNone подтверждает запись сразу после write(2), данные могут остаться в page cache.
+	DurabilityNone Durability = "none"
+	// DurabilityInterval группирует fsync: Write ждет ближайшей периодической синхронизации.
+	DurabilityInterval Durability = "interval"
+	// DurabilityAlways выполняет fsync на каждую запись.
+	DurabilityAlways Durability = "always"
+)
+
+const (
+	activeSuffix    = ".jsonl"
+	rotatedTimeFmt  = "20060102T150405.000000000"
+	maintenanceTick = time.Second
+)
+
+type Options struct {
+	Dir            string
+	Name           string
+	MaxSize        int64
+	RotateInterval time.Duration
+	Compression    Compression
+	MaxAge         time.Duration
+	MaxTotalSize   int64
+	Durability     Durability
+	SyncInterval   time.Duration
+	// CloudEvents режим CloudEvents для строк, используется Sender
+	CloudEvents cloudevents.Mode
+}
+
+func (o *Options) validate() error {
+	if o.Dir == "" {
+		return errors.New("directory is empty")
+	}
+	if o.Name == "" {
+		return errors.New("file name is empty")
+	}
+
+	switch o.Compression {
+	case "":
+		o.Compression = CompressionNone
+	case CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return fmt.Errorf("unknown compression: %s", o.Compression)
+	}
+
+	switch o.Durability {
+	case "":
+		o.Durability = DurabilityAlways
+	case DurabilityNone, DurabilityAlways:
+	case DurabilityInterval:
+		if o.SyncInterval <= 0 {
+			return errors.New("sync interval must be positive for interval durability")
+		}
+	default:
+		return fmt.Errorf("unknown durability: %s", o.Durability)
+	}
+
+	return nil
+}
+
+// Writer дописывает строки в активный файл, ротирует его по размеру и времени,
+// сжимает ротированные файлы и удаляет старые по возрасту и суммарному размеру.
+type Writer struct {
+	opts   Options
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	synced   *sync.Cond
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+
+	writeGen  uint64
+	syncedGen uint64
+	errGen    uint64
+	syncErr   error
+
+	rotated chan string
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewWriter(opts Options, logger *zap.Logger) (*Writer, error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	err = os.MkdirAll(opts.Dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("can't create directory: %w", err)
+	}
+
+	w := &Writer{
+		opts:    opts,
+		logger:  logger,
+		rotated: make(chan string, 16),
+		done:    make(chan struct{}),
+	}
+	w.synced = sync.NewCond(&w.mu)
+
+	err = w.open()
+	if err != nil {
+		return nil, err
+	}
+
+	leftovers, err := w.uncompressedRotated()
+	if err != nil {
+		_ = w.file.Close()
+		return nil, err
+	}
+
+	w.wg.Add(2)
+	go w.compressLoop(leftovers)
+	go w.maintenanceLoop()
+
+	return w, nil
+}
+
+// Write дописывает строку и возвращается, когда она сохранена согласно режиму durability.
+func (w *Writer) Write(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return errors.New("writer is closed")
+	}
+
+	if w.needRotate(int64(len(line))) {
+		err := w.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("can't write to file: %w", err)
+	}
+
+	w.writeGen++
+	gen := w.writeGen
+
+	switch w.opts.Durability {
+	case DurabilityAlways:
+		return w.sync()
+	case DurabilityInterval:
+		for w.syncedGen < gen && !w.closed {
+			w.synced.Wait()
+		}
+		if w.syncedGen < gen {
+			return errors.New("writer closed before sync")
+		}
+		if w.syncErr != nil && gen <= w.errGen {
+			return fmt.Errorf("can't sync file: %w", w.syncErr)
+		}
+	case DurabilityNone:
+	}
+
+	return nil
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	err := w.sync()
+	if errC := w.file.Close(); errC != nil && err == nil {
+		err = errC
+	}
+	w.closed = true
+	w.synced.Broadcast()
+	w.mu.Unlock()
+
+	close(w.done)
+	w.wg.Wait()
+
+	return err
+}
+
+func (w *Writer) activePath() string {
+	return filepath.Join(w.opts.Dir, w.opts.Name+activeSuffix)
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.activePath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("can't open file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("can't stat file: %w", err)
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.openedAt = time.Now()
+
+	return nil
+}
+
+func (w *Writer) needRotate(incoming int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+incoming > w.opts.MaxSize {
+		return true
+	}
+
+	return w.opts.RotateInterval > 0 && time.Since(w.openedAt) >= w.opts.RotateInterval
+}
+
+// sync вызывается под w.mu.
+func (w *Writer) sync() error {
+	target := w.writeGen
+	if w.syncedGen >= target {
+		return nil
+	}
+
+	err := w.file.Sync()
+	w.syncedGen = target
+	if err != nil {
+		w.syncErr = err
+		w.errGen = target
+	}
+	w.synced.Broadcast()
+
+	if err != nil {
+		return fmt.Errorf("can't sync file: %w", err)
+	}
+
+	return nil
+}
+
+// rotate вызывается под w.mu.
+func (w *Writer) rotate() error {
+	err := w.sync()
+	if err != nil {
+		return err
+	}
+
+	err = w.file.Close()
+	if err != nil {
+		return fmt.Errorf("can't close file: %w", err)
+	}
+
+	rotatedPath := filepath.Join(
+		w.opts.Dir,
+		w.opts.Name+"-"+time.Now().UTC().Format(rotatedTimeFmt)+activeSuffix,
+	)
+	err = os.Rename(w.activePath(), rotatedPath)
+	if err != nil {
+		return fmt.Errorf("can't rename file: %w", err)
+	}
+
+	err = w.open()
+	if err != nil {
+		return err
+	}
+
+	w.logger.Info("file rotated", zap.String("path", rotatedPath))
+
+	select {
+	case w.rotated <- rotatedPath:
+	default:
+		w.logger.Warn("compression queue is full, file left uncompressed", zap.String("path", rotatedPath))
+	}
+
+	return nil
+}
+
+func (w *Writer) maintenanceLoop() {
+	defer w.wg.Done()
+
+	tick := maintenanceTick
+	if w.opts.Durability == DurabilityInterval {
+		tick = w.opts.SyncInterval
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			return
+		}
+		if w.opts.Durability == DurabilityInterval {
+			err := w.sync()
+			if err != nil {
+				w.logger.Error("can't sync file", zap.Error(err))
+			}
+		}
+		if w.needRotate(0) {
+			err := w.rotate()
+			if err != nil {
+				w.logger.Error("can't rotate file", zap.Error(err))
+			}
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *Writer) compressLoop(pending []string) {
+	defer w.wg.Done()
+
+	for _, path := range pending {
+		w.compressAndPrune(path)
+	}
+
+	for {
+		select {
+		case path := <-w.rotated:
+			w.compressAndPrune(path)
+		case <-w.done:
+			for {
+				select {
+				case path := <-w.rotated:
+					w.compressAndPrune(path)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Writer) compressAndPrune(path string) {
+	err := w.compress(path)
+	if err != nil {
+		w.logger.Error("can't compress rotated file", zap.String("path", path), zap.Error(err))
+	}
+
+	err = w.prune()
+	if err != nil {
+		w.logger.Error("can't prune rotated files", zap.Error(err))
+	}
+}
+
+func (w *Writer) compress(path string) (err error) {
+	var ext string
+	switch w.opts.Compression {
+	case CompressionGzip:
+		ext = ".gz"
+	case CompressionZstd:
+		ext = ".zst"
+	default:
+		return nil
+	}
+
+	src, err := os.Open(path) //nolint:gosec // путь формируется самим writer
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't open file: %w", err)
+	}
+	defer src.Close()
+
+	tmpPath := path + ext + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640) //nolint:gosec // путь формируется самим writer
+	if err != nil {
+		return fmt.Errorf("can't create file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	var enc io.WriteCloser
+	if w.opts.Compression == CompressionGzip {
+		enc = gzip.NewWriter(dst)
+	} else {
+		enc, err = zstd.NewWriter(dst)
+		if err != nil {
+			return fmt.Errorf("can't create zstd encoder: %w", err)
+		}
+	}
+
+	_, err = io.Copy(enc, src)
+	if err != nil {
+		_ = enc.Close()
+		return fmt.Errorf("can't compress file: %w", err)
+	}
+	err = enc.Close()
+	if err != nil {
+		return fmt.Errorf("can't finish compression: %w", err)
+	}
+	err = dst.Sync()
+	if err != nil {
+		return fmt.Errorf("can't sync compressed file: %w", err)
+	}
+	err = dst.Close()
+	if err != nil {
+		return fmt.Errorf("can't close compressed file: %w", err)
+	}
+
+	err = os.Rename(tmpPath, path+ext)
+	if err != nil {
+		return fmt.Errorf("can't rename compressed file: %w", err)
+	}
+
+	return os.Remove(path)
+}
+
+type rotatedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (w *Writer) listRotated() ([]rotatedFile, error) {
+	entries, err := os.ReadDir(w.opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("can't read directory: %w", err)
+	}
+
+	files := make([]rotatedFile, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !w.isRotated(name) {
+			continue
+		}
+
+		info, errI := entry.Info()
+		if errI != nil {
+			continue
+		}
+
+		files = append(files, rotatedFile{
+			path:    filepath.Join(w.opts.Dir, name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+
+	return files, nil
+}
+
+// isRotated проверяет, что файл — ротированный файл этого писателя: <name>-<время ротации>.jsonl
+// со сжатием или без. Префикса недостаточно: файлы events-admin-* не относятся к писателю events.
+func (w *Writer) isRotated(name string) bool {
+	rest, ok := strings.CutPrefix(name, w.opts.Name+"-")
+	if !ok || len(rest) < len(rotatedTimeFmt) {
+		return false
+	}
+
+	_, err := time.Parse(rotatedTimeFmt, rest[:len(rotatedTimeFmt)])
+	if err != nil {
+		return false
+	}
+
+	switch rest[len(rotatedTimeFmt):] {
+	case activeSuffix, activeSuffix + ".gz", activeSuffix + ".zst":
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *Writer) uncompressedRotated() ([]string, error) {
+	files, err := w.listRotated()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, f := range files {
+		if strings.HasSuffix(f.path, activeSuffix) {
+			paths = append(paths, f.path)
+		}
+	}
+
+	return paths, nil
+}
+
+func (w *Writer) prune() error {
+	if w.opts.MaxAge <= 0 && w.opts.MaxTotalSize <= 0 {
+		return nil
+	}
+
+	files, err := w.listRotated()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+
+	// файлы отсортированы по времени ротации, начиная с самых старых
+	for _, f := range files {
+		expired := w.opts.MaxAge > 0 && time.Since(f.modTime) > w.opts.MaxAge
+		oversize := w.opts.MaxTotalSize > 0 && total > w.opts.MaxTotalSize
+		if !expired && !oversize {
+			continue
+		}
+
+		err = os.Remove(f.path)
+		if err != nil {
+			return fmt.Errorf("can't remove file: %w", err)
+		}
+		total -= f.size
+		w.logger.Info("rotated file removed", zap.String("path", f.path))
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package forward
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"keycloak-events-adapter/internal"
+	apigrpc "keycloak-events-adapter/internal/api/grpc"
+	eventv1 "keycloak-events-adapter/internal/specs/gen/keycloak/event/v1"
+)
+
+type Options struct {
+	// Target адрес вышестоящего адаптера host:port
+	Target           string
+	Insecure         bool
+	CAFile           string
+	CertFile         string
+	KeyFile          string
+	ServerName       string
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	Timeout          time.Duration
+}
+
+func (o *Options) validate() error {
+	if o.Target == "" {
+		return errors.New("target is empty")
+	}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return errors.New("cert file and key file must be set together")
+	}
+
+	if o.KeepaliveTime <= 0 {
+		o.KeepaliveTime = 30 * time.Second
+	}
+	if o.KeepaliveTimeout <= 0 {
+		o.KeepaliveTimeout = 10 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+
+	return nil
+}
+
+func (o *Options) credentials() (credentials.TransportCredentials, error) {
+	if o.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: o.ServerName}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read ca file: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates in ca file")
+		}
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// Sender пересылает события в EventAPI вышестоящего адаптера.
+// Ошибки доступности вышестоящего адаптера помечаются internal.ErrUnavailable,
+// окончательный отказ принять событие — internal.ErrRejected.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	conn   *grpc.ClientConn
+	client eventv1.EventAPIClient
+	opts   Options
+	logger *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	creds, err := opts.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(
+		opts.Target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                opts.KeepaliveTime,
+			Timeout:             opts.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't create grpc client: %w", err)
+	}
+
+	return &Sender[T]{
+		conn:   conn,
+		client: eventv1.NewEventAPIClient(conn),
+		opts:   opts,
+		logger: logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	err := s.forward(event)
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("can't forward event: %w", err)
+	if internal.UnavailableGRPC(err) {
+		return internal.Unavailable(err)
+	}
+	if rejected(err) {
+		return internal.Rejected(err)
+	}
+
+	return err
+}
+
+func (s *Sender[T]) forward(event *T) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	switch e := any(event).(type) {
+	case *internal.Event:
+		request, err := apigrpc.MapEventToCreateRequest(e)
+		if err != nil {
+			return errMapping{err}
+		}
+		_, err = s.client.Create(ctx, request)
+
+		return err
+	case *internal.AdminEvent:
+		request, err := apigrpc.MapAdminEventToCreateAdminRequest(e)
+		if err != nil {
+			return errMapping{err}
+		}
+		_, err = s.client.CreateAdmin(ctx, request)
+
+		return err
+	default:
+		return errMapping{fmt.Errorf("unsupported event type %T", event)}
+	}
+}
+
+func (s *Sender[T]) Close() error {
+	return s.conn.Close()
+}
+
+// errMapping ошибка преобразования события в запрос, повторная отправка ее не исправит
+type errMapping struct {
+	err error
+}
+
+func (e errMapping) Error() string {
+	return e.err.Error()
+}
+
+func (e errMapping) Unwrap() error {
+	return e.err
+}
+
+// rejected сообщает, что вышестоящий адаптер окончательно отказался принять событие
+// или событие не удалось преобразовать в запрос
+func rejected(err error) bool {
+	if errors.As(err, &errMapping{}) {
+		return true
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument, codes.OutOfRange, codes.AlreadyExists:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,180 @@
+package forward
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"keycloak-events-adapter/internal"
+	eventv1 "keycloak-events-adapter/internal/specs/gen/keycloak/event/v1"
+)
+
+type fakeUpstream struct {
+	eventv1.UnimplementedEventAPIServer
+
+	mu     sync.Mutex
+	events []*eventv1.CreateRequest
+	admins []*eventv1.CreateAdminRequest
+	calls  int
+	err    error
+}
+
+func (u *fakeUpstream) Create(_ context.Context, request *eventv1.CreateRequest) (*eventv1.CreateResponse, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.calls++
+	if u.err != nil {
+		return nil, u.err
+	}
+	u.events = append(u.events, request)
+
+	return &eventv1.CreateResponse{}, nil
+}
+
+func (u *fakeUpstream) CreateAdmin(_ context.Context, request *eventv1.CreateAdminRequest) (*eventv1.CreateAdminResponse, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.calls++
+	if u.err != nil {
+		return nil, u.err
+	}
+	u.admins = append(u.admins, request)
+
+	return &eventv1.CreateAdminResponse{}, nil
+}
+
+func newUpstream(t *testing.T, upstream *fakeUpstream) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpc_validator.UnaryServerInterceptor()))
+	eventv1.RegisterEventAPIServer(server, upstream)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func newTestSender[T internal.Event | internal.AdminEvent](t *testing.T, target string) *Sender[T] {
+	t.Helper()
+
+	sender, err := NewSender[T](Options{
+		Target:   target,
+		Insecure: true,
+		Timeout:  time.Second,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sender.Close()
+	})
+
+	return sender
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+
+	upstream := &fakeUpstream{}
+	sender := newTestSender[internal.Event](t, newUpstream(t, upstream))
+
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Time:      time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Type:      internal.EventTypeLogin,
+		RealmId:   uuid.New(),
+		RealmName: "master",
+		UserId:    uuid.New(),
+		IpAddress: "127.0.0.1",
+	}
+	require.NoError(t, sender.Send(event))
+
+	require.Len(t, upstream.events, 1)
+	assert.Equal(t, event.Id.String(), upstream.events[0].Id)
+	assert.Equal(t, eventv1.EventType_EVENT_TYPE_LOGIN, upstream.events[0].Type)
+	assert.Equal(t, event.Time, upstream.events[0].Time.AsTime())
+}
+
+func TestSender_SendAdmin(t *testing.T) {
+	t.Parallel()
+
+	upstream := &fakeUpstream{}
+	sender := newTestSender[internal.AdminEvent](t, newUpstream(t, upstream))
+
+	adminEvent := &internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmId:       uuid.New(),
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		AuthDetails:   &internal.AuthDetails{RealmId: uuid.New(), UserId: uuid.New(), IpAddress: "10.0.0.1"},
+	}
+	require.NoError(t, sender.Send(adminEvent))
+
+	require.Len(t, upstream.admins, 1)
+	assert.Equal(t, eventv1.OperationType_OPERATION_TYPE_CREATE, upstream.admins[0].OperationType)
+	assert.Equal(t, "10.0.0.1", upstream.admins[0].AuthDetails.IpAddress)
+}
+
+func TestSender_SendErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		wantUnavailable bool
+		wantRejected    bool
+	}{
+		{name: "unavailable upstream", err: status.Error(codes.Unavailable, "unavailable"), wantUnavailable: true},
+		{name: "overloaded upstream", err: status.Error(codes.ResourceExhausted, "exhausted"), wantUnavailable: true},
+		{name: "rejected event", err: status.Error(codes.InvalidArgument, "invalid"), wantRejected: true},
+		{name: "already forwarded event", err: status.Error(codes.AlreadyExists, "exists"), wantRejected: true},
+		{name: "unauthenticated", err: status.Error(codes.Unauthenticated, "unauthenticated")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			upstream := &fakeUpstream{err: tt.err}
+			sender := newTestSender[internal.AdminEvent](t, newUpstream(t, upstream))
+
+			err := sender.Send(&internal.AdminEvent{Id: uuid.New(), RealmId: uuid.New(), OperationType: internal.OperationTypeDelete})
+			require.Error(t, err)
+			assert.Equal(t, tt.wantUnavailable, errors.Is(err, internal.ErrUnavailable))
+			assert.Equal(t, tt.wantRejected, errors.Is(err, internal.ErrRejected))
+			assert.Equal(t, 1, upstream.calls)
+		})
+	}
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty target", opts: Options{Insecure: true}},
+		{name: "cert without key", opts: Options{Target: "localhost:9999", CertFile: "client.pem"}},
+		{name: "missing ca file", opts: Options{Target: "localhost:9999", CAFile: "missing.pem"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package loki
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// entry строка потока Loki
+type entry struct {
+	time time.Time
+	line string
+}
+
+// stream поток Loki с фиксированным набором меток
+type stream struct {
+	labels  map[string]string
+	entries []entry
+}
+
+// labelsString возвращает метки потока в формате {key="value", ...} с сортировкой по ключу
+func (s *stream) labelsString() string {
+	keys := make([]string, 0, len(s.labels))
+	for key := range s.labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, strconv.Quote(s.labels[key])))
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// marshalPushRequest кодирует logproto.PushRequest:
+//
+//	PushRequest   { repeated StreamAdapter streams = 1; }
+//	StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	EntryAdapter  { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func marshalPushRequest(streams []*stream) []byte {
+	var req []byte
+	for _, s := range streams {
+		var streamMsg []byte
+		streamMsg = protowire.AppendTag(streamMsg, 1, protowire.BytesType)
+		streamMsg = protowire.AppendString(streamMsg, s.labelsString())
+
+		for _, e := range s.entries {
+			var ts []byte
+			if seconds := e.time.Unix(); seconds != 0 {
+				ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+				ts = protowire.AppendVarint(ts, uint64(seconds))
+			}
+			if nanos := e.time.Nanosecond(); nanos != 0 {
+				ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+				ts = protowire.AppendVarint(ts, uint64(nanos))
+			}
+
+			var entryMsg []byte
+			entryMsg = protowire.AppendTag(entryMsg, 1, protowire.BytesType)
+			entryMsg = protowire.AppendBytes(entryMsg, ts)
+			entryMsg = protowire.AppendTag(entryMsg, 2, protowire.BytesType)
+			entryMsg = protowire.AppendString(entryMsg, e.line)
+
+			streamMsg = protowire.AppendTag(streamMsg, 2, protowire.BytesType)
+			streamMsg = protowire.AppendBytes(streamMsg, entryMsg)
+		}
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, streamMsg)
+	}
+
+	return req
+}
@@ -0,0 +1,226 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+	"keycloak-events-adapter/internal/sink/template"
+)
+
+const pushPath = "/loki/api/v1/push"
+
+// labelFields поля событий, которые становятся метками потока. Остальные поля,
+// в том числе пользователь, сессия и IP, остаются в теле строки, чтобы число потоков было ограничено.
+var labelFields = map[string]string{
+	template.FieldRealmName:     "realm",
+	template.FieldKind:          "kind",
+	template.FieldEventType:     "event_type",
+	template.FieldResourceType:  "resource_type",
+	template.FieldOperationType: "operation_type",
+	template.FieldOutcome:       "outcome",
+}
+
+// rejectionMarkers фрагменты ответов Loki об отклонении строк по времени, повтор которых бесполезен
+var rejectionMarkers = []string{
+	"out of order",
+	"too far behind",
+	"timestamp too old",
+	"greater_than_max_sample_age",
+}
+
+type Options struct {
+	URL      string
+	TenantID string
+	Username string
+	Password string
+	// Labels статические метки всех потоков
+	Labels  map[string]string
+	Timeout time.Duration
+	// CloudEvents режим CloudEvents для строк, поддерживается только структурированный режим
+	CloudEvents cloudevents.Mode
+}
+
+func (o *Options) validate() error {
+	if o.URL == "" {
+		return errors.New("url is empty")
+	}
+	o.URL = strings.TrimRight(o.URL, "/")
+
+	err := o.CloudEvents.Validate()
+	if err != nil {
+		return err
+	}
+	if o.CloudEvents == cloudevents.ModeBinary {
+		return errors.New("cloudevents binary mode is not supported by loki sink")
+	}
+
+	for key := range o.Labels {
+		for _, label := range labelFields {
+			if key == label {
+				return fmt.Errorf("static label %s conflicts with event label", key)
+			}
+		}
+	}
+
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+
+	return nil
+}
+
+// Sender отправляет события в Loki, группируя их в потоки по набору меток.
+// Строки, отклоненные Loki из-за нарушения порядка или возраста, не отправляются повторно.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	opts   Options
+	client *http.Client
+	logger *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	return &Sender[T]{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		logger: logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	return s.SendBatch([]*T{event})
+}
+
+func (s *Sender[T]) SendBatch(events []*T) error {
+	streams, err := s.streams(events)
+	if err != nil {
+		return err
+	}
+
+	body := s2.EncodeSnappy(nil, marshalPushRequest(streams))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.URL+pushPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("can't create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	if s.opts.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.opts.TenantID)
+	}
+	if s.opts.Username != "" {
+		req.SetBasicAuth(s.opts.Username, s.opts.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return internal.Unavailable(fmt.Errorf("can't push events: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusBadRequest && isRejection(string(message)) {
+		s.logger.Warn(
+			"loki rejected events, they won't be retried",
+			zap.Int("events", len(events)),
+			zap.ByteString("message", bytes.TrimSpace(message)),
+		)
+
+		return nil
+	}
+
+	err = fmt.Errorf("can't push events: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	if internal.UnavailableStatus(resp.StatusCode) {
+		return internal.Unavailable(err)
+	}
+
+	return err
+}
+
+// streams группирует события в потоки, строки каждого потока упорядочены по времени
+func (s *Sender[T]) streams(events []*T) ([]*stream, error) {
+	byLabels := map[string]*stream{}
+	for _, event := range events {
+		msg, err := cloudevents.Encode(event, s.opts.CloudEvents, cloudevents.Binding{})
+		if err != nil {
+			return nil, err
+		}
+
+		eventTime := internal.MetaOf(event).Time
+		if eventTime.IsZero() {
+			eventTime = time.Now()
+		}
+
+		st := &stream{labels: s.labels(event)}
+		key := st.labelsString()
+		if existing, ok := byLabels[key]; ok {
+			st = existing
+		} else {
+			byLabels[key] = st
+		}
+		st.entries = append(st.entries, entry{time: eventTime, line: string(msg.Data)})
+	}
+
+	keys := make([]string, 0, len(byLabels))
+	for key := range byLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	streams := make([]*stream, 0, len(keys))
+	for _, key := range keys {
+		st := byLabels[key]
+		sort.SliceStable(st.entries, func(i, j int) bool {
+			return st.entries[i].time.Before(st.entries[j].time)
+		})
+		streams = append(streams, st)
+	}
+
+	return streams, nil
+}
+
+func (s *Sender[T]) labels(event *T) map[string]string {
+	labels := make(map[string]string, len(s.opts.Labels)+len(labelFields))
+	for key, value := range s.opts.Labels {
+		labels[key] = value
+	}
+
+	for field, value := range template.Fields(event) {
+		label, ok := labelFields[field]
+		if ok && value != "" {
+			labels[label] = value
+		}
+	}
+
+	return labels
+}
+
+func isRejection(message string) bool {
+	for _, marker := range rejectionMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,264 @@
+package loki
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/s2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+type pushedStream struct {
+	labels string
+	times  []time.Time
+	lines  []string
+}
+
+type fakeLoki struct {
+	mu      sync.Mutex
+	streams []pushedStream
+	tenant  string
+	status  int
+	message string
+}
+
+type wireField struct {
+	num   protowire.Number
+	value []byte
+	n     uint64
+}
+
+// fields разбирает сообщение protobuf в пары номер поля — значение, поддерживаются только varint и bytes
+func fields(t *testing.T, msg []byte) []wireField {
+	t.Helper()
+
+	var result []wireField
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		require.GreaterOrEqual(t, n, 0)
+		msg = msg[n:]
+
+		field := wireField{num: num}
+		switch typ {
+		case protowire.VarintType:
+			field.n, n = protowire.ConsumeVarint(msg)
+		case protowire.BytesType:
+			field.value, n = protowire.ConsumeBytes(msg)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		require.GreaterOrEqual(t, n, 0)
+		msg = msg[n:]
+		result = append(result, field)
+	}
+
+	return result
+}
+
+func decodePushRequest(t *testing.T, body []byte) []pushedStream {
+	t.Helper()
+
+	var streams []pushedStream
+	for _, streamField := range fields(t, body) {
+		var st pushedStream
+		for _, field := range fields(t, streamField.value) {
+			switch field.num {
+			case 1:
+				st.labels = string(field.value)
+			case 2:
+				var ts time.Time
+				var line string
+				for _, entryField := range fields(t, field.value) {
+					switch entryField.num {
+					case 1:
+						var seconds, nanos uint64
+						for _, tsField := range fields(t, entryField.value) {
+							if tsField.num == 1 {
+								seconds = tsField.n
+							} else {
+								nanos = tsField.n
+							}
+						}
+						ts = time.Unix(int64(seconds), int64(nanos)).UTC()
+					case 2:
+						line = string(entryField.value)
+					}
+				}
+				st.times = append(st.times, ts)
+				st.lines = append(st.lines, line)
+			}
+		}
+		streams = append(streams, st)
+	}
+
+	return streams
+}
+
+func newFakeLoki(t *testing.T, loki *fakeLoki) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loki.mu.Lock()
+		defer loki.mu.Unlock()
+
+		if r.URL.Path != pushPath || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if loki.status != 0 {
+			w.WriteHeader(loki.status)
+			_, _ = w.Write([]byte(loki.message))
+			return
+		}
+
+		compressed, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		body, err := s2.Decode(nil, compressed)
+		require.NoError(t, err)
+
+		loki.tenant = r.Header.Get("X-Scope-OrgID")
+		loki.streams = append(loki.streams, decodePushRequest(t, body)...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestSender_SendBatch(t *testing.T) {
+	t.Parallel()
+
+	loki := &fakeLoki{}
+	server := newFakeLoki(t, loki)
+	sender, err := NewSender[internal.Event](Options{
+		URL:      server.URL,
+		TenantID: "auth",
+		Labels:   map[string]string{"job": "keycloak"},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	eventTime := time.Date(2024, 1, 15, 10, 30, 0, 500, time.UTC)
+	events := []*internal.Event{
+		{Id: uuid.New(), Time: eventTime.Add(time.Second), Type: internal.EventTypeLogin, RealmName: "master", UserId: uuid.New(), IpAddress: "10.0.0.1"},
+		{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLogin, RealmName: "master", UserId: uuid.New()},
+		{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLoginError, RealmName: "master", Error: "invalid_user_credentials"},
+	}
+	require.NoError(t, sender.SendBatch(events))
+
+	assert.Equal(t, "auth", loki.tenant)
+	require.Len(t, loki.streams, 2)
+
+	login, loginError := loki.streams[0], loki.streams[1]
+	assert.Equal(t, `{event_type="LOGIN", job="keycloak", kind="events", outcome="success", realm="master"}`, login.labels)
+	assert.Equal(t, `{event_type="LOGIN_ERROR", job="keycloak", kind="events", outcome="error", realm="master"}`, loginError.labels)
+
+	// строки потока упорядочены по времени
+	require.Len(t, login.lines, 2)
+	assert.Equal(t, []time.Time{eventTime, eventTime.Add(time.Second)}, login.times)
+
+	var got internal.Event
+	require.NoError(t, json.Unmarshal([]byte(login.lines[1]), &got))
+	assert.Equal(t, events[0].UserId, got.UserId)
+	assert.Equal(t, "10.0.0.1", got.IpAddress)
+}
+
+func TestSender_SendAdmin(t *testing.T) {
+	t.Parallel()
+
+	loki := &fakeLoki{}
+	server := newFakeLoki(t, loki)
+	sender, err := NewSender[internal.AdminEvent](Options{URL: server.URL}, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, sender.Send(&internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmName:     "master",
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		ResourcePath:  "users/" + uuid.NewString(),
+	}))
+
+	require.Len(t, loki.streams, 1)
+	assert.Equal(t, `{kind="admin_events", operation_type="CREATE", outcome="success", realm="master", resource_type="USER"}`, loki.streams[0].labels)
+}
+
+func TestSender_SendCloudEvents(t *testing.T) {
+	t.Parallel()
+
+	loki := &fakeLoki{}
+	server := newFakeLoki(t, loki)
+	sender, err := NewSender[internal.Event](Options{URL: server.URL, CloudEvents: cloudevents.ModeStructured}, zap.NewNop())
+	require.NoError(t, err)
+
+	event := &internal.Event{Id: uuid.New(), Type: internal.EventTypeLogin, RealmName: "master", UserId: uuid.New()}
+	require.NoError(t, sender.Send(event))
+
+	require.Len(t, loki.streams, 1)
+	require.Len(t, loki.streams[0].lines, 1)
+
+	var ce cloudevents.Event
+	require.NoError(t, json.Unmarshal([]byte(loki.streams[0].lines[0]), &ce))
+	assert.Equal(t, event.Id.String(), ce.Id)
+	assert.Equal(t, "org.keycloak.event.LOGIN", ce.Type)
+	assert.Equal(t, event.UserId.String(), ce.Subject)
+}
+
+func TestSender_SendErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		message         string
+		wantErr         bool
+		wantUnavailable bool
+	}{
+		{name: "out of order is not retried", status: http.StatusBadRequest, message: "entry with timestamp 2024-01-15 10:30:00 ignored, reason: 'entry out of order'"},
+		{name: "too old is not retried", status: http.StatusBadRequest, message: "entry for stream has timestamp too old"},
+		{name: "invalid request", status: http.StatusBadRequest, message: "error parsing labels", wantErr: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, message: "ingestion rate limit exceeded", wantErr: true, wantUnavailable: true},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true, wantUnavailable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newFakeLoki(t, &fakeLoki{status: tt.status, message: tt.message})
+			sender, err := NewSender[internal.Event](Options{URL: server.URL}, zap.NewNop())
+			require.NoError(t, err)
+
+			err = sender.Send(&internal.Event{Id: uuid.New(), RealmName: "master"})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantUnavailable, errors.Is(err, internal.ErrUnavailable))
+		})
+	}
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty url", opts: Options{}},
+		{name: "cloudevents binary", opts: Options{URL: "http://localhost:3100", CloudEvents: cloudevents.ModeBinary}},
+		{name: "conflicting static label", opts: Options{URL: "http://localhost:3100", Labels: map[string]string{"realm": "master"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	pahov3 "github.com/eclipse/paho.mqtt.golang"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+// qos уровень доставки публикаций: брокер подтверждает каждое сообщение PUBACK
+const qos = 1
+
+// client публикует сообщение и ждет подтверждения брокера
+type client interface {
+	publish(ctx context.Context, topic string, msg *cloudevents.Message, retain bool) error
+	Close() error
+}
+
+// v3Client клиент MQTT 3.1.1
+type v3Client struct {
+	client pahov3.Client
+}
+
+func newV3Client(opts Options, tlsConfig *tls.Config) (*v3Client, error) {
+	clientOpts := pahov3.NewClientOptions().
+		AddBroker(opts.URL).
+		SetClientID(opts.ClientID).
+		SetUsername(opts.Username).
+		SetPassword(opts.Password).
+		SetTLSConfig(tlsConfig).
+		SetProtocolVersion(4).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectTimeout(opts.Timeout)
+
+	c := pahov3.NewClient(clientOpts)
+	token := c.Connect()
+	if !token.WaitTimeout(opts.Timeout) {
+		c.Disconnect(0)
+		return nil, errors.New("connect timeout")
+	}
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+
+	return &v3Client{client: c}, nil
+}
+
+// publish отправляет только данные сообщения: в MQTT 3.1.1 нет свойств для типа содержимого и заголовков
+func (c *v3Client) publish(ctx context.Context, topic string, msg *cloudevents.Message, retain bool) error {
+	token := c.client.Publish(topic, qos, retain, msg.Data)
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return fmt.Errorf("puback wait: %w", ctx.Err())
+	}
+}
+
+func (c *v3Client) Close() error {
+	c.client.Disconnect(250)
+	return nil
+}
+
+// v5Client клиент MQTT 5
+type v5Client struct {
+	conn *autopaho.ConnectionManager
+}
+
+func newV5Client(opts Options, tlsConfig *tls.Config) (*v5Client, error) {
+	serverURL, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	cfg := autopaho.ClientConfig{
+		ServerUrls:                    []*url.URL{serverURL},
+		TlsCfg:                        tlsConfig,
+		KeepAlive:                     30,
+		CleanStartOnInitialConnection: true,
+		ConnectTimeout:                opts.Timeout,
+		ConnectRetryDelay:             time.Second,
+		ClientConfig:                  paho.ClientConfig{ClientID: opts.ClientID},
+	}
+	if opts.Username != "" {
+		cfg.SetUsernamePassword(opts.Username, []byte(opts.Password))
+	}
+
+	conn, err := autopaho.NewConnection(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	err = conn.AwaitConnection(ctx)
+	if err != nil {
+		_ = conn.Disconnect(context.Background())
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	return &v5Client{conn: conn}, nil
+}
+
+func (c *v5Client) publish(ctx context.Context, topic string, msg *cloudevents.Message, retain bool) error {
+	props := &paho.PublishProperties{ContentType: msg.ContentType}
+	for name, value := range msg.Headers {
+		props.User.Add(name, value)
+	}
+
+	_, err := c.conn.Publish(ctx, &paho.Publish{
+		QoS:        qos,
+		Topic:      topic,
+		Payload:    msg.Data,
+		Retain:     retain,
+		Properties: props,
+	})
+
+	return err
+}
+
+func (c *v5Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return c.conn.Disconnect(ctx)
+}
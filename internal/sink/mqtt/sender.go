@@ -0,0 +1,188 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+	"keycloak-events-adapter/internal/sink/template"
+)
+
+type Version string
+
+const (
+	Version311 Version = "3.1.1"
+	Version5   Version = "5"
+)
+
+type Options struct {
+	// URL адрес брокера, например mqtt://host:1883 или mqtts://host:8883
+	URL      string
+	Version  Version
+	ClientID string
+	Username string
+	Password string
+	Topic    string
+	Retain   bool
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	Timeout  time.Duration
+	// CloudEvents режим CloudEvents, бинарный режим передает атрибуты пользовательскими свойствами MQTT 5
+	CloudEvents cloudevents.Mode
+}
+
+func (o *Options) validate() error {
+	if o.URL == "" {
+		return errors.New("url is empty")
+	}
+
+	switch o.Version {
+	case "":
+		o.Version = Version311
+	case Version311, Version5:
+	default:
+		return fmt.Errorf("unknown protocol version: %s", o.Version)
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return errors.New("cert file and key file must be set together")
+	}
+
+	err := o.CloudEvents.Validate()
+	if err != nil {
+		return err
+	}
+	if o.CloudEvents == cloudevents.ModeBinary && o.Version != Version5 {
+		return errors.New("cloudevents binary mode requires mqtt 5")
+	}
+
+	if o.ClientID == "" {
+		o.ClientID = "keycloak-events-adapter-" + uuid.NewString()
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+
+	return nil
+}
+
+// tlsConfig возвращает конфигурацию TLS, если задан CA или клиентский сертификат
+func (o *Options) tlsConfig() (*tls.Config, error) {
+	if o.CAFile == "" && o.CertFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read ca file: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates in ca file")
+		}
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// Sender публикует события в MQTT с QoS 1 и возвращает управление только после PUBACK брокера
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	client client
+	topic  *template.Template
+	opts   Options
+	logger *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	topic, err := template.Parse(opts.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic: %w", err)
+	}
+
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var c client
+	switch opts.Version {
+	case Version5:
+		c, err = newV5Client(opts, tlsConfig)
+	default:
+		c, err = newV3Client(opts, tlsConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to mqtt: %w", err)
+	}
+
+	return &Sender[T]{
+		client: c,
+		topic:  topic,
+		opts:   opts,
+		logger: logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	msg, err := cloudevents.Encode(event, s.opts.CloudEvents, cloudevents.BindingMQTT)
+	if err != nil {
+		return err
+	}
+
+	topic := s.topic.Render(template.Fields(event), escapeLevel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	err = s.client.publish(ctx, topic, msg, s.opts.Retain)
+	if err != nil {
+		return internal.Unavailable(fmt.Errorf("can't publish to %s: %w", topic, err))
+	}
+
+	return nil
+}
+
+func (s *Sender[T]) Close() error {
+	return s.client.Close()
+}
+
+// escapeLevel делает значение допустимым уровнем темы: без разделителя уровней и символов подстановки
+func escapeLevel(value string) string {
+	if value == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#', 0:
+			return '_'
+		default:
+			return r
+		}
+	}, value)
+}
@@ -0,0 +1,324 @@
+package mqtt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+// aclHook разрешает подключение всем клиентам и запрещает публикацию в темы с префиксом deny
+type aclHook struct {
+	mochi.HookBase
+	deny string
+}
+
+func (h *aclHook) ID() string {
+	return "acl"
+}
+
+func (h *aclHook) Provides(b byte) bool {
+	return b == mochi.OnConnectAuthenticate || b == mochi.OnACLCheck
+}
+
+func (h *aclHook) OnConnectAuthenticate(*mochi.Client, packets.Packet) bool {
+	return true
+}
+
+func (h *aclHook) OnACLCheck(_ *mochi.Client, topic string, _ bool) bool {
+	return h.deny == "" || !strings.HasPrefix(topic, h.deny)
+}
+
+type message struct {
+	topic      string
+	payload    []byte
+	retain     bool
+	properties map[string]string
+}
+
+type broker struct {
+	addr     string
+	mu       sync.Mutex
+	messages []message
+}
+
+func (b *broker) received() []message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]message(nil), b.messages...)
+}
+
+func newBroker(t *testing.T, tlsConfig *tls.Config, deny string) *broker {
+	t.Helper()
+
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, server.AddHook(&aclHook{deny: deny}, nil))
+
+	listener := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0", TLSConfig: tlsConfig})
+	require.NoError(t, server.AddListener(listener))
+
+	b := &broker{addr: listener.Address()}
+	require.NoError(t, server.Subscribe("keycloak/#", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		properties := map[string]string{"content-type": pk.Properties.ContentType}
+		for _, prop := range pk.Properties.User {
+			properties[prop.Key] = prop.Val
+		}
+		b.messages = append(b.messages, message{
+			topic:      pk.TopicName,
+			payload:    pk.Payload,
+			retain:     pk.FixedHeader.Retain,
+			properties: properties,
+		})
+	}))
+
+	require.NoError(t, server.Serve())
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	return b
+}
+
+func TestSender_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		version Version
+		retain  bool
+	}{
+		{name: "mqtt 3.1.1", version: Version311},
+		{name: "mqtt 5", version: Version5},
+		{name: "mqtt 5 retained", version: Version5, retain: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := newBroker(t, nil, "")
+			sender, err := NewSender[internal.Event](Options{
+				URL:     "mqtt://" + b.addr,
+				Version: tt.version,
+				Topic:   "keycloak/{realm_name}/events/{event_type}",
+				Retain:  tt.retain,
+			}, zap.NewNop())
+			require.NoError(t, err)
+			defer sender.Close()
+
+			event := &internal.Event{Id: uuid.New(), Type: internal.EventTypeLogin, RealmName: "my/realm"}
+			require.NoError(t, sender.Send(event))
+
+			require.Eventually(t, func() bool {
+				return len(b.received()) == 1
+			}, 3*time.Second, 10*time.Millisecond)
+
+			msg := b.received()[0]
+			assert.Equal(t, "keycloak/my_realm/events/LOGIN", msg.topic)
+			assert.Equal(t, tt.retain, msg.retain)
+
+			var got internal.Event
+			require.NoError(t, json.Unmarshal(msg.payload, &got))
+			assert.Equal(t, event.Id, got.Id)
+		})
+	}
+}
+
+func TestSender_SendCloudEventsBinary(t *testing.T) {
+	t.Parallel()
+
+	b := newBroker(t, nil, "")
+	sender, err := NewSender[internal.Event](Options{
+		URL:         "mqtt://" + b.addr,
+		Version:     Version5,
+		Topic:       "keycloak/{realm_name}",
+		CloudEvents: cloudevents.ModeBinary,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	event := &internal.Event{Id: uuid.New(), Type: internal.EventTypeLogin, RealmName: "master"}
+	require.NoError(t, sender.Send(event))
+
+	require.Eventually(t, func() bool {
+		return len(b.received()) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	msg := b.received()[0]
+	assert.Equal(t, event.Id.String(), msg.properties["id"])
+	assert.Equal(t, "org.keycloak.event.LOGIN", msg.properties["type"])
+	assert.Equal(t, cloudevents.ContentTypeJSON, msg.properties["content-type"])
+}
+
+func TestSender_SendNotAuthorized(t *testing.T) {
+	t.Parallel()
+
+	b := newBroker(t, nil, "keycloak/master")
+	sender, err := NewSender[internal.AdminEvent](Options{
+		URL:     "mqtt://" + b.addr,
+		Version: Version5,
+		Topic:   "keycloak/{realm_name}/admin/{resource_type}",
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	err = sender.Send(&internal.AdminEvent{Id: uuid.New(), RealmName: "master", ResourceType: "USER"})
+	assert.Error(t, err)
+	assert.Empty(t, b.received())
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, pem.Encode(&buf, &pem.Block{Type: blockType, Bytes: der}))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+// newCertificates создает CA, серверный и клиентский сертификаты, клиентские файлы пишутся в dir
+func newCertificates(t *testing.T, dir string) *tls.Config {
+	t.Helper()
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return key
+	}
+	notAfter := time.Now().Add(time.Hour)
+
+	caKey := newKey()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key := newKey()
+		der, errC := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     notAfter,
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}, ca, &key.PublicKey, caKey)
+		require.NoError(t, errC)
+		return der, key
+	}
+
+	serverDER, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	clientDER, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", caDER)
+	writePEM(t, filepath.Join(dir, "client.pem"), "CERTIFICATE", clientDER)
+	writePEM(t, filepath.Join(dir, "client-key.pem"), "EC PRIVATE KEY", clientKeyDER)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func TestSender_SendTLSClientAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		version Version
+	}{
+		{name: "mqtt 3.1.1", version: Version311},
+		{name: "mqtt 5", version: Version5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			b := newBroker(t, newCertificates(t, dir), "")
+
+			opts := Options{
+				URL:     "mqtts://" + b.addr,
+				Version: tt.version,
+				Topic:   "keycloak/{kind}",
+				CAFile:  filepath.Join(dir, "ca.pem"),
+				Timeout: 2 * time.Second,
+			}
+
+			_, err := NewSender[internal.Event](opts, zap.NewNop())
+			require.Error(t, err, "broker must reject client without certificate")
+
+			opts.CertFile = filepath.Join(dir, "client.pem")
+			opts.KeyFile = filepath.Join(dir, "client-key.pem")
+			sender, err := NewSender[internal.Event](opts, zap.NewNop())
+			require.NoError(t, err)
+			defer sender.Close()
+
+			require.NoError(t, sender.Send(&internal.Event{Id: uuid.New()}))
+		})
+	}
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty url", opts: Options{Topic: "keycloak"}},
+		{name: "unknown version", opts: Options{URL: "mqtt://localhost:1883", Topic: "keycloak", Version: "4"}},
+		{name: "cert without key", opts: Options{URL: "mqtt://localhost:1883", Topic: "keycloak", CertFile: "client.pem"}},
+		{name: "invalid topic", opts: Options{URL: "mqtt://localhost:1883", Topic: "{unknown}"}},
+		{name: "binary cloudevents on mqtt 3.1.1", opts: Options{URL: "mqtt://localhost:1883", Topic: "keycloak", CloudEvents: cloudevents.ModeBinary}},
+		{name: "missing ca file", opts: Options{URL: "mqtt://localhost:1883", Topic: "keycloak", CAFile: "missing.pem"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	natsio "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+	"keycloak-events-adapter/internal/sink/template"
+)
+
+type Options struct {
+	URL       string
+	CredsFile string
+	User      string
+	Password  string
+	Subject   string
+	Timeout   time.Duration
+	// CloudEvents режим CloudEvents, в бинарном режиме атрибуты передаются заголовками ce-*
+	CloudEvents cloudevents.Mode
+}
+
+// Sender публикует события в JetStream и возвращает управление только после подтверждения публикации.
+// UUID события передается в заголовке Nats-Msg-Id для дедупликации на стороне сервера.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	conn        *natsio.Conn
+	js          jetstream.JetStream
+	subject     *template.Template
+	timeout     time.Duration
+	cloudEvents cloudevents.Mode
+	logger      *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	if opts.URL == "" {
+		return nil, errors.New("url is empty")
+	}
+
+	subject, err := template.Parse(opts.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject: %w", err)
+	}
+
+	err = opts.CloudEvents.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	connOpts := []natsio.Option{
+		natsio.Name("keycloak-events-adapter"),
+		natsio.MaxReconnects(-1),
+		natsio.DisconnectErrHandler(func(_ *natsio.Conn, err error) {
+			logger.Warn("nats disconnected", zap.Error(err))
+		}),
+		natsio.ReconnectHandler(func(conn *natsio.Conn) {
+			logger.Info("nats reconnected", zap.String("url", conn.ConnectedUrl()))
+		}),
+	}
+	if opts.CredsFile != "" {
+		connOpts = append(connOpts, natsio.UserCredentials(opts.CredsFile))
+	}
+	if opts.User != "" {
+		connOpts = append(connOpts, natsio.UserInfo(opts.User, opts.Password))
+	}
+
+	conn, err := natsio.Connect(opts.URL, connOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("can't create jetstream context: %w", err)
+	}
+
+	return &Sender[T]{
+		conn:        conn,
+		js:          js,
+		subject:     subject,
+		timeout:     opts.Timeout,
+		cloudEvents: opts.CloudEvents,
+		logger:      logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	encoded, err := cloudevents.Encode(event, s.cloudEvents, cloudevents.BindingNATS)
+	if err != nil {
+		return err
+	}
+
+	msg := natsio.NewMsg(s.subject.Render(template.Fields(event), escapeToken))
+	msg.Data = encoded.Data
+	for name, value := range encoded.Headers {
+		msg.Header[name] = []string{value}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	ack, err := s.js.PublishMsg(ctx, msg, jetstream.WithMsgID(internal.MetaOf(event).Id.String()))
+	if err != nil {
+		err = fmt.Errorf("can't publish to %s: %w", msg.Subject, err)
+		// слишком большое сообщение не будет принято и доступным сервером
+		if errors.Is(err, natsio.ErrMaxPayload) {
+			return err
+		}
+		return internal.Unavailable(err)
+	}
+
+	if ack.Duplicate {
+		s.logger.Debug("duplicate event skipped by server", zap.String("subject", msg.Subject))
+	}
+
+	return nil
+}
+
+func (s *Sender[T]) Close() error {
+	return s.conn.Drain()
+}
+
+// escapeToken приводит значение к допустимому токену субъекта NATS
+func escapeToken(value string) string {
+	if value == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		default:
+			return r
+		}
+	}, value)
+}
@@ -0,0 +1,210 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	natsio "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+func runServer(t *testing.T) (string, jetstream.Stream) {
+	t.Helper()
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(5*time.Second))
+
+	conn, err := natsio.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+
+	stream, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:       "KEYCLOAK",
+		Subjects:   []string{"keycloak.>"},
+		Duplicates: time.Minute,
+	})
+	require.NoError(t, err)
+
+	return ns.ClientURL(), stream
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+
+	url, stream := runServer(t)
+	sender, err := NewSender[internal.Event](Options{
+		URL:     url,
+		Subject: "keycloak.{realm_name}.events.{event_type}",
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Type:      internal.EventTypeLoginError,
+		RealmName: "my.realm",
+		Error:     "invalid_user_credentials",
+	}
+	require.NoError(t, sender.Send(event))
+	require.NoError(t, sender.Send(event))
+
+	info, err := stream.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs)
+
+	msg, err := stream.GetLastMsgForSubject(context.Background(), "keycloak.my_realm.events.LOGIN_ERROR")
+	require.NoError(t, err)
+	assert.Equal(t, event.Id.String(), msg.Header.Get(jetstream.MsgIDHeader))
+
+	var got internal.Event
+	require.NoError(t, json.Unmarshal(msg.Data, &got))
+	assert.Equal(t, event.Id, got.Id)
+	assert.Equal(t, "invalid_user_credentials", got.Error)
+}
+
+func TestSender_SendAdmin(t *testing.T) {
+	t.Parallel()
+
+	url, stream := runServer(t)
+	sender, err := NewSender[internal.AdminEvent](Options{
+		URL:     url,
+		Subject: "keycloak.{realm_name}.admin.{resource_type}.{operation_type}",
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	adminEvent := &internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmName:     "master",
+		ResourceType:  "REALM_ROLE_MAPPING",
+		OperationType: internal.OperationTypeCreate,
+	}
+	require.NoError(t, sender.Send(adminEvent))
+
+	msg, err := stream.GetLastMsgForSubject(context.Background(), "keycloak.master.admin.REALM_ROLE_MAPPING.CREATE")
+	require.NoError(t, err)
+	assert.Equal(t, adminEvent.Id.String(), msg.Header.Get(jetstream.MsgIDHeader))
+}
+
+func TestSender_SendCloudEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		mode cloudevents.Mode
+	}{
+		{name: "structured", mode: cloudevents.ModeStructured},
+		{name: "binary", mode: cloudevents.ModeBinary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			url, stream := runServer(t)
+			sender, err := NewSender[internal.Event](Options{
+				URL:         url,
+				Subject:     "keycloak.{realm_name}",
+				CloudEvents: tt.mode,
+			}, zap.NewNop())
+			require.NoError(t, err)
+			defer sender.Close()
+
+			event := &internal.Event{Id: uuid.New(), Type: internal.EventTypeLogin, RealmName: "master"}
+			require.NoError(t, sender.Send(event))
+
+			msg, err := stream.GetLastMsgForSubject(context.Background(), "keycloak.master")
+			require.NoError(t, err)
+
+			if tt.mode == cloudevents.ModeBinary {
+				assert.Equal(t, event.Id.String(), msg.Header.Get("ce-id"))
+				assert.Equal(t, "keycloak/master", msg.Header.Get("ce-source"))
+				assert.Equal(t, cloudevents.ContentTypeJSON, msg.Header.Get("content-type"))
+
+				var got internal.Event
+				require.NoError(t, json.Unmarshal(msg.Data, &got))
+				assert.Equal(t, event.Id, got.Id)
+				return
+			}
+
+			assert.Equal(t, cloudevents.ContentTypeStructured, msg.Header.Get("content-type"))
+
+			var got cloudevents.Event
+			require.NoError(t, json.Unmarshal(msg.Data, &got))
+			assert.Equal(t, event.Id.String(), got.Id)
+			assert.Equal(t, "org.keycloak.event.LOGIN", got.Type)
+		})
+	}
+}
+
+func TestSender_SendNoStream(t *testing.T) {
+	t.Parallel()
+
+	url, _ := runServer(t)
+	sender, err := NewSender[internal.Event](Options{
+		URL:     url,
+		Subject: "other.{realm_name}",
+		Timeout: time.Second,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	assert.Error(t, sender.Send(&internal.Event{Id: uuid.New(), RealmName: "master"}))
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty url", opts: Options{Subject: "keycloak.events"}},
+		{name: "invalid subject", opts: Options{URL: "nats://127.0.0.1:4222", Subject: "keycloak.{unknown}"}},
+		{name: "unknown cloudevents mode", opts: Options{URL: "nats://127.0.0.1:4222", Subject: "keycloak", CloudEvents: "batch"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEscapeToken(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "master", want: "master"},
+		{value: "", want: "_"},
+		{value: "my.realm", want: "my_realm"},
+		{value: "a*b>c d", want: "a_b_c_d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, escapeToken(tt.value))
+		})
+	}
+}
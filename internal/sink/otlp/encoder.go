@@ -0,0 +1,144 @@
+package otlp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"keycloak-events-adapter/internal"
+)
+
+const scopeName = "keycloak-events-adapter"
+
+type attributes []*commonpb.KeyValue
+
+func (a *attributes) add(key, value string) {
+	if value == "" {
+		return
+	}
+
+	*a = append(*a, &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	})
+}
+
+func (a *attributes) addUUID(key string, value uuid.UUID) {
+	if value == uuid.Nil {
+		return
+	}
+
+	a.add(key, value.String())
+}
+
+func (a *attributes) addDetails(details map[string]string) {
+	for key, value := range details {
+		a.add("keycloak.details."+key, value)
+	}
+}
+
+// addEnrichment добавляет маршрут, метки и геолокацию
+func (a *attributes) addEnrichment(enrichment *internal.Enrichment) {
+	if enrichment == nil {
+		return
+	}
+
+	a.add("keycloak.route", enrichment.Route)
+	for key, value := range enrichment.Tags {
+		a.add("keycloak.tags."+key, value)
+	}
+	a.addGeo(enrichment.Geo)
+}
+
+// addGeo добавляет геолокацию в атрибутах geo.* семантических соглашений OpenTelemetry
+func (a *attributes) addGeo(geo *internal.Geo) {
+	if geo == nil {
+		return
+	}
+
+	a.add("keycloak.geo.scope", string(geo.Scope))
+	a.add("geo.country.iso_code", geo.CountryCode)
+	a.add("geo.locality.name", geo.City)
+	if geo.Latitude != 0 || geo.Longitude != 0 {
+		*a = append(*a,
+			&commonpb.KeyValue{Key: "geo.location.lat", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: geo.Latitude}}},
+			&commonpb.KeyValue{Key: "geo.location.lon", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: geo.Longitude}}},
+		)
+	}
+	if geo.ASN != 0 {
+		*a = append(*a, &commonpb.KeyValue{Key: "keycloak.geo.asn", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(geo.ASN)}}})
+	}
+	a.add("keycloak.geo.as_organization", geo.ASOrganization)
+}
+
+// record преобразует событие в LogRecord: ошибка задает уровень ERROR,
+// время события используется и как время записи, и как время наблюдения
+func record[T internal.Event | internal.AdminEvent](event *T) *logspb.LogRecord {
+	meta := internal.MetaOf(event)
+
+	eventTime := meta.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+
+	attrs := attributes{}
+	attrs.add("keycloak.event.kind", meta.Kind)
+	attrs.addUUID("keycloak.event.id", meta.Id)
+	attrs.addUUID("keycloak.realm.id", meta.RealmId)
+	attrs.add("keycloak.realm.name", meta.RealmName)
+
+	var body, eventError string
+	switch e := any(event).(type) {
+	case *internal.Event:
+		body = e.TypeName()
+		eventError = e.Error
+
+		attrs.add("keycloak.event.type", body)
+		attrs.addUUID("enduser.id", e.UserId)
+		attrs.add("keycloak.client.id", e.ClientId)
+		attrs.add("client.address", e.IpAddress)
+		attrs.add("session.id", e.SessionId)
+		attrs.addDetails(e.Details)
+		attrs.addEnrichment(e.Enrichment)
+	case *internal.AdminEvent:
+		body = e.ResourceType + " " + fmt.Sprint(e.OperationType)
+		eventError = e.Error
+
+		attrs.add("keycloak.admin.resource_type", e.ResourceType)
+		attrs.add("keycloak.admin.operation_type", fmt.Sprint(e.OperationType))
+		attrs.add("keycloak.admin.resource_path", e.ResourcePath)
+		if ref := internal.ResourceOf(e); ref != nil {
+			attrs.add("keycloak.admin.resource_kind", ref.Kind)
+			attrs.add("keycloak.admin.target_user_id", ref.TargetUserId)
+			attrs.add("keycloak.admin.target_client_id", ref.TargetClientId)
+			attrs.add("keycloak.admin.target_group_id", ref.TargetGroupId)
+		}
+		attrs.add("keycloak.admin.representation", e.Representation)
+		if e.AuthDetails != nil {
+			attrs.addUUID("enduser.id", e.AuthDetails.UserId)
+			attrs.addUUID("keycloak.client.id", e.AuthDetails.ClientId)
+			attrs.add("client.address", e.AuthDetails.IpAddress)
+			attrs.addUUID("keycloak.auth.realm.id", e.AuthDetails.RealmId)
+			attrs.add("keycloak.auth.realm.name", e.AuthDetails.RealmName)
+		}
+		attrs.addDetails(e.Details)
+		attrs.addEnrichment(e.Enrichment)
+	}
+
+	severity, severityText := logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO"
+	if eventError != "" {
+		severity, severityText = logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, "ERROR"
+		attrs.add("error.type", eventError)
+	}
+
+	return &logspb.LogRecord{
+		TimeUnixNano:         uint64(eventTime.UnixNano()),
+		ObservedTimeUnixNano: uint64(eventTime.UnixNano()),
+		SeverityNumber:       severity,
+		SeverityText:         severityText,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: body}},
+		Attributes:           attrs,
+	}
+}
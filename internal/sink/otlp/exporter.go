@@ -0,0 +1,121 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"keycloak-events-adapter/internal"
+)
+
+type exporter interface {
+	export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error)
+	io.Closer
+}
+
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	client  collogspb.LogsServiceClient
+	headers metadata.MD
+}
+
+func newGRPCExporter(opts Options) (*grpcExporter, error) {
+	creds := insecure.NewCredentials()
+	if !opts.Insecure {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	conn, err := grpc.NewClient(opts.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("can't create grpc client: %w", err)
+	}
+
+	return &grpcExporter{
+		conn:    conn,
+		client:  collogspb.NewLogsServiceClient(conn),
+		headers: metadata.New(opts.Headers),
+	}, nil
+}
+
+func (e *grpcExporter) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	resp, err := e.client.Export(metadata.NewOutgoingContext(ctx, e.headers), req)
+	if internal.UnavailableGRPC(err) {
+		return nil, internal.Unavailable(err)
+	}
+
+	return resp, err
+}
+
+func (e *grpcExporter) Close() error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+}
+
+func newHTTPExporter(opts Options) *httpExporter {
+	return &httpExporter{
+		url:     opts.Endpoint,
+		client:  &http.Client{},
+		headers: opts.Headers,
+	}
+}
+
+func (e *httpExporter) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range e.headers {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, internal.Unavailable(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, internal.Unavailable(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+		if internal.UnavailableStatus(resp.StatusCode) {
+			return nil, internal.Unavailable(err)
+		}
+		return nil, err
+	}
+
+	var exportResp collogspb.ExportLogsServiceResponse
+	err = proto.Unmarshal(data, &exportResp)
+	if err != nil {
+		return nil, fmt.Errorf("can't unmarshal response: %w", err)
+	}
+
+	return &exportResp, nil
+}
+
+func (e *httpExporter) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,138 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+)
+
+type Protocol string
+
+const (
+	ProtocolGRPC Protocol = "grpc"
+	ProtocolHTTP Protocol = "http"
+)
+
+type Options struct {
+	Protocol Protocol
+	// Endpoint host:port для grpc, полный URL (например http://collector:4318/v1/logs) для http
+	Endpoint    string
+	Insecure    bool
+	Headers     map[string]string
+	ServiceName string
+	Timeout     time.Duration
+}
+
+func (o *Options) validate() error {
+	if o.Endpoint == "" {
+		return errors.New("endpoint is empty")
+	}
+
+	switch o.Protocol {
+	case "":
+		o.Protocol = ProtocolGRPC
+	case ProtocolGRPC, ProtocolHTTP:
+	default:
+		return fmt.Errorf("unknown protocol: %s", o.Protocol)
+	}
+
+	if o.ServiceName == "" {
+		o.ServiceName = scopeName
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+
+	return nil
+}
+
+// Sender экспортирует события в OTLP коллектор как LogRecord.
+// Пачка отправляется одним синхронным Export, поэтому задачи подтверждаются только после ответа коллектора.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	exporter exporter
+	opts     Options
+	logger   *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	var exp exporter
+	switch opts.Protocol {
+	case ProtocolHTTP:
+		exp = newHTTPExporter(opts)
+	default:
+		exp, err = newGRPCExporter(opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Sender[T]{
+		exporter: exp,
+		opts:     opts,
+		logger:   logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	return s.SendBatch([]*T{event})
+}
+
+func (s *Sender[T]) SendBatch(events []*T) error {
+	records := make([]*logspb.LogRecord, 0, len(events))
+	for _, event := range events {
+		records = append(records, record(event))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	resp, err := s.exporter.export(ctx, s.request(records))
+	if err != nil {
+		return fmt.Errorf("can't export logs: %w", err)
+	}
+
+	// отклоненные коллектором записи повторно не отправляются, иначе принятые записи пачки задублируются
+	if partial := resp.GetPartialSuccess(); partial.GetRejectedLogRecords() > 0 {
+		s.logger.Warn(
+			"collector rejected log records",
+			zap.Int64("rejected", partial.GetRejectedLogRecords()),
+			zap.String("message", partial.GetErrorMessage()),
+		)
+	}
+
+	return nil
+}
+
+func (s *Sender[T]) request(records []*logspb.LogRecord) *collogspb.ExportLogsServiceRequest {
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{{
+					Key:   "service.name",
+					Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s.opts.ServiceName}},
+				}},
+			},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: scopeName},
+				LogRecords: records,
+			}},
+		}},
+	}
+}
+
+func (s *Sender[T]) Close() error {
+	return s.exporter.Close()
+}
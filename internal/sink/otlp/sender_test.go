@@ -0,0 +1,284 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"keycloak-events-adapter/internal"
+)
+
+type fakeCollector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	headers  []string
+	rejected int64
+	fail     bool
+}
+
+func (c *fakeCollector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fail {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.headers = append(c.headers, md.Get("x-tenant")...)
+	c.requests = append(c.requests, req)
+
+	resp := &collogspb.ExportLogsServiceResponse{}
+	if c.rejected > 0 {
+		resp.PartialSuccess = &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: c.rejected, ErrorMessage: "rejected"}
+	}
+
+	return resp, nil
+}
+
+func (c *fakeCollector) records() []*logspb.LogRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var records []*logspb.LogRecord
+	for _, req := range c.requests {
+		for _, resourceLogs := range req.ResourceLogs {
+			for _, scopeLogs := range resourceLogs.ScopeLogs {
+				records = append(records, scopeLogs.LogRecords...)
+			}
+		}
+	}
+
+	return records
+}
+
+func newGRPCCollector(t *testing.T, collector *fakeCollector) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, collector)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func newHTTPCollector(t *testing.T, collector *fakeCollector) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req collogspb.ExportLogsServiceRequest
+		require.NoError(t, proto.Unmarshal(body, &req))
+
+		ctx := metadata.NewIncomingContext(r.Context(), metadata.Pairs("x-tenant", r.Header.Get("X-Tenant")))
+		resp, err := collector.Export(ctx, &req)
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		data, err := proto.Marshal(resp)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL + "/v1/logs"
+}
+
+func attrs(record *logspb.LogRecord) map[string]string {
+	result := map[string]string{}
+	for _, kv := range record.Attributes {
+		result[kv.Key] = kv.Value.GetStringValue()
+	}
+
+	return result
+}
+
+func TestSender_SendBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol Protocol
+		endpoint func(t *testing.T, collector *fakeCollector) string
+	}{
+		{name: "grpc", protocol: ProtocolGRPC, endpoint: newGRPCCollector},
+		{name: "http", protocol: ProtocolHTTP, endpoint: newHTTPCollector},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collector := &fakeCollector{}
+			sender, err := NewSender[internal.Event](Options{
+				Protocol: tt.protocol,
+				Endpoint: tt.endpoint(t, collector),
+				Insecure: true,
+				Headers:  map[string]string{"x-tenant": "auth"},
+			}, zap.NewNop())
+			require.NoError(t, err)
+			defer sender.Close()
+
+			eventTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+			events := []*internal.Event{
+				{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLogin, RealmName: "master", UserId: uuid.New()},
+				{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLoginError, RealmName: "master", Error: "invalid_user_credentials"},
+			}
+			require.NoError(t, sender.SendBatch(events))
+
+			require.Len(t, collector.requests, 1)
+			assert.Equal(t, []string{"auth"}, collector.headers)
+
+			records := collector.records()
+			require.Len(t, records, 2)
+			assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, records[0].SeverityNumber)
+			assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[1].SeverityNumber)
+			assert.Equal(t, uint64(eventTime.UnixNano()), records[0].ObservedTimeUnixNano)
+			assert.Equal(t, events[0].UserId.String(), attrs(records[0])["enduser.id"])
+			assert.Equal(t, "invalid_user_credentials", attrs(records[1])["error.type"])
+		})
+	}
+}
+
+func TestSender_SendErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector *fakeCollector
+		wantErr   bool
+	}{
+		{name: "collector unavailable", collector: &fakeCollector{fail: true}, wantErr: true},
+		{name: "partial success is not retried", collector: &fakeCollector{rejected: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sender, err := NewSender[internal.Event](Options{
+				Protocol: ProtocolGRPC,
+				Endpoint: newGRPCCollector(t, tt.collector),
+				Insecure: true,
+				Timeout:  time.Second,
+			}, zap.NewNop())
+			require.NoError(t, err)
+			defer sender.Close()
+
+			err = sender.Send(&internal.Event{Id: uuid.New()})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestRecord_AdminEvent(t *testing.T) {
+	t.Parallel()
+
+	adminEvent := &internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmName:     "master",
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		ResourcePath:  "users/1",
+		AuthDetails:   &internal.AuthDetails{UserId: uuid.New(), IpAddress: "10.0.0.1"},
+		Details:       map[string]string{"key": "value"},
+	}
+
+	got := record(adminEvent)
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, got.SeverityNumber)
+
+	attributes := attrs(got)
+	assert.Equal(t, internal.KindAdminEvents, attributes["keycloak.event.kind"])
+	assert.Equal(t, "master", attributes["keycloak.realm.name"])
+	assert.Equal(t, "USER", attributes["keycloak.admin.resource_type"])
+	assert.Equal(t, "users/1", attributes["keycloak.admin.resource_path"])
+	assert.Equal(t, "users", attributes["keycloak.admin.resource_kind"])
+	assert.Equal(t, "1", attributes["keycloak.admin.target_user_id"])
+	assert.NotContains(t, attributes, "keycloak.admin.target_client_id")
+	assert.Equal(t, adminEvent.AuthDetails.UserId.String(), attributes["enduser.id"])
+	assert.Equal(t, "10.0.0.1", attributes["client.address"])
+	assert.Equal(t, "value", attributes["keycloak.details.key"])
+	assert.NotContains(t, attributes, "keycloak.client.id")
+}
+
+func TestRecord_Enrichment(t *testing.T) {
+	t.Parallel()
+
+	event := &internal.Event{
+		Id:        uuid.New(),
+		IpAddress: "81.2.69.142",
+		Enrichment: &internal.Enrichment{
+			Geo: &internal.Geo{
+				Scope:       internal.GeoScopePublic,
+				CountryCode: "GB",
+				City:        "London",
+				Latitude:    51.5142,
+				Longitude:   -0.0931,
+				ASN:         20712,
+			},
+			Route: "siem",
+			Tags:  map[string]string{"env": "prod"},
+		},
+	}
+
+	values := map[string]*commonpb.AnyValue{}
+	for _, kv := range record(event).Attributes {
+		values[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "public", values["keycloak.geo.scope"].GetStringValue())
+	assert.Equal(t, "GB", values["geo.country.iso_code"].GetStringValue())
+	assert.Equal(t, "London", values["geo.locality.name"].GetStringValue())
+	assert.Equal(t, 51.5142, values["geo.location.lat"].GetDoubleValue())
+	assert.Equal(t, -0.0931, values["geo.location.lon"].GetDoubleValue())
+	assert.Equal(t, int64(20712), values["keycloak.geo.asn"].GetIntValue())
+	assert.NotContains(t, values, "keycloak.geo.as_organization")
+	assert.Equal(t, "siem", values["keycloak.route"].GetStringValue())
+	assert.Equal(t, "prod", values["keycloak.tags.env"].GetStringValue())
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty endpoint", opts: Options{}},
+		{name: "unknown protocol", opts: Options{Endpoint: "localhost:4317", Protocol: "udp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/template"
+)
+
+const (
+	detailsPrefix = "details."
+	tagsPrefix    = "tags."
+)
+
+type Options struct {
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	Stream   string
+	MaxLen   int64
+	Timeout  time.Duration
+}
+
+// Sender добавляет события в потоки Redis командой XADD, по полю на атрибут события.
+// Длина потока ограничивается приблизительным MAXLEN, пачки отправляются одним pipeline.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	client  goredis.UniversalClient
+	stream  *template.Template
+	maxLen  int64
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	if opts.Addr == "" {
+		return nil, errors.New("addr is empty")
+	}
+
+	stream, err := template.Parse(opts.Stream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream: %w", err)
+	}
+
+	if opts.MaxLen < 0 {
+		return nil, errors.New("max len must not be negative")
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     opts.Addr,
+		Username: opts.Username,
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+
+	return &Sender[T]{
+		client:  client,
+		stream:  stream,
+		maxLen:  opts.MaxLen,
+		timeout: opts.Timeout,
+		logger:  logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	err := s.client.XAdd(ctx, s.xAddArgs(event)).Err()
+	if err != nil {
+		return internal.Unavailable(fmt.Errorf("can't add event to stream: %w", err))
+	}
+
+	return nil
+}
+
+func (s *Sender[T]) SendBatch(events []*T) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, event := range events {
+			pipe.XAdd(ctx, s.xAddArgs(event))
+		}
+		return nil
+	})
+	if err != nil {
+		return internal.Unavailable(fmt.Errorf("can't add events to stream: %w", err))
+	}
+
+	return nil
+}
+
+func (s *Sender[T]) Close() error {
+	return s.client.Close()
+}
+
+func (s *Sender[T]) xAddArgs(event *T) *goredis.XAddArgs {
+	return &goredis.XAddArgs{
+		Stream: s.stream.Render(template.Fields(event), func(value string) string { return value }),
+		MaxLen: s.maxLen,
+		Approx: s.maxLen > 0,
+		Values: encode(event),
+	}
+}
+
+// encode раскладывает событие на пары поле-значение, детали записываются полями details.<key>
+func encode[T internal.Event | internal.AdminEvent](event *T) []any {
+	var values []any
+	add := func(field, value string) {
+		if value != "" {
+			values = append(values, field, value)
+		}
+	}
+
+	var details map[string]string
+	var enrichment *internal.Enrichment
+	switch e := any(event).(type) {
+	case *internal.Event:
+		add("id", e.Id.String())
+		add("time", formatTime(e.Time))
+		add("type", e.TypeName())
+		add("raw_type", e.RawType)
+		add("realm_id", e.RealmId.String())
+		add("realm_name", e.RealmName)
+		add("client_id", e.ClientId)
+		add("user_id", e.UserId.String())
+		add("session_id", e.SessionId)
+		add("ip_address", e.IpAddress)
+		add("error", e.Error)
+		details = e.Details
+		enrichment = e.Enrichment
+	case *internal.AdminEvent:
+		add("id", e.Id.String())
+		add("time", formatTime(e.Time))
+		add("realm_id", e.RealmId.String())
+		add("realm_name", e.RealmName)
+		if e.AuthDetails != nil {
+			add("auth_details.realm_id", e.AuthDetails.RealmId.String())
+			add("auth_details.realm_name", e.AuthDetails.RealmName)
+			add("auth_details.client_id", e.AuthDetails.ClientId.String())
+			add("auth_details.user_id", e.AuthDetails.UserId.String())
+			add("auth_details.ip_address", e.AuthDetails.IpAddress)
+		}
+		add("resource_type", e.ResourceType)
+		add("operation_type", e.OperationType.String())
+		add("resource_path", e.ResourcePath)
+		if ref := internal.ResourceOf(e); ref != nil {
+			add("resource_kind", ref.Kind)
+			add("target_user_id", ref.TargetUserId)
+			add("target_client_id", ref.TargetClientId)
+			add("target_group_id", ref.TargetGroupId)
+		}
+		add("representation", e.Representation)
+		add("error", e.Error)
+		details = e.Details
+		enrichment = e.Enrichment
+	}
+
+	if enrichment != nil && enrichment.Geo != nil {
+		geo := enrichment.Geo
+		add("geo.scope", string(geo.Scope))
+		add("geo.country_code", geo.CountryCode)
+		add("geo.country_name", geo.CountryName)
+		add("geo.city", geo.City)
+		if geo.Latitude != 0 || geo.Longitude != 0 {
+			add("geo.latitude", strconv.FormatFloat(geo.Latitude, 'f', -1, 64))
+			add("geo.longitude", strconv.FormatFloat(geo.Longitude, 'f', -1, 64))
+		}
+		if geo.ASN != 0 {
+			add("geo.asn", strconv.FormatUint(uint64(geo.ASN), 10))
+		}
+		add("geo.as_organization", geo.ASOrganization)
+	}
+
+	addMap(add, detailsPrefix, details)
+	if enrichment != nil {
+		add("route", enrichment.Route)
+		addMap(add, tagsPrefix, enrichment.Tags)
+	}
+
+	return values
+}
+
+// addMap добавляет значения в порядке ключей с префиксом prefix
+func addMap(add func(field, value string), prefix string, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(prefix+key, values[key])
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
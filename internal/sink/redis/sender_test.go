@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+)
+
+func newTestSender[T internal.Event | internal.AdminEvent](t *testing.T, addr, stream string, maxLen int64) *Sender[T] {
+	t.Helper()
+
+	sender, err := NewSender[T](Options{
+		Addr:    addr,
+		Stream:  stream,
+		MaxLen:  maxLen,
+		Timeout: time.Second,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sender.Close() })
+
+	return sender
+}
+
+func readStream(t *testing.T, addr, stream string) []goredis.XMessage {
+	t.Helper()
+
+	client := goredis.NewClient(&goredis.Options{Addr: addr})
+	defer client.Close()
+
+	messages, err := client.XRange(context.Background(), stream, "-", "+").Result()
+	require.NoError(t, err)
+
+	return messages
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+
+	server := miniredis.RunT(t)
+	sender := newTestSender[internal.Event](t, server.Addr(), "keycloak:{kind}:{realm_name}", 0)
+
+	event := &internal.Event{
+		Id:        uuid.New(),
+		Time:      time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Type:      internal.EventTypeLoginError,
+		RealmId:   uuid.New(),
+		RealmName: "master",
+		ClientId:  "account",
+		UserId:    uuid.New(),
+		IpAddress: "10.0.0.1",
+		Error:     "invalid_user_credentials",
+		Details:   map[string]string{"username": "john", "auth_method": "openid-connect"},
+	}
+	require.NoError(t, sender.Send(event))
+
+	messages := readStream(t, server.Addr(), "keycloak:events:master")
+	require.Len(t, messages, 1)
+	assert.Equal(t, map[string]any{
+		"id":                  event.Id.String(),
+		"time":                "2024-01-15T10:30:00Z",
+		"type":                "LOGIN_ERROR",
+		"realm_id":            event.RealmId.String(),
+		"realm_name":          "master",
+		"client_id":           "account",
+		"user_id":             event.UserId.String(),
+		"ip_address":          "10.0.0.1",
+		"error":               "invalid_user_credentials",
+		"details.username":    "john",
+		"details.auth_method": "openid-connect",
+	}, messages[0].Values)
+}
+
+func TestSender_SendAdmin(t *testing.T) {
+	t.Parallel()
+
+	server := miniredis.RunT(t)
+	sender := newTestSender[internal.AdminEvent](t, server.Addr(), "keycloak:{kind}", 0)
+
+	adminEvent := &internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmName:     "master",
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeDelete,
+		ResourcePath:  "users/1",
+		AuthDetails:   &internal.AuthDetails{IpAddress: "81.2.69.142"},
+		Enrichment: &internal.Enrichment{
+			Geo: &internal.Geo{
+				Scope:       internal.GeoScopePublic,
+				CountryCode: "GB",
+				Latitude:    51.5142,
+				Longitude:   -0.0931,
+				ASN:         20712,
+			},
+			Route: "siem",
+			Tags:  map[string]string{"env": "prod"},
+		},
+	}
+	require.NoError(t, sender.Send(adminEvent))
+
+	messages := readStream(t, server.Addr(), "keycloak:admin_events")
+	require.Len(t, messages, 1)
+	assert.Equal(t, "USER", messages[0].Values["resource_type"])
+	assert.Equal(t, "DELETE", messages[0].Values["operation_type"])
+	assert.Equal(t, "81.2.69.142", messages[0].Values["auth_details.ip_address"])
+	assert.Equal(t, "users", messages[0].Values["resource_kind"])
+	assert.Equal(t, "1", messages[0].Values["target_user_id"])
+	assert.NotContains(t, messages[0].Values, "target_client_id")
+	assert.Equal(t, "public", messages[0].Values["geo.scope"])
+	assert.Equal(t, "GB", messages[0].Values["geo.country_code"])
+	assert.Equal(t, "51.5142", messages[0].Values["geo.latitude"])
+	assert.Equal(t, "-0.0931", messages[0].Values["geo.longitude"])
+	assert.Equal(t, "20712", messages[0].Values["geo.asn"])
+	assert.NotContains(t, messages[0].Values, "geo.city")
+	assert.Equal(t, "siem", messages[0].Values["route"])
+	assert.Equal(t, "prod", messages[0].Values["tags.env"])
+}
+
+func TestSender_SendBatch(t *testing.T) {
+	t.Parallel()
+
+	server := miniredis.RunT(t)
+	sender := newTestSender[internal.Event](t, server.Addr(), "keycloak:{kind}", 3)
+
+	events := make([]*internal.Event, 5)
+	for i := range events {
+		events[i] = &internal.Event{Id: uuid.New(), Details: map[string]string{"n": fmt.Sprint(i)}}
+	}
+	require.NoError(t, sender.SendBatch(events))
+
+	messages := readStream(t, server.Addr(), "keycloak:events")
+	require.Len(t, messages, 3)
+	assert.Equal(t, "2", messages[0].Values["details.n"])
+	assert.Equal(t, "4", messages[2].Values["details.n"])
+}
+
+func TestSender_SendError(t *testing.T) {
+	t.Parallel()
+
+	server := miniredis.RunT(t)
+	sender := newTestSender[internal.Event](t, server.Addr(), "keycloak:{kind}", 0)
+	server.Close()
+
+	assert.Error(t, sender.Send(&internal.Event{Id: uuid.New()}))
+	assert.Error(t, sender.SendBatch([]*internal.Event{{Id: uuid.New()}}))
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty addr", opts: Options{Stream: "keycloak:{kind}"}},
+		{name: "invalid stream", opts: Options{Addr: "localhost:6379", Stream: "keycloak:{unknown}"}},
+		{name: "negative max len", opts: Options{Addr: "localhost:6379", Stream: "keycloak:{kind}", MaxLen: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/parquet-go/parquet-go"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+type Format string
+
+const (
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+type eventRow struct {
+	Id        string            `parquet:"id"`
+	Time      time.Time         `parquet:"time,timestamp(millisecond)"`
+	Type      string            `parquet:"type"`
+	RawType   string            `parquet:"raw_type,optional"`
+	RealmId   string            `parquet:"realm_id"`
+	RealmName string            `parquet:"realm_name"`
+	ClientId  string            `parquet:"client_id"`
+	UserId    string            `parquet:"user_id"`
+	SessionId string            `parquet:"session_id"`
+	IpAddress string            `parquet:"ip_address"`
+	Error     string            `parquet:"error"`
+	Details   map[string]string `parquet:"details"`
+	Geo       *geoRow           `parquet:"geo,optional"`
+	Route     string            `parquet:"route,optional"`
+	Tags      map[string]string `parquet:"tags,optional"`
+}
+
+type adminEventRow struct {
+	Id                   string            `parquet:"id"`
+	Time                 time.Time         `parquet:"time,timestamp(millisecond)"`
+	RealmId              string            `parquet:"realm_id"`
+	RealmName            string            `parquet:"realm_name"`
+	AuthDetailsRealmId   string            `parquet:"auth_details_realm_id"`
+	AuthDetailsRealmName string            `parquet:"auth_details_realm_name"`
+	AuthDetailsClientId  string            `parquet:"auth_details_client_id"`
+	AuthDetailsUserId    string            `parquet:"auth_details_user_id"`
+	AuthDetailsIpAddress string            `parquet:"auth_details_ip_address"`
+	ResourceType         string            `parquet:"resource_type"`
+	OperationType        string            `parquet:"operation_type"`
+	ResourcePath         string            `parquet:"resource_path"`
+	ResourceKind         string            `parquet:"resource_kind,optional"`
+	TargetUserId         string            `parquet:"target_user_id,optional"`
+	TargetClientId       string            `parquet:"target_client_id,optional"`
+	TargetGroupId        string            `parquet:"target_group_id,optional"`
+	Representation       string            `parquet:"representation"`
+	Error                string            `parquet:"error"`
+	Details              map[string]string `parquet:"details"`
+	Geo                  *geoRow           `parquet:"geo,optional"`
+	Route                string            `parquet:"route,optional"`
+	Tags                 map[string]string `parquet:"tags,optional"`
+}
+
+type geoRow struct {
+	Scope          string  `parquet:"scope"`
+	CountryCode    string  `parquet:"country_code"`
+	CountryName    string  `parquet:"country_name"`
+	City           string  `parquet:"city"`
+	Latitude       float64 `parquet:"latitude"`
+	Longitude      float64 `parquet:"longitude"`
+	AccuracyRadius int32   `parquet:"accuracy_radius"`
+	ASN            int64   `parquet:"asn"`
+	ASOrganization string  `parquet:"as_organization"`
+}
+
+// object закодированное содержимое объекта
+type object struct {
+	body        []byte
+	ext         string
+	contentType string
+}
+
+func encode[T internal.Event | internal.AdminEvent](
+	events []*T,
+	format Format,
+	compression Compression,
+	ceMode cloudevents.Mode,
+) (*object, error) {
+	if format == FormatParquet {
+		return encodeParquet(events, compression)
+	}
+
+	return encodeNDJSON(events, compression, ceMode)
+}
+
+func encodeNDJSON[T internal.Event | internal.AdminEvent](
+	events []*T,
+	compression Compression,
+	ceMode cloudevents.Mode,
+) (*object, error) {
+	buf := &bytes.Buffer{}
+	obj := &object{ext: ".ndjson", contentType: "application/x-ndjson"}
+
+	var w io.WriteCloser
+	switch compression {
+	case CompressionGzip:
+		w = gzip.NewWriter(buf)
+		obj.ext += ".gz"
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(buf)
+		if err != nil {
+			return nil, fmt.Errorf("can't create zstd encoder: %w", err)
+		}
+		w = enc
+		obj.ext += ".zst"
+	default:
+		w = nopWriteCloser{buf}
+	}
+
+	for _, event := range events {
+		msg, err := cloudevents.Encode(event, ceMode, cloudevents.Binding{})
+		if err == nil {
+			_, err = w.Write(append(msg.Data, '\n'))
+		}
+		if err != nil {
+			_ = w.Close()
+			return nil, fmt.Errorf("can't encode event: %w", err)
+		}
+	}
+
+	err := w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("can't finish compression: %w", err)
+	}
+	obj.body = buf.Bytes()
+
+	return obj, nil
+}
+
+func encodeParquet[T internal.Event | internal.AdminEvent](events []*T, compression Compression) (*object, error) {
+	var opts []parquet.WriterOption
+	switch compression {
+	case CompressionGzip:
+		opts = append(opts, parquet.Compression(&parquet.Gzip))
+	case CompressionZstd:
+		opts = append(opts, parquet.Compression(&parquet.Zstd))
+	default:
+		opts = append(opts, parquet.Compression(&parquet.Uncompressed))
+	}
+
+	buf := &bytes.Buffer{}
+	var err error
+	switch evs := any(events).(type) {
+	case []*internal.Event:
+		err = writeParquet(buf, eventRows(evs), opts)
+	case []*internal.AdminEvent:
+		err = writeParquet(buf, adminEventRows(evs), opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &object{
+		body:        buf.Bytes(),
+		ext:         ".parquet",
+		contentType: "application/vnd.apache.parquet",
+	}, nil
+}
+
+func writeParquet[R any](w io.Writer, rows []R, opts []parquet.WriterOption) error {
+	writer := parquet.NewGenericWriter[R](w, opts...)
+	_, err := writer.Write(rows)
+	if err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("can't write parquet rows: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("can't close parquet writer: %w", err)
+	}
+
+	return nil
+}
+
+func eventRows(events []*internal.Event) []eventRow {
+	rows := make([]eventRow, 0, len(events))
+	for _, e := range events {
+		row := eventRow{
+			Id:        e.Id.String(),
+			Time:      e.Time,
+			Type:      e.TypeName(),
+			RawType:   e.RawType,
+			RealmId:   e.RealmId.String(),
+			RealmName: e.RealmName,
+			ClientId:  e.ClientId,
+			UserId:    e.UserId.String(),
+			SessionId: e.SessionId,
+			IpAddress: e.IpAddress,
+			Error:     e.Error,
+			Details:   e.Details,
+			Geo:       geoRowOf(e.Enrichment),
+		}
+		if e.Enrichment != nil {
+			row.Route = e.Enrichment.Route
+			row.Tags = e.Enrichment.Tags
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func adminEventRows(events []*internal.AdminEvent) []adminEventRow {
+	rows := make([]adminEventRow, 0, len(events))
+	for _, e := range events {
+		row := adminEventRow{
+			Id:             e.Id.String(),
+			Time:           e.Time,
+			RealmId:        e.RealmId.String(),
+			RealmName:      e.RealmName,
+			ResourceType:   e.ResourceType,
+			OperationType:  e.OperationType.String(),
+			ResourcePath:   e.ResourcePath,
+			Representation: e.Representation,
+			Error:          e.Error,
+			Details:        e.Details,
+			Geo:            geoRowOf(e.Enrichment),
+		}
+		if e.AuthDetails != nil {
+			row.AuthDetailsRealmId = e.AuthDetails.RealmId.String()
+			row.AuthDetailsRealmName = e.AuthDetails.RealmName
+			row.AuthDetailsClientId = e.AuthDetails.ClientId.String()
+			row.AuthDetailsUserId = e.AuthDetails.UserId.String()
+			row.AuthDetailsIpAddress = e.AuthDetails.IpAddress
+		}
+		if ref := internal.ResourceOf(e); ref != nil {
+			row.ResourceKind = ref.Kind
+			row.TargetUserId = ref.TargetUserId
+			row.TargetClientId = ref.TargetClientId
+			row.TargetGroupId = ref.TargetGroupId
+		}
+		if e.Enrichment != nil {
+			row.Route = e.Enrichment.Route
+			row.Tags = e.Enrichment.Tags
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func geoRowOf(enrichment *internal.Enrichment) *geoRow {
+	if enrichment == nil || enrichment.Geo == nil {
+		return nil
+	}
+
+	geo := enrichment.Geo
+	return &geoRow{
+		Scope:          string(geo.Scope),
+		CountryCode:    geo.CountryCode,
+		CountryName:    geo.CountryName,
+		City:           geo.City,
+		Latitude:       geo.Latitude,
+		Longitude:      geo.Longitude,
+		AccuracyRadius: int32(geo.AccuracyRadius),
+		ASN:            int64(geo.ASN),
+		ASOrganization: geo.ASOrganization,
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
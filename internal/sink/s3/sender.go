@@ -0,0 +1,191 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+type Options struct {
+	Endpoint    string
+	Region      string
+	Bucket      string
+	AccessKey   string
+	SecretKey   string
+	UseSSL      bool
+	PathStyle   bool
+	Prefix      string
+	Format      Format
+	Compression Compression
+	Timeout     time.Duration
+	// CloudEvents режим CloudEvents для строк ndjson, поддерживается только структурированный режим
+	CloudEvents cloudevents.Mode
+}
+
+func (o *Options) validate() error {
+	if o.Endpoint == "" {
+		return errors.New("endpoint is empty")
+	}
+	if o.Bucket == "" {
+		return errors.New("bucket is empty")
+	}
+
+	switch o.Format {
+	case "":
+		o.Format = FormatNDJSON
+	case FormatNDJSON, FormatParquet:
+	default:
+		return fmt.Errorf("unknown format: %s", o.Format)
+	}
+
+	err := o.CloudEvents.Validate()
+	if err != nil {
+		return err
+	}
+	if o.CloudEvents == cloudevents.ModeBinary {
+		return errors.New("cloudevents binary mode is not supported by s3 sink")
+	}
+	if o.CloudEvents == cloudevents.ModeStructured && o.Format != FormatNDJSON {
+		return fmt.Errorf("cloudevents are not supported by %s format", o.Format)
+	}
+
+	switch o.Compression {
+	case "":
+		o.Compression = CompressionZstd
+	case CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return fmt.Errorf("unknown compression: %s", o.Compression)
+	}
+
+	if o.Timeout <= 0 {
+		o.Timeout = 30 * time.Second
+	}
+
+	return nil
+}
+
+// Sender складывает события в объекты S3-совместимого хранилища, по объекту на каждую партицию пачки.
+// Пачка считается отправленной только после загрузки всех ее объектов.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	client *minio.Client
+	opts   Options
+	logger *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	bucketLookup := minio.BucketLookupAuto
+	if opts.PathStyle {
+		bucketLookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:       opts.UseSSL,
+		Region:       opts.Region,
+		BucketLookup: bucketLookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't create s3 client: %w", err)
+	}
+
+	return &Sender[T]{
+		client: client,
+		opts:   opts,
+		logger: logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	return s.SendBatch([]*T{event})
+}
+
+func (s *Sender[T]) SendBatch(events []*T) error {
+	partitions := map[string][]*T{}
+	for _, event := range events {
+		key := s.partition(internal.MetaOf(event))
+		partitions[key] = append(partitions[key], event)
+	}
+
+	keys := make([]string, 0, len(partitions))
+	for key := range partitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := s.upload(key, partitions[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Sender[T]) upload(partition string, events []*T) error {
+	obj, err := encode(events, s.opts.Format, s.opts.Compression, s.opts.CloudEvents)
+	if err != nil {
+		return fmt.Errorf("can't encode object: %w", err)
+	}
+
+	key := path.Join(partition, uuid.NewString()+obj.ext)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	_, err = s.client.PutObject(ctx, s.opts.Bucket, key, bytes.NewReader(obj.body), int64(len(obj.body)), minio.PutObjectOptions{
+		ContentType: obj.contentType,
+	})
+	if err != nil {
+		// без ответа хранилища (код 0) или с ответом о перегрузке хранилище недоступно
+		status := minio.ToErrorResponse(err).StatusCode
+		err = fmt.Errorf("can't upload object %s: %w", key, err)
+		if status == 0 || internal.UnavailableStatus(status) {
+			return internal.Unavailable(err)
+		}
+		return err
+	}
+
+	s.logger.Debug("object uploaded", zap.String("key", key), zap.Int("events", len(events)))
+
+	return nil
+}
+
+// partition возвращает префикс ключа вида realm=<name>/kind=<kind>/date=YYYY-MM-DD/hour=HH
+func (s *Sender[T]) partition(meta internal.Meta) string {
+	realm := meta.RealmName
+	if realm == "" {
+		realm = meta.RealmId.String()
+	}
+
+	eventTime := meta.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	eventTime = eventTime.UTC()
+
+	return path.Join(
+		s.opts.Prefix,
+		"realm="+url.PathEscape(realm),
+		"kind="+meta.Kind,
+		"date="+eventTime.Format(time.DateOnly),
+		"hour="+eventTime.Format("15"),
+	)
+}
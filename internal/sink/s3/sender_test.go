@@ -0,0 +1,277 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+type fakeStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	fail    bool
+}
+
+func newFakeStorage(t *testing.T) (*fakeStorage, *httptest.Server) {
+	t.Helper()
+
+	storage := &fakeStorage{objects: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storage.mu.Lock()
+		defer storage.mu.Unlock()
+
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		if storage.fail {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+			return
+		}
+
+		body, err := readBody(r)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		storage.objects[r.URL.Path] = body
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	return storage, server
+}
+
+// readBody читает тело запроса, в том числе в формате aws-chunked с подписью каждого чанка
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body, nil
+		}
+
+		chunk := make([]byte, size+2)
+		_, err = io.ReadFull(reader, chunk)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, chunk[:size]...)
+	}
+}
+
+func newTestSender[T internal.Event | internal.AdminEvent](t *testing.T, server *httptest.Server, format Format) *Sender[T] {
+	t.Helper()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	sender, err := NewSender[T](Options{
+		Endpoint:    u.Host,
+		Region:      "us-east-1",
+		Bucket:      "audit",
+		AccessKey:   "access",
+		SecretKey:   "secret",
+		PathStyle:   true,
+		Prefix:      "keycloak",
+		Format:      format,
+		Compression: CompressionZstd,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	return sender
+}
+
+func TestSender_SendBatch(t *testing.T) {
+	t.Parallel()
+
+	storage, server := newFakeStorage(t)
+	sender := newTestSender[internal.Event](t, server, FormatNDJSON)
+
+	eventTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	events := []*internal.Event{
+		{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLogin, RealmName: "master"},
+		{Id: uuid.New(), Time: eventTime.Add(time.Minute), Type: internal.EventTypeLogout, RealmName: "master"},
+		{Id: uuid.New(), Time: eventTime.Add(time.Hour), Type: internal.EventTypeLogin, RealmName: "master"},
+		{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLoginError, RealmName: "other"},
+	}
+	require.NoError(t, sender.SendBatch(events))
+
+	counts := map[string]int{}
+	for key, body := range storage.objects {
+		assert.True(t, strings.HasSuffix(key, ".ndjson.zst"), key)
+		partition := key[:strings.LastIndex(key, "/")]
+
+		decoder, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(decoder)
+		for scanner.Scan() {
+			var event internal.Event
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+			counts[partition]++
+		}
+		decoder.Close()
+	}
+
+	assert.Equal(t, map[string]int{
+		"/audit/keycloak/realm=master/kind=events/date=2024-01-15/hour=10": 2,
+		"/audit/keycloak/realm=master/kind=events/date=2024-01-15/hour=11": 1,
+		"/audit/keycloak/realm=other/kind=events/date=2024-01-15/hour=10":  1,
+	}, counts)
+}
+
+func TestEncode_CloudEvents(t *testing.T) {
+	t.Parallel()
+
+	events := []*internal.Event{
+		{Id: uuid.New(), Type: internal.EventTypeLogin, RealmName: "master"},
+		{Id: uuid.New(), Type: internal.EventTypeLogout, RealmName: "master"},
+	}
+	obj, err := encode(events, FormatNDJSON, CompressionNone, cloudevents.ModeStructured)
+	require.NoError(t, err)
+	assert.Equal(t, ".ndjson", obj.ext)
+
+	lines := strings.Split(strings.TrimSuffix(string(obj.body), "\n"), "\n")
+	require.Len(t, lines, 2)
+	for i, line := range lines {
+		var ce cloudevents.Event
+		require.NoError(t, json.Unmarshal([]byte(line), &ce))
+		assert.Equal(t, events[i].Id.String(), ce.Id)
+		assert.Equal(t, "keycloak/master", ce.Source)
+	}
+	assert.Contains(t, lines[0], `"type":"org.keycloak.event.LOGIN"`)
+}
+
+func TestEncodeParquet_EventType(t *testing.T) {
+	t.Parallel()
+
+	obj, err := encodeParquet([]*internal.Event{
+		{Id: uuid.New(), Type: internal.EventTypeLogin},
+		{Id: uuid.New(), RawType: "CUSTOM_EVENT"},
+	}, CompressionNone)
+	require.NoError(t, err)
+
+	rows, err := parquet.Read[eventRow](bytes.NewReader(obj.body), int64(len(obj.body)))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "LOGIN", rows[0].Type)
+	assert.Equal(t, "CUSTOM_EVENT", rows[1].Type)
+}
+
+func TestSender_SendBatchParquet(t *testing.T) {
+	t.Parallel()
+
+	storage, server := newFakeStorage(t)
+	sender := newTestSender[internal.AdminEvent](t, server, FormatParquet)
+
+	adminEvent := &internal.AdminEvent{
+		Id:            uuid.New(),
+		Time:          time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		RealmName:     "master",
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		ResourcePath:  "groups/2/role-mappings/clients/3",
+		AuthDetails:   &internal.AuthDetails{IpAddress: "10.0.0.1"},
+		Details:       map[string]string{"key": "value"},
+		Enrichment: &internal.Enrichment{
+			Geo:  &internal.Geo{Scope: internal.GeoScopePrivate},
+			Tags: map[string]string{"env": "prod"},
+		},
+	}
+	require.NoError(t, sender.Send(adminEvent))
+	require.Len(t, storage.objects, 1)
+
+	for key, body := range storage.objects {
+		assert.True(t, strings.HasPrefix(key, "/audit/keycloak/realm=master/kind=admin_events/date=2024-01-15/hour=10/"))
+		assert.True(t, strings.HasSuffix(key, ".parquet"))
+
+		rows, err := parquet.Read[adminEventRow](bytes.NewReader(body), int64(len(body)))
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, adminEvent.Id.String(), rows[0].Id)
+		assert.Equal(t, "10.0.0.1", rows[0].AuthDetailsIpAddress)
+		assert.Equal(t, "CREATE", rows[0].OperationType)
+		assert.Equal(t, map[string]string{"key": "value"}, rows[0].Details)
+		assert.Equal(t, "groups/role-mappings/clients", rows[0].ResourceKind)
+		assert.Equal(t, "2", rows[0].TargetGroupId)
+		assert.Equal(t, "3", rows[0].TargetClientId)
+		assert.Empty(t, rows[0].TargetUserId)
+		require.NotNil(t, rows[0].Geo)
+		assert.Equal(t, "private", rows[0].Geo.Scope)
+		assert.Equal(t, map[string]string{"env": "prod"}, rows[0].Tags)
+	}
+}
+
+func TestSender_SendBatchError(t *testing.T) {
+	t.Parallel()
+
+	storage, server := newFakeStorage(t)
+	storage.fail = true
+	sender := newTestSender[internal.Event](t, server, FormatNDJSON)
+
+	err := sender.SendBatch([]*internal.Event{{Id: uuid.New(), RealmName: "master"}})
+	assert.Error(t, err)
+	assert.Empty(t, storage.objects)
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty endpoint", opts: Options{Bucket: "audit"}},
+		{name: "empty bucket", opts: Options{Endpoint: "localhost:9000"}},
+		{name: "unknown format", opts: Options{Endpoint: "localhost:9000", Bucket: "audit", Format: "avro"}},
+		{name: "unknown compression", opts: Options{Endpoint: "localhost:9000", Bucket: "audit", Compression: "lz4"}},
+		{
+			name: "cloudevents binary",
+			opts: Options{Endpoint: "localhost:9000", Bucket: "audit", CloudEvents: cloudevents.ModeBinary},
+		},
+		{
+			name: "cloudevents parquet",
+			opts: Options{Endpoint: "localhost:9000", Bucket: "audit", Format: FormatParquet, CloudEvents: cloudevents.ModeStructured},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package splunk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+const (
+	eventPath = "/services/collector/event"
+	ackPath   = "/services/collector/ack"
+
+	SourceTypeEvent      = "keycloak:event"
+	SourceTypeAdminEvent = "keycloak:admin_event"
+)
+
+type Options struct {
+	URL string
+	// Token токен HEC по умолчанию
+	Token string
+	// IndexTokens токены HEC для отдельных индексов
+	IndexTokens map[string]string
+	// Index индекс по умолчанию, пустой — индекс токена
+	Index string
+	// RealmIndexes индексы для отдельных realm
+	RealmIndexes map[string]string
+	Source       string
+	Host         string
+	UseAck       bool
+	AckInterval  time.Duration
+	AckTimeout   time.Duration
+	Timeout      time.Duration
+	// CloudEvents режим CloudEvents для поля event конверта, поддерживается только структурированный режим
+	CloudEvents cloudevents.Mode
+}
+
+func (o *Options) validate() error {
+	if o.URL == "" {
+		return errors.New("url is empty")
+	}
+	if o.Token == "" && len(o.IndexTokens) == 0 {
+		return errors.New("token is empty")
+	}
+	o.URL = strings.TrimRight(o.URL, "/")
+
+	err := o.CloudEvents.Validate()
+	if err != nil {
+		return err
+	}
+	// конверты пачки отправляются одним запросом, заголовков отдельного события нет
+	if o.CloudEvents == cloudevents.ModeBinary {
+		return errors.New("cloudevents binary mode is not supported by splunk sink")
+	}
+
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.AckInterval <= 0 {
+		o.AckInterval = time.Second
+	}
+	if o.AckTimeout <= 0 {
+		o.AckTimeout = time.Minute
+	}
+
+	return nil
+}
+
+type envelope struct {
+	Time       float64         `json:"time"`
+	Host       string          `json:"host,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	SourceType string          `json:"sourcetype"`
+	Index      string          `json:"index,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+type eventResponse struct {
+	Text  string  `json:"text"`
+	Code  int     `json:"code"`
+	AckId *uint64 `json:"ackId"`
+}
+
+type ackRequest struct {
+	Acks []uint64 `json:"acks"`
+}
+
+type ackResponse struct {
+	Acks map[string]bool `json:"acks"`
+}
+
+type pendingAck struct {
+	token string
+	id    uint64
+}
+
+// Sender отправляет события в Splunk HTTP Event Collector пачками конвертов, по запросу на индекс.
+// При включенных индексных подтверждениях пачка считается доставленной только после того,
+// как Splunk подтвердит индексацию по ackId канала.
+type Sender[T internal.Event | internal.AdminEvent] struct {
+	opts    Options
+	client  *http.Client
+	channel string
+	logger  *zap.Logger
+}
+
+func NewSender[T internal.Event | internal.AdminEvent](opts Options, logger *zap.Logger) (*Sender[T], error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	return &Sender[T]{
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.Timeout},
+		channel: uuid.NewString(),
+		logger:  logger,
+	}, nil
+}
+
+func (s *Sender[T]) Send(event *T) error {
+	return s.SendBatch([]*T{event})
+}
+
+func (s *Sender[T]) SendBatch(events []*T) error {
+	batches := map[string]*bytes.Buffer{}
+	for _, event := range events {
+		meta := internal.MetaOf(event)
+		index := s.index(meta.RealmName)
+
+		buf, ok := batches[index]
+		if !ok {
+			buf = &bytes.Buffer{}
+			batches[index] = buf
+		}
+
+		msg, err := cloudevents.Encode(event, s.opts.CloudEvents, cloudevents.Binding{})
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(buf).Encode(s.envelope(meta, index, json.RawMessage(msg.Data)))
+		if err != nil {
+			return fmt.Errorf("can't marshal event: %w", err)
+		}
+	}
+
+	indexes := make([]string, 0, len(batches))
+	for index := range batches {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+
+	var acks []pendingAck
+	for _, index := range indexes {
+		token, err := s.token(index)
+		if err != nil {
+			return err
+		}
+
+		ackId, err := s.post(token, batches[index].Bytes())
+		if err != nil {
+			return err
+		}
+		if s.opts.UseAck {
+			if ackId == nil {
+				return errors.New("indexer acknowledgement is not enabled for token")
+			}
+			acks = append(acks, pendingAck{token: token, id: *ackId})
+		}
+	}
+
+	return s.waitAcks(acks)
+}
+
+func (s *Sender[T]) index(realmName string) string {
+	if index, ok := s.opts.RealmIndexes[realmName]; ok {
+		return index
+	}
+
+	return s.opts.Index
+}
+
+func (s *Sender[T]) token(index string) (string, error) {
+	if token, ok := s.opts.IndexTokens[index]; ok {
+		return token, nil
+	}
+	if s.opts.Token == "" {
+		return "", fmt.Errorf("no token for index %q", index)
+	}
+
+	return s.opts.Token, nil
+}
+
+func (s *Sender[T]) envelope(meta internal.Meta, index string, event json.RawMessage) envelope {
+	eventTime := meta.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+
+	sourceType := SourceTypeEvent
+	if meta.Kind == internal.KindAdminEvents {
+		sourceType = SourceTypeAdminEvent
+	}
+
+	return envelope{
+		Time:       float64(eventTime.UnixMilli()) / 1000,
+		Host:       s.opts.Host,
+		Source:     s.opts.Source,
+		SourceType: sourceType,
+		Index:      index,
+		Event:      event,
+	}
+}
+
+func (s *Sender[T]) post(token string, body []byte) (*uint64, error) {
+	var resp eventResponse
+	err := s.do(eventPath, token, body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("can't send events: %w", err)
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("can't send events: %d %s", resp.Code, resp.Text)
+	}
+
+	return resp.AckId, nil
+}
+
+func (s *Sender[T]) waitAcks(acks []pendingAck) error {
+	if len(acks) == 0 {
+		return nil
+	}
+
+	byToken := map[string][]uint64{}
+	for _, ack := range acks {
+		byToken[ack.token] = append(byToken[ack.token], ack.id)
+	}
+
+	deadline := time.Now().Add(s.opts.AckTimeout)
+	for {
+		for token, ids := range byToken {
+			pending, err := s.poll(token, ids)
+			if err != nil {
+				return err
+			}
+			if len(pending) == 0 {
+				delete(byToken, token)
+				continue
+			}
+			byToken[token] = pending
+		}
+
+		if len(byToken) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return internal.Unavailable(errors.New("indexer acknowledgement timeout"))
+		}
+
+		time.Sleep(s.opts.AckInterval)
+	}
+}
+
+// poll возвращает ackId, индексация которых еще не подтверждена
+func (s *Sender[T]) poll(token string, ids []uint64) ([]uint64, error) {
+	body, err := json.Marshal(ackRequest{Acks: ids})
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal ack request: %w", err)
+	}
+
+	var resp ackResponse
+	err = s.do(ackPath, token, body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("can't poll acknowledgements: %w", err)
+	}
+
+	var pending []uint64
+	for _, id := range ids {
+		if !resp.Acks[fmt.Sprint(id)] {
+			pending = append(pending, id)
+		}
+	}
+
+	return pending, nil
+}
+
+func (s *Sender[T]) do(path, token string, body []byte, out any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Splunk "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Splunk-Request-Channel", s.channel)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return internal.Unavailable(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return internal.Unavailable(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+		if internal.UnavailableStatus(resp.StatusCode) {
+			return internal.Unavailable(err)
+		}
+		return err
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,243 @@
+package splunk
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/sink/cloudevents"
+)
+
+type request struct {
+	token     string
+	channel   string
+	envelopes []map[string]any
+}
+
+type fakeCollector struct {
+	mu       sync.Mutex
+	requests []request
+	nextAck  uint64
+	// pollsBeforeAck количество опросов, после которых ackId считается проиндексированным
+	pollsBeforeAck int
+	polls          int
+	noAck          bool
+}
+
+func newFakeCollector(t *testing.T, collector *fakeCollector) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		collector.mu.Lock()
+		defer collector.mu.Unlock()
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Splunk ")
+		channel := r.Header.Get("X-Splunk-Request-Channel")
+
+		switch r.URL.Path {
+		case eventPath:
+			req := request{token: token, channel: channel}
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				var envelope map[string]any
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &envelope))
+				req.envelopes = append(req.envelopes, envelope)
+			}
+			collector.requests = append(collector.requests, req)
+
+			if collector.noAck {
+				_, _ = w.Write([]byte(`{"text":"Success","code":0}`))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"text":"Success","code":0,"ackId":%d}`, collector.nextAck)
+			collector.nextAck++
+		case ackPath:
+			var ackReq ackRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ackReq))
+
+			collector.polls++
+			acks := map[string]bool{}
+			for _, id := range ackReq.Acks {
+				acks[fmt.Sprint(id)] = collector.polls > collector.pollsBeforeAck
+			}
+			_ = json.NewEncoder(w).Encode(ackResponse{Acks: acks})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newTestOptions(server *httptest.Server) Options {
+	return Options{
+		URL:          server.URL,
+		Token:        "default-token",
+		IndexTokens:  map[string]string{"security": "security-token"},
+		Index:        "main",
+		RealmIndexes: map[string]string{"master": "security"},
+		Source:       "keycloak",
+		UseAck:       true,
+		AckInterval:  10 * time.Millisecond,
+		AckTimeout:   time.Second,
+	}
+}
+
+func TestSender_SendBatch(t *testing.T) {
+	t.Parallel()
+
+	collector := &fakeCollector{pollsBeforeAck: 2}
+	server := newFakeCollector(t, collector)
+	sender, err := NewSender[internal.Event](newTestOptions(server), zap.NewNop())
+	require.NoError(t, err)
+
+	eventTime := time.Date(2024, 1, 15, 10, 30, 0, 500_000_000, time.UTC)
+	events := []*internal.Event{
+		{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLogin, RealmName: "master"},
+		{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLogout, RealmName: "master"},
+		{Id: uuid.New(), Time: eventTime, Type: internal.EventTypeLogin, RealmName: "other"},
+	}
+	require.NoError(t, sender.SendBatch(events))
+
+	require.Len(t, collector.requests, 2)
+	assert.GreaterOrEqual(t, collector.polls, 3)
+
+	main, security := collector.requests[0], collector.requests[1]
+	assert.Equal(t, "default-token", main.token)
+	assert.Equal(t, "security-token", security.token)
+	assert.Equal(t, main.channel, security.channel)
+	assert.NotEmpty(t, main.channel)
+
+	require.Len(t, main.envelopes, 1)
+	require.Len(t, security.envelopes, 2)
+
+	envelope := security.envelopes[0]
+	assert.Equal(t, "security", envelope["index"])
+	assert.Equal(t, SourceTypeEvent, envelope["sourcetype"])
+	assert.Equal(t, "keycloak", envelope["source"])
+	assert.InDelta(t, float64(eventTime.UnixMilli())/1000, envelope["time"], 0.001)
+	assert.Equal(t, events[0].Id.String(), envelope["event"].(map[string]any)["id"])
+	assert.Equal(t, "main", main.envelopes[0]["index"])
+}
+
+func TestSender_SendAdmin(t *testing.T) {
+	t.Parallel()
+
+	collector := &fakeCollector{}
+	server := newFakeCollector(t, collector)
+	opts := newTestOptions(server)
+	opts.UseAck = false
+	sender, err := NewSender[internal.AdminEvent](opts, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, sender.Send(&internal.AdminEvent{Id: uuid.New(), RealmName: "other", ResourceType: "USER"}))
+
+	require.Len(t, collector.requests, 1)
+	assert.Equal(t, SourceTypeAdminEvent, collector.requests[0].envelopes[0]["sourcetype"])
+	assert.Zero(t, collector.polls)
+}
+
+func TestSender_SendCloudEvents(t *testing.T) {
+	t.Parallel()
+
+	collector := &fakeCollector{}
+	server := newFakeCollector(t, collector)
+	opts := newTestOptions(server)
+	opts.UseAck = false
+	opts.CloudEvents = cloudevents.ModeStructured
+	sender, err := NewSender[internal.AdminEvent](opts, zap.NewNop())
+	require.NoError(t, err)
+
+	event := &internal.AdminEvent{
+		Id:            uuid.New(),
+		RealmName:     "master",
+		ResourceType:  "USER",
+		OperationType: internal.OperationTypeCreate,
+		ResourcePath:  "users/1",
+	}
+	require.NoError(t, sender.Send(event))
+
+	require.Len(t, collector.requests, 1)
+	envelope := collector.requests[0].envelopes[0]
+	assert.Equal(t, SourceTypeAdminEvent, envelope["sourcetype"])
+
+	ce := envelope["event"].(map[string]any)
+	assert.Equal(t, cloudevents.SpecVersion, ce["specversion"])
+	assert.Equal(t, event.Id.String(), ce["id"])
+	assert.Equal(t, "keycloak/master", ce["source"])
+	assert.Equal(t, "org.keycloak.admin.USER.CREATE", ce["type"])
+	assert.Equal(t, "users/1", ce["subject"])
+	assert.Equal(t, event.Id.String(), ce["data"].(map[string]any)["id"])
+
+	opts.CloudEvents = cloudevents.ModeBinary
+	_, err = NewSender[internal.AdminEvent](opts, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestSender_SendAckErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector *fakeCollector
+	}{
+		{name: "ack timeout", collector: &fakeCollector{pollsBeforeAck: 1000}},
+		{name: "ack disabled for token", collector: &fakeCollector{noAck: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newFakeCollector(t, tt.collector)
+			opts := newTestOptions(server)
+			opts.AckTimeout = 50 * time.Millisecond
+			sender, err := NewSender[internal.Event](opts, zap.NewNop())
+			require.NoError(t, err)
+
+			assert.Error(t, sender.Send(&internal.Event{Id: uuid.New(), RealmName: "master"}))
+		})
+	}
+}
+
+func TestSender_SendHTTPError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"text":"Invalid token","code":4}`))
+	}))
+	t.Cleanup(server.Close)
+
+	sender, err := NewSender[internal.Event](newTestOptions(server), zap.NewNop())
+	require.NoError(t, err)
+
+	assert.Error(t, sender.Send(&internal.Event{Id: uuid.New()}))
+}
+
+func TestNewSender_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "empty url", opts: Options{Token: "token"}},
+		{name: "empty token", opts: Options{URL: "http://localhost:8088"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewSender[internal.Event](tt.opts, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
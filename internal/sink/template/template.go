@@ -0,0 +1,134 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"keycloak-events-adapter/internal"
+)
+
+const (
+	FieldKind          = "kind"
+	FieldRealmName     = "realm_name"
+	FieldRealmId       = "realm_id"
+	FieldEventType     = "event_type"
+	FieldClientId      = "client_id"
+	FieldUserId        = "user_id"
+	FieldResourceType  = "resource_type"
+	FieldOperationType = "operation_type"
+	FieldOutcome       = "outcome"
+	FieldRoute         = "route"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+var knownFields = map[string]bool{
+	FieldKind:          true,
+	FieldRealmName:     true,
+	FieldRealmId:       true,
+	FieldEventType:     true,
+	FieldClientId:      true,
+	FieldUserId:        true,
+	FieldResourceType:  true,
+	FieldOperationType: true,
+	FieldOutcome:       true,
+	FieldRoute:         true,
+}
+
+type segment struct {
+	literal string
+	field   string
+}
+
+// Template шаблон вида keycloak.{realm_name}.events.{event_type} для имен топиков, субъектов и ключей маршрутизации
+type Template struct {
+	segments []segment
+}
+
+func Parse(pattern string) (*Template, error) {
+	if pattern == "" {
+		return nil, errors.New("template is empty")
+	}
+
+	t := &Template{}
+	rest := pattern
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.segments = append(t.segments, segment{literal: rest})
+			break
+		}
+		if open > 0 {
+			t.segments = append(t.segments, segment{literal: rest[:open]})
+		}
+
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder in template %q", pattern)
+		}
+
+		field := rest[open+1 : open+end]
+		if !knownFields[field] {
+			return nil, fmt.Errorf("unknown placeholder {%s} in template %q", field, pattern)
+		}
+		t.segments = append(t.segments, segment{field: field})
+		rest = rest[open+end+1:]
+	}
+
+	return t, nil
+}
+
+// Render подставляет значения полей, пропуская каждое через escape
+func (t *Template) Render(fields map[string]string, escape func(string) string) string {
+	sb := strings.Builder{}
+	for _, s := range t.segments {
+		if s.field == "" {
+			sb.WriteString(s.literal)
+			continue
+		}
+		sb.WriteString(escape(fields[s.field]))
+	}
+
+	return sb.String()
+}
+
+// Fields возвращает значения полей события, доступные в шаблонах
+func Fields[T internal.Event | internal.AdminEvent](event *T) map[string]string {
+	meta := internal.MetaOf(event)
+	fields := map[string]string{
+		FieldKind:      meta.Kind,
+		FieldRealmName: meta.RealmName,
+		FieldRealmId:   meta.RealmId.String(),
+	}
+	if meta.Enrichment != nil {
+		fields[FieldRoute] = meta.Enrichment.Route
+	}
+
+	var eventError string
+	switch e := any(event).(type) {
+	case *internal.Event:
+		fields[FieldEventType] = e.TypeName()
+		fields[FieldClientId] = e.ClientId
+		fields[FieldUserId] = e.UserId.String()
+		eventError = e.Error
+	case *internal.AdminEvent:
+		fields[FieldResourceType] = e.ResourceType
+		fields[FieldOperationType] = fmt.Sprint(e.OperationType)
+		if e.AuthDetails != nil {
+			fields[FieldClientId] = e.AuthDetails.ClientId.String()
+			fields[FieldUserId] = e.AuthDetails.UserId.String()
+		}
+		eventError = e.Error
+	}
+
+	fields[FieldOutcome] = OutcomeSuccess
+	if eventError != "" {
+		fields[FieldOutcome] = OutcomeError
+	}
+
+	return fields
+}
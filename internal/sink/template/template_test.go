@@ -0,0 +1,99 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"keycloak-events-adapter/internal"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "placeholders", pattern: "keycloak.{realm_name}.events.{event_type}"},
+		{name: "literal only", pattern: "keycloak.events"},
+		{name: "placeholder only", pattern: "{kind}"},
+		{name: "empty", pattern: "", wantErr: true},
+		{name: "unknown placeholder", pattern: "keycloak.{realm}", wantErr: true},
+		{name: "unclosed placeholder", pattern: "keycloak.{realm_name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(tt.pattern)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	realmId := uuid.New()
+	tests := []struct {
+		name    string
+		pattern string
+		fields  map[string]string
+		want    string
+	}{
+		{
+			name:    "event",
+			pattern: "keycloak.{realm_name}.events.{event_type}",
+			fields:  Fields(&internal.Event{RealmName: "master", Type: internal.EventTypeLogin}),
+			want:    "keycloak.master.events.LOGIN",
+		},
+		{
+			name:    "unknown event type",
+			pattern: "keycloak.{realm_name}.events.{event_type}",
+			fields:  Fields(&internal.Event{RealmName: "master", RawType: "JWT_AUTHORIZATION_GRANT"}),
+			want:    "keycloak.master.events.JWT_AUTHORIZATION_GRANT",
+		},
+		{
+			name:    "admin event",
+			pattern: "keycloak.{realm_name}.admin.{resource_type}.{operation_type}",
+			fields: Fields(&internal.AdminEvent{
+				RealmName:     "my.realm",
+				ResourceType:  "USER",
+				OperationType: internal.OperationTypeCreate,
+			}),
+			want: "keycloak.my_realm.admin.USER.CREATE",
+		},
+		{
+			name:    "outcome and realm id",
+			pattern: "{kind}/{realm_id}/{outcome}",
+			fields:  Fields(&internal.Event{RealmId: realmId, Error: "invalid_user_credentials"}),
+			want:    "events/" + realmId.String() + "/error",
+		},
+		{
+			name:    "route",
+			pattern: "keycloak.{route}.{realm_name}",
+			fields: Fields(&internal.AdminEvent{
+				RealmName:  "master",
+				Enrichment: &internal.Enrichment{Route: "siem"},
+			}),
+			want: "keycloak.siem.master",
+		},
+		{
+			name:    "missing field",
+			pattern: "keycloak.{resource_type}",
+			fields:  Fields(&internal.Event{}),
+			want:    "keycloak.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, err := Parse(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tmpl.Render(tt.fields, func(s string) string {
+				return strings.ReplaceAll(s, ".", "_")
+			}))
+		})
+	}
+}
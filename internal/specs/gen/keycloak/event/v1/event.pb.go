@@ -347,6 +347,14 @@ const (
 	EventType_EVENT_TYPE_USER_SESSION_DELETED EventType = 119
 	// User session deletion error event
 	EventType_EVENT_TYPE_USER_SESSION_DELETED_ERROR EventType = 120
+	// Verifiable credential request event
+	EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST EventType = 121
+	// Verifiable credential request error event
+	EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST_ERROR EventType = 122
+	// Verifiable credential pre-authorized code grant event
+	EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT EventType = 123
+	// Verifiable credential pre-authorized code grant error event
+	EventType_EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT_ERROR EventType = 124
 )
 
 // Enum value maps for EventType.
@@ -473,129 +481,137 @@ var (
 		118: "EVENT_TYPE_INVITE_ORG_ERROR",
 		119: "EVENT_TYPE_USER_SESSION_DELETED",
 		120: "EVENT_TYPE_USER_SESSION_DELETED_ERROR",
+		121: "EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST",
+		122: "EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST_ERROR",
+		123: "EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT",
+		124: "EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT_ERROR",
 	}
 	EventType_value = map[string]int32{
-		"EVENT_TYPE_INVALID":                                  0,
-		"EVENT_TYPE_LOGIN":                                    1,
-		"EVENT_TYPE_LOGIN_ERROR":                              2,
-		"EVENT_TYPE_REGISTER":                                 3,
-		"EVENT_TYPE_REGISTER_ERROR":                           4,
-		"EVENT_TYPE_LOGOUT":                                   5,
-		"EVENT_TYPE_LOGOUT_ERROR":                             6,
-		"EVENT_TYPE_CODE_TO_TOKEN":                            7,
-		"EVENT_TYPE_CODE_TO_TOKEN_ERROR":                      8,
-		"EVENT_TYPE_CLIENT_LOGIN":                             9,
-		"EVENT_TYPE_CLIENT_LOGIN_ERROR":                       10,
-		"EVENT_TYPE_REFRESH_TOKEN":                            11,
-		"EVENT_TYPE_REFRESH_TOKEN_ERROR":                      12,
-		"EVENT_TYPE_VALIDATE_ACCESS_TOKEN":                    13,
-		"EVENT_TYPE_VALIDATE_ACCESS_TOKEN_ERROR":              14,
-		"EVENT_TYPE_INTROSPECT_TOKEN":                         15,
-		"EVENT_TYPE_INTROSPECT_TOKEN_ERROR":                   16,
-		"EVENT_TYPE_FEDERATED_IDENTITY_LINK":                  17,
-		"EVENT_TYPE_FEDERATED_IDENTITY_LINK_ERROR":            18,
-		"EVENT_TYPE_REMOVE_FEDERATED_IDENTITY":                19,
-		"EVENT_TYPE_REMOVE_FEDERATED_IDENTITY_ERROR":          20,
-		"EVENT_TYPE_UPDATE_EMAIL":                             21,
-		"EVENT_TYPE_UPDATE_EMAIL_ERROR":                       22,
-		"EVENT_TYPE_UPDATE_PROFILE":                           23,
-		"EVENT_TYPE_UPDATE_PROFILE_ERROR":                     24,
-		"EVENT_TYPE_UPDATE_PASSWORD":                          25,
-		"EVENT_TYPE_UPDATE_PASSWORD_ERROR":                    26,
-		"EVENT_TYPE_UPDATE_TOTP":                              27,
-		"EVENT_TYPE_UPDATE_TOTP_ERROR":                        28,
-		"EVENT_TYPE_VERIFY_EMAIL":                             29,
-		"EVENT_TYPE_VERIFY_EMAIL_ERROR":                       30,
-		"EVENT_TYPE_VERIFY_PROFILE":                           31,
-		"EVENT_TYPE_VERIFY_PROFILE_ERROR":                     32,
-		"EVENT_TYPE_REMOVE_TOTP":                              33,
-		"EVENT_TYPE_REMOVE_TOTP_ERROR":                        34,
-		"EVENT_TYPE_GRANT_CONSENT":                            35,
-		"EVENT_TYPE_GRANT_CONSENT_ERROR":                      36,
-		"EVENT_TYPE_UPDATE_CONSENT":                           37,
-		"EVENT_TYPE_UPDATE_CONSENT_ERROR":                     38,
-		"EVENT_TYPE_REVOKE_GRANT":                             39,
-		"EVENT_TYPE_REVOKE_GRANT_ERROR":                       40,
-		"EVENT_TYPE_SEND_VERIFY_EMAIL":                        41,
-		"EVENT_TYPE_SEND_VERIFY_EMAIL_ERROR":                  42,
-		"EVENT_TYPE_SEND_RESET_PASSWORD":                      43,
-		"EVENT_TYPE_SEND_RESET_PASSWORD_ERROR":                44,
-		"EVENT_TYPE_SEND_IDENTITY_PROVIDER_LINK":              45,
-		"EVENT_TYPE_SEND_IDENTITY_PROVIDER_LINK_ERROR":        46,
-		"EVENT_TYPE_RESET_PASSWORD":                           47,
-		"EVENT_TYPE_RESET_PASSWORD_ERROR":                     48,
-		"EVENT_TYPE_RESTART_AUTHENTICATION":                   49,
-		"EVENT_TYPE_RESTART_AUTHENTICATION_ERROR":             50,
-		"EVENT_TYPE_INVALID_SIGNATURE":                        51,
-		"EVENT_TYPE_INVALID_SIGNATURE_ERROR":                  52,
-		"EVENT_TYPE_REGISTER_NODE":                            53,
-		"EVENT_TYPE_REGISTER_NODE_ERROR":                      54,
-		"EVENT_TYPE_UNREGISTER_NODE":                          55,
-		"EVENT_TYPE_UNREGISTER_NODE_ERROR":                    56,
-		"EVENT_TYPE_USER_INFO_REQUEST":                        57,
-		"EVENT_TYPE_USER_INFO_REQUEST_ERROR":                  58,
-		"EVENT_TYPE_IDENTITY_PROVIDER_LINK_ACCOUNT":           59,
-		"EVENT_TYPE_IDENTITY_PROVIDER_LINK_ACCOUNT_ERROR":     60,
-		"EVENT_TYPE_IDENTITY_PROVIDER_LOGIN":                  61,
-		"EVENT_TYPE_IDENTITY_PROVIDER_LOGIN_ERROR":            62,
-		"EVENT_TYPE_IDENTITY_PROVIDER_FIRST_LOGIN":            63,
-		"EVENT_TYPE_IDENTITY_PROVIDER_FIRST_LOGIN_ERROR":      64,
-		"EVENT_TYPE_IDENTITY_PROVIDER_POST_LOGIN":             65,
-		"EVENT_TYPE_IDENTITY_PROVIDER_POST_LOGIN_ERROR":       66,
-		"EVENT_TYPE_IDENTITY_PROVIDER_RESPONSE":               67,
-		"EVENT_TYPE_IDENTITY_PROVIDER_RESPONSE_ERROR":         68,
-		"EVENT_TYPE_IDENTITY_PROVIDER_RETRIEVE_TOKEN":         69,
-		"EVENT_TYPE_IDENTITY_PROVIDER_RETRIEVE_TOKEN_ERROR":   70,
-		"EVENT_TYPE_IMPERSONATE":                              71,
-		"EVENT_TYPE_IMPERSONATE_ERROR":                        72,
-		"EVENT_TYPE_CUSTOM_REQUIRED_ACTION":                   73,
-		"EVENT_TYPE_CUSTOM_REQUIRED_ACTION_ERROR":             74,
-		"EVENT_TYPE_EXECUTE_ACTIONS":                          75,
-		"EVENT_TYPE_EXECUTE_ACTIONS_ERROR":                    76,
-		"EVENT_TYPE_EXECUTE_ACTION_TOKEN":                     77,
-		"EVENT_TYPE_EXECUTE_ACTION_TOKEN_ERROR":               78,
-		"EVENT_TYPE_CLIENT_INFO":                              79,
-		"EVENT_TYPE_CLIENT_INFO_ERROR":                        80,
-		"EVENT_TYPE_CLIENT_REGISTER":                          81,
-		"EVENT_TYPE_CLIENT_REGISTER_ERROR":                    82,
-		"EVENT_TYPE_CLIENT_UPDATE":                            83,
-		"EVENT_TYPE_CLIENT_UPDATE_ERROR":                      84,
-		"EVENT_TYPE_CLIENT_DELETE":                            85,
-		"EVENT_TYPE_CLIENT_DELETE_ERROR":                      86,
-		"EVENT_TYPE_CLIENT_INITIATED_ACCOUNT_LINKING":         87,
-		"EVENT_TYPE_CLIENT_INITIATED_ACCOUNT_LINKING_ERROR":   88,
-		"EVENT_TYPE_TOKEN_EXCHANGE":                           89,
-		"EVENT_TYPE_TOKEN_EXCHANGE_ERROR":                     90,
-		"EVENT_TYPE_OAUTH2_DEVICE_AUTH":                       91,
-		"EVENT_TYPE_OAUTH2_DEVICE_AUTH_ERROR":                 92,
-		"EVENT_TYPE_OAUTH2_DEVICE_VERIFY_USER_CODE":           93,
-		"EVENT_TYPE_OAUTH2_DEVICE_VERIFY_USER_CODE_ERROR":     94,
-		"EVENT_TYPE_OAUTH2_DEVICE_CODE_TO_TOKEN":              95,
-		"EVENT_TYPE_OAUTH2_DEVICE_CODE_TO_TOKEN_ERROR":        96,
-		"EVENT_TYPE_AUTHREQID_TO_TOKEN":                       97,
-		"EVENT_TYPE_AUTHREQID_TO_TOKEN_ERROR":                 98,
-		"EVENT_TYPE_PERMISSION_TOKEN":                         99,
-		"EVENT_TYPE_PERMISSION_TOKEN_ERROR":                   100,
-		"EVENT_TYPE_DELETE_ACCOUNT":                           101,
-		"EVENT_TYPE_DELETE_ACCOUNT_ERROR":                     102,
-		"EVENT_TYPE_PUSHED_AUTHORIZATION_REQUEST":             103,
-		"EVENT_TYPE_PUSHED_AUTHORIZATION_REQUEST_ERROR":       104,
-		"EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT":       105,
-		"EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT_ERROR": 106,
-		"EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT":       107,
-		"EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT_ERROR": 108,
-		"EVENT_TYPE_OAUTH2_EXTENSION_GRANT":                   109,
-		"EVENT_TYPE_OAUTH2_EXTENSION_GRANT_ERROR":             110,
-		"EVENT_TYPE_FEDERATED_IDENTITY_OVERRIDE_LINK":         111,
-		"EVENT_TYPE_FEDERATED_IDENTITY_OVERRIDE_LINK_ERROR":   112,
-		"EVENT_TYPE_UPDATE_CREDENTIAL":                        113,
-		"EVENT_TYPE_UPDATE_CREDENTIAL_ERROR":                  114,
-		"EVENT_TYPE_REMOVE_CREDENTIAL":                        115,
-		"EVENT_TYPE_REMOVE_CREDENTIAL_ERROR":                  116,
-		"EVENT_TYPE_INVITE_ORG":                               117,
-		"EVENT_TYPE_INVITE_ORG_ERROR":                         118,
-		"EVENT_TYPE_USER_SESSION_DELETED":                     119,
-		"EVENT_TYPE_USER_SESSION_DELETED_ERROR":               120,
+		"EVENT_TYPE_INVALID":                                          0,
+		"EVENT_TYPE_LOGIN":                                            1,
+		"EVENT_TYPE_LOGIN_ERROR":                                      2,
+		"EVENT_TYPE_REGISTER":                                         3,
+		"EVENT_TYPE_REGISTER_ERROR":                                   4,
+		"EVENT_TYPE_LOGOUT":                                           5,
+		"EVENT_TYPE_LOGOUT_ERROR":                                     6,
+		"EVENT_TYPE_CODE_TO_TOKEN":                                    7,
+		"EVENT_TYPE_CODE_TO_TOKEN_ERROR":                              8,
+		"EVENT_TYPE_CLIENT_LOGIN":                                     9,
+		"EVENT_TYPE_CLIENT_LOGIN_ERROR":                               10,
+		"EVENT_TYPE_REFRESH_TOKEN":                                    11,
+		"EVENT_TYPE_REFRESH_TOKEN_ERROR":                              12,
+		"EVENT_TYPE_VALIDATE_ACCESS_TOKEN":                            13,
+		"EVENT_TYPE_VALIDATE_ACCESS_TOKEN_ERROR":                      14,
+		"EVENT_TYPE_INTROSPECT_TOKEN":                                 15,
+		"EVENT_TYPE_INTROSPECT_TOKEN_ERROR":                           16,
+		"EVENT_TYPE_FEDERATED_IDENTITY_LINK":                          17,
+		"EVENT_TYPE_FEDERATED_IDENTITY_LINK_ERROR":                    18,
+		"EVENT_TYPE_REMOVE_FEDERATED_IDENTITY":                        19,
+		"EVENT_TYPE_REMOVE_FEDERATED_IDENTITY_ERROR":                  20,
+		"EVENT_TYPE_UPDATE_EMAIL":                                     21,
+		"EVENT_TYPE_UPDATE_EMAIL_ERROR":                               22,
+		"EVENT_TYPE_UPDATE_PROFILE":                                   23,
+		"EVENT_TYPE_UPDATE_PROFILE_ERROR":                             24,
+		"EVENT_TYPE_UPDATE_PASSWORD":                                  25,
+		"EVENT_TYPE_UPDATE_PASSWORD_ERROR":                            26,
+		"EVENT_TYPE_UPDATE_TOTP":                                      27,
+		"EVENT_TYPE_UPDATE_TOTP_ERROR":                                28,
+		"EVENT_TYPE_VERIFY_EMAIL":                                     29,
+		"EVENT_TYPE_VERIFY_EMAIL_ERROR":                               30,
+		"EVENT_TYPE_VERIFY_PROFILE":                                   31,
+		"EVENT_TYPE_VERIFY_PROFILE_ERROR":                             32,
+		"EVENT_TYPE_REMOVE_TOTP":                                      33,
+		"EVENT_TYPE_REMOVE_TOTP_ERROR":                                34,
+		"EVENT_TYPE_GRANT_CONSENT":                                    35,
+		"EVENT_TYPE_GRANT_CONSENT_ERROR":                              36,
+		"EVENT_TYPE_UPDATE_CONSENT":                                   37,
+		"EVENT_TYPE_UPDATE_CONSENT_ERROR":                             38,
+		"EVENT_TYPE_REVOKE_GRANT":                                     39,
+		"EVENT_TYPE_REVOKE_GRANT_ERROR":                               40,
+		"EVENT_TYPE_SEND_VERIFY_EMAIL":                                41,
+		"EVENT_TYPE_SEND_VERIFY_EMAIL_ERROR":                          42,
+		"EVENT_TYPE_SEND_RESET_PASSWORD":                              43,
+		"EVENT_TYPE_SEND_RESET_PASSWORD_ERROR":                        44,
+		"EVENT_TYPE_SEND_IDENTITY_PROVIDER_LINK":                      45,
+		"EVENT_TYPE_SEND_IDENTITY_PROVIDER_LINK_ERROR":                46,
+		"EVENT_TYPE_RESET_PASSWORD":                                   47,
+		"EVENT_TYPE_RESET_PASSWORD_ERROR":                             48,
+		"EVENT_TYPE_RESTART_AUTHENTICATION":                           49,
+		"EVENT_TYPE_RESTART_AUTHENTICATION_ERROR":                     50,
+		"EVENT_TYPE_INVALID_SIGNATURE":                                51,
+		"EVENT_TYPE_INVALID_SIGNATURE_ERROR":                          52,
+		"EVENT_TYPE_REGISTER_NODE":                                    53,
+		"EVENT_TYPE_REGISTER_NODE_ERROR":                              54,
+		"EVENT_TYPE_UNREGISTER_NODE":                                  55,
+		"EVENT_TYPE_UNREGISTER_NODE_ERROR":                            56,
+		"EVENT_TYPE_USER_INFO_REQUEST":                                57,
+		"EVENT_TYPE_USER_INFO_REQUEST_ERROR":                          58,
+		"EVENT_TYPE_IDENTITY_PROVIDER_LINK_ACCOUNT":                   59,
+		"EVENT_TYPE_IDENTITY_PROVIDER_LINK_ACCOUNT_ERROR":             60,
+		"EVENT_TYPE_IDENTITY_PROVIDER_LOGIN":                          61,
+		"EVENT_TYPE_IDENTITY_PROVIDER_LOGIN_ERROR":                    62,
+		"EVENT_TYPE_IDENTITY_PROVIDER_FIRST_LOGIN":                    63,
+		"EVENT_TYPE_IDENTITY_PROVIDER_FIRST_LOGIN_ERROR":              64,
+		"EVENT_TYPE_IDENTITY_PROVIDER_POST_LOGIN":                     65,
+		"EVENT_TYPE_IDENTITY_PROVIDER_POST_LOGIN_ERROR":               66,
+		"EVENT_TYPE_IDENTITY_PROVIDER_RESPONSE":                       67,
+		"EVENT_TYPE_IDENTITY_PROVIDER_RESPONSE_ERROR":                 68,
+		"EVENT_TYPE_IDENTITY_PROVIDER_RETRIEVE_TOKEN":                 69,
+		"EVENT_TYPE_IDENTITY_PROVIDER_RETRIEVE_TOKEN_ERROR":           70,
+		"EVENT_TYPE_IMPERSONATE":                                      71,
+		"EVENT_TYPE_IMPERSONATE_ERROR":                                72,
+		"EVENT_TYPE_CUSTOM_REQUIRED_ACTION":                           73,
+		"EVENT_TYPE_CUSTOM_REQUIRED_ACTION_ERROR":                     74,
+		"EVENT_TYPE_EXECUTE_ACTIONS":                                  75,
+		"EVENT_TYPE_EXECUTE_ACTIONS_ERROR":                            76,
+		"EVENT_TYPE_EXECUTE_ACTION_TOKEN":                             77,
+		"EVENT_TYPE_EXECUTE_ACTION_TOKEN_ERROR":                       78,
+		"EVENT_TYPE_CLIENT_INFO":                                      79,
+		"EVENT_TYPE_CLIENT_INFO_ERROR":                                80,
+		"EVENT_TYPE_CLIENT_REGISTER":                                  81,
+		"EVENT_TYPE_CLIENT_REGISTER_ERROR":                            82,
+		"EVENT_TYPE_CLIENT_UPDATE":                                    83,
+		"EVENT_TYPE_CLIENT_UPDATE_ERROR":                              84,
+		"EVENT_TYPE_CLIENT_DELETE":                                    85,
+		"EVENT_TYPE_CLIENT_DELETE_ERROR":                              86,
+		"EVENT_TYPE_CLIENT_INITIATED_ACCOUNT_LINKING":                 87,
+		"EVENT_TYPE_CLIENT_INITIATED_ACCOUNT_LINKING_ERROR":           88,
+		"EVENT_TYPE_TOKEN_EXCHANGE":                                   89,
+		"EVENT_TYPE_TOKEN_EXCHANGE_ERROR":                             90,
+		"EVENT_TYPE_OAUTH2_DEVICE_AUTH":                               91,
+		"EVENT_TYPE_OAUTH2_DEVICE_AUTH_ERROR":                         92,
+		"EVENT_TYPE_OAUTH2_DEVICE_VERIFY_USER_CODE":                   93,
+		"EVENT_TYPE_OAUTH2_DEVICE_VERIFY_USER_CODE_ERROR":             94,
+		"EVENT_TYPE_OAUTH2_DEVICE_CODE_TO_TOKEN":                      95,
+		"EVENT_TYPE_OAUTH2_DEVICE_CODE_TO_TOKEN_ERROR":                96,
+		"EVENT_TYPE_AUTHREQID_TO_TOKEN":                               97,
+		"EVENT_TYPE_AUTHREQID_TO_TOKEN_ERROR":                         98,
+		"EVENT_TYPE_PERMISSION_TOKEN":                                 99,
+		"EVENT_TYPE_PERMISSION_TOKEN_ERROR":                           100,
+		"EVENT_TYPE_DELETE_ACCOUNT":                                   101,
+		"EVENT_TYPE_DELETE_ACCOUNT_ERROR":                             102,
+		"EVENT_TYPE_PUSHED_AUTHORIZATION_REQUEST":                     103,
+		"EVENT_TYPE_PUSHED_AUTHORIZATION_REQUEST_ERROR":               104,
+		"EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT":               105,
+		"EVENT_TYPE_USER_DISABLED_BY_PERMANENT_LOCKOUT_ERROR":         106,
+		"EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT":               107,
+		"EVENT_TYPE_USER_DISABLED_BY_TEMPORARY_LOCKOUT_ERROR":         108,
+		"EVENT_TYPE_OAUTH2_EXTENSION_GRANT":                           109,
+		"EVENT_TYPE_OAUTH2_EXTENSION_GRANT_ERROR":                     110,
+		"EVENT_TYPE_FEDERATED_IDENTITY_OVERRIDE_LINK":                 111,
+		"EVENT_TYPE_FEDERATED_IDENTITY_OVERRIDE_LINK_ERROR":           112,
+		"EVENT_TYPE_UPDATE_CREDENTIAL":                                113,
+		"EVENT_TYPE_UPDATE_CREDENTIAL_ERROR":                          114,
+		"EVENT_TYPE_REMOVE_CREDENTIAL":                                115,
+		"EVENT_TYPE_REMOVE_CREDENTIAL_ERROR":                          116,
+		"EVENT_TYPE_INVITE_ORG":                                       117,
+		"EVENT_TYPE_INVITE_ORG_ERROR":                                 118,
+		"EVENT_TYPE_USER_SESSION_DELETED":                             119,
+		"EVENT_TYPE_USER_SESSION_DELETED_ERROR":                       120,
+		"EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST":                    121,
+		"EVENT_TYPE_VERIFIABLE_CREDENTIAL_REQUEST_ERROR":              122,
+		"EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT":       123,
+		"EVENT_TYPE_VERIFIABLE_CREDENTIAL_PRE_AUTHORIZED_GRANT_ERROR": 124,
 	}
 )
 
@@ -1078,7 +1094,7 @@ var file_keycloak_event_v1_event_proto_rawDesc = []byte{
 	0x10, 0x02, 0x12, 0x19, 0x0a, 0x15, 0x4f, 0x50, 0x45, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f,
 	0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x03, 0x12, 0x19, 0x0a,
 	0x15, 0x4f, 0x50, 0x45, 0x52, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x04, 0x2a, 0xbf, 0x24, 0x0a, 0x09, 0x45, 0x76, 0x65,
+	0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x04, 0x2a, 0x9d, 0x26, 0x0a, 0x09, 0x45, 0x76, 0x65,
 	0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x12, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f,
 	0x54, 0x59, 0x50, 0x45, 0x5f, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12, 0x14,
 	0x0a, 0x10, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4c, 0x4f, 0x47,
@@ -1370,21 +1386,35 @@ var file_keycloak_event_v1_event_proto_rawDesc = []byte{
 	0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x77, 0x12,
 	0x29, 0x0a, 0x25, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x53,
 	0x45, 0x52, 0x5f, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54,
-	0x45, 0x44, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x78, 0x42, 0xcf, 0x01, 0x0a, 0x15, 0x63,
-	0x6f, 0x6d, 0x2e, 0x6b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2e, 0x65, 0x76, 0x65, 0x6e,
-	0x74, 0x2e, 0x76, 0x31, 0x42, 0x0a, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f,
-	0x50, 0x01, 0x5a, 0x44, 0x6b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2d, 0x65, 0x76, 0x65,
-	0x6e, 0x74, 0x73, 0x2d, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2f, 0x69, 0x6e, 0x74, 0x65,
-	0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x73, 0x70, 0x65, 0x63, 0x73, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6b,
-	0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2f, 0x76, 0x31,
-	0x3b, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x76, 0x31, 0xa2, 0x02, 0x03, 0x4b, 0x45, 0x58, 0xaa, 0x02,
-	0x11, 0x4b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e,
-	0x56, 0x31, 0xca, 0x02, 0x11, 0x4b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x5c, 0x45, 0x76,
-	0x65, 0x6e, 0x74, 0x5c, 0x56, 0x31, 0xe2, 0x02, 0x1d, 0x4b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61,
-	0x6b, 0x5c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x5c, 0x56, 0x31, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65,
-	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x13, 0x4b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61,
-	0x6b, 0x3a, 0x3a, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x3a, 0x3a, 0x56, 0x31, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x45, 0x44, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x78, 0x12, 0x2c, 0x0a, 0x28, 0x45, 0x56,
+	0x45, 0x4e, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x56, 0x45, 0x52, 0x49, 0x46, 0x49, 0x41,
+	0x42, 0x4c, 0x45, 0x5f, 0x43, 0x52, 0x45, 0x44, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c, 0x5f, 0x52,
+	0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x79, 0x12, 0x32, 0x0a, 0x2e, 0x45, 0x56, 0x45, 0x4e,
+	0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x56, 0x45, 0x52, 0x49, 0x46, 0x49, 0x41, 0x42, 0x4c,
+	0x45, 0x5f, 0x43, 0x52, 0x45, 0x44, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c, 0x5f, 0x52, 0x45, 0x51,
+	0x55, 0x45, 0x53, 0x54, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x7a, 0x12, 0x39, 0x0a, 0x35,
+	0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x56, 0x45, 0x52, 0x49, 0x46,
+	0x49, 0x41, 0x42, 0x4c, 0x45, 0x5f, 0x43, 0x52, 0x45, 0x44, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c,
+	0x5f, 0x50, 0x52, 0x45, 0x5f, 0x41, 0x55, 0x54, 0x48, 0x4f, 0x52, 0x49, 0x5a, 0x45, 0x44, 0x5f,
+	0x47, 0x52, 0x41, 0x4e, 0x54, 0x10, 0x7b, 0x12, 0x3f, 0x0a, 0x3b, 0x45, 0x56, 0x45, 0x4e, 0x54,
+	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x56, 0x45, 0x52, 0x49, 0x46, 0x49, 0x41, 0x42, 0x4c, 0x45,
+	0x5f, 0x43, 0x52, 0x45, 0x44, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c, 0x5f, 0x50, 0x52, 0x45, 0x5f,
+	0x41, 0x55, 0x54, 0x48, 0x4f, 0x52, 0x49, 0x5a, 0x45, 0x44, 0x5f, 0x47, 0x52, 0x41, 0x4e, 0x54,
+	0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x7c, 0x42, 0xcf, 0x01, 0x0a, 0x15, 0x63, 0x6f, 0x6d,
+	0x2e, 0x6b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2e,
+	0x76, 0x31, 0x42, 0x0a, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01,
+	0x5a, 0x44, 0x6b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2d, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x2d, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e,
+	0x61, 0x6c, 0x2f, 0x73, 0x70, 0x65, 0x63, 0x73, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6b, 0x65, 0x79,
+	0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2f, 0x76, 0x31, 0x3b, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x76, 0x31, 0xa2, 0x02, 0x03, 0x4b, 0x45, 0x58, 0xaa, 0x02, 0x11, 0x4b,
+	0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x56, 0x31,
+	0xca, 0x02, 0x11, 0x4b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x5c, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x5c, 0x56, 0x31, 0xe2, 0x02, 0x1d, 0x4b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x5c,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x5c, 0x56, 0x31, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x13, 0x4b, 0x65, 0x79, 0x63, 0x6c, 0x6f, 0x61, 0x6b, 0x3a,
+	0x3a, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x3a, 0x3a, 0x56, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -478,6 +478,28 @@ func (m *CreateRequest) validate(all bool) error {
 
 	}
 
+	if utf8.RuneCountInString(m.GetTypeName()) > 128 {
+		err := CreateRequestValidationError{
+			field:  "TypeName",
+			reason: "value length must be at most 128 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if !_CreateRequest_TypeName_Pattern.MatchString(m.GetTypeName()) {
+		err := CreateRequestValidationError{
+			field:  "TypeName",
+			reason: "value does not match regex pattern \"^[A-Z0-9_]*$\"",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
 	if len(errors) > 0 {
 		return CreateRequestMultiError(errors)
 	}
@@ -564,6 +586,8 @@ var _ interface {
 	ErrorName() string
 } = CreateRequestValidationError{}
 
+var _CreateRequest_TypeName_Pattern = regexp.MustCompile("^[A-Z0-9_]*$")
+
 // Validate checks the field values on CreateResponse with the rules defined in
 // the proto definition for this message. If any rules are violated, the first
 // error encountered is returned, or nil if there are no violations.
@@ -0,0 +1,128 @@
+package tarantool
+
+import (
+	"context"
+	"time"
+
+	"github.com/tarantool/go-tarantool/queue"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/breaker"
+)
+
+const takeTimeout = 1 * time.Second
+
+// BatchEvent забирает из очереди до size задач в пределах окна window и отправляет их одной пачкой.
+// Задачи подтверждаются только после успешной отправки всей пачки, иначе возвращаются в очередь.
+// Автомат breaker учитывает пачку как один вызов.
+type BatchEvent[T internal.Event | internal.AdminEvent] struct {
+	queue       queue.Queue
+	batchSender internal.BatchSender[T]
+	breaker     *breaker.Breaker
+	size        int
+	window      time.Duration
+	logger      *zap.Logger
+}
+
+func NewBatchEvent[T internal.Event | internal.AdminEvent](
+	queue queue.Queue,
+	batchSender internal.BatchSender[T],
+	breaker *breaker.Breaker,
+	size int,
+	window time.Duration,
+	logger *zap.Logger,
+) *BatchEvent[T] {
+	return &BatchEvent[T]{
+		queue:       queue,
+		batchSender: batchSender,
+		breaker:     breaker,
+		size:        size,
+		window:      window,
+		logger:      logger,
+	}
+}
+
+func (e *BatchEvent[T]) Push(event *T) error {
+	return put(e.queue, event, e.logger)
+}
+
+func (e *BatchEvent[T]) Process(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			e.logger.Info("Queue worker has been shutdown")
+			return
+		default:
+		}
+
+		token, ok := breakerAllow(ctx, e.breaker)
+		if !ok {
+			continue
+		}
+
+		tasks, events := e.take(ctx)
+		if len(tasks) == 0 {
+			breakerCancel(e.breaker, token)
+			continue
+		}
+
+		err := e.batchSender.SendBatch(events)
+		breakerDone(e.breaker, token, err)
+		if err != nil {
+			e.logger.Error("can't send batch", zap.Int("size", len(events)), zap.Error(err))
+			for _, task := range tasks {
+				release(task, e.logger)
+			}
+
+			continue
+		}
+
+		for _, task := range tasks {
+			ack(task, e.logger)
+		}
+	}
+}
+
+// take собирает пачку: окно отсчитывается от первой полученной задачи
+func (e *BatchEvent[T]) take(ctx context.Context) ([]*queue.Task, []*T) {
+	var (
+		tasks    []*queue.Task
+		events   []*T
+		deadline time.Time
+	)
+
+	for len(tasks) < e.size {
+		timeout := takeTimeout
+		if len(tasks) > 0 {
+			timeout = min(time.Until(deadline), takeTimeout)
+			if timeout <= 0 {
+				break
+			}
+		}
+
+		var event *T
+		task, err := e.queue.TakeTypedTimeout(timeout, &event)
+		if err != nil {
+			e.logger.Error("can't take task", zap.Error(err))
+			if len(tasks) == 0 {
+				time.Sleep(1 * time.Second)
+			}
+			break
+		}
+
+		if task == nil {
+			if len(tasks) == 0 || ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		if len(tasks) == 0 {
+			deadline = time.Now().Add(e.window)
+		}
+		tasks = append(tasks, task)
+		events = append(events, event)
+	}
+
+	return tasks, events
+}
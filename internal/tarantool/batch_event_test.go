@@ -0,0 +1,98 @@
+package tarantool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/breaker"
+	internalmock "keycloak-events-adapter/internal/mock"
+	"keycloak-events-adapter/internal/tarantool/mock"
+)
+
+func TestBatchEvent_Process(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(queueMock *mock.MockQueue)
+	}{
+		{
+			name: "take error",
+			prepare: func(queueMock *mock.MockQueue) {
+				var eventPtr *internal.Event
+				queueMock.EXPECT().TakeTypedTimeout(1*time.Second, &eventPtr).Return(nil, errors.New("take error")).Times(1)
+			},
+		},
+		{
+			name: "nil task",
+			prepare: func(queueMock *mock.MockQueue) {
+				var eventPtr *internal.Event
+				queueMock.EXPECT().TakeTypedTimeout(1*time.Second, &eventPtr).Return(nil, nil).AnyTimes()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			queueMock := mock.NewMockQueue(ctrl)
+			batchSender := internalmock.NewMockBatchSender[internal.Event](ctrl)
+			logger := zap.NewNop()
+
+			tt.prepare(queueMock)
+			batchSender.EXPECT().SendBatch(gomock.Any()).Times(0)
+
+			event := NewBatchEvent[internal.Event](queueMock, batchSender, nil, 100, time.Second, logger)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				event.Process(ctx)
+			}()
+
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+			<-done
+		})
+	}
+}
+
+func TestBatchEvent_ProcessOpenBreaker(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	queueMock := mock.NewMockQueue(ctrl)
+	batchSender := internalmock.NewMockBatchSender[internal.Event](ctrl)
+	logger := zap.NewNop()
+
+	b := breaker.New(1, time.Minute)
+	token, err := b.Allow()
+	require.NoError(t, err)
+	b.Done(token, false)
+
+	queueMock.EXPECT().TakeTypedTimeout(gomock.Any(), gomock.Any()).Times(0)
+	batchSender.EXPECT().SendBatch(gomock.Any()).Times(0)
+
+	event := NewBatchEvent[internal.Event](queueMock, batchSender, b, 100, time.Second, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		event.Process(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+}
@@ -0,0 +1,186 @@
+package tarantool
+
+//go:generate mockgen -destination=mock/caller.go -package=mock -source=detect.go Caller
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/detect"
+)
+
+const AlertsQueueName = "alerts"
+
+// Хранимые функции детекторов, объявлены в tarantool/init.lua
+const (
+	windowAddFunc     = "detect_window_add"
+	counterAddFunc    = "detect_counter_add"
+	alertRaiseFunc    = "detect_alert_raise"
+	alertWithdrawFunc = "detect_alert_withdraw"
+	alertsActiveFunc  = "detect_alerts_active"
+	historyAddFunc    = "detect_history_add"
+)
+
+// Caller вызывает хранимые функции, реализуется *tarantool.Connection
+type Caller interface {
+	Call17Typed(functionName string, args interface{}, result interface{}) error
+}
+
+// Windows скользящие окна детекторов в спейсах detect_windows и detect_counters, общие для реплик адаптера
+type Windows struct {
+	caller Caller
+}
+
+func NewWindows(caller Caller) *Windows {
+	return &Windows{caller: caller}
+}
+
+func (w *Windows) Add(key, member string, at time.Time, window time.Duration) (int, error) {
+	var result []int
+	err := w.caller.Call17Typed(windowAddFunc, []any{key, member, at.UnixMilli(), window.Milliseconds()}, &result)
+	if err != nil {
+		return 0, fmt.Errorf("call %s: %w", windowAddFunc, err)
+	}
+	if len(result) != 1 {
+		return 0, fmt.Errorf("call %s: unexpected result %v", windowAddFunc, result)
+	}
+
+	return result[0], nil
+}
+
+func (w *Windows) Increment(key string, at time.Time, window time.Duration) (int, error) {
+	var result []int
+	err := w.caller.Call17Typed(
+		counterAddFunc,
+		[]any{key, at.UnixMilli(), detect.WindowBucket(window), detect.WindowBuckets},
+		&result,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("call %s: %w", counterAddFunc, err)
+	}
+	if len(result) != 1 {
+		return 0, fmt.Errorf("call %s: unexpected result %v", counterAddFunc, result)
+	}
+
+	return result[0], nil
+}
+
+// Alerts активные тревоги в спейсе detect_alerts, тревога хранится в JSON
+type Alerts struct {
+	caller Caller
+}
+
+func NewAlerts(caller Caller) *Alerts {
+	return &Alerts{caller: caller}
+}
+
+func (a *Alerts) Raise(alert *internal.Alert) (bool, error) {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return false, fmt.Errorf("marshal alert: %w", err)
+	}
+
+	var result []any
+	err = a.caller.Call17Typed(
+		alertRaiseFunc,
+		[]any{detect.AlertKey(alert), string(data), alert.LastSeen.UnixMilli(), alert.ExpiresAt.UnixMilli()},
+		&result,
+	)
+	if err != nil {
+		return false, fmt.Errorf("call %s: %w", alertRaiseFunc, err)
+	}
+
+	if len(result) != 2 {
+		return false, fmt.Errorf("call %s: unexpected result %v", alertRaiseFunc, result)
+	}
+	isNew, ok := result[0].(bool)
+	stored, okStored := result[1].(string)
+	if !ok || !okStored {
+		return false, fmt.Errorf("call %s: unexpected result %v", alertRaiseFunc, result)
+	}
+
+	// активная тревога сохранила свои id и first_seen
+	err = json.Unmarshal([]byte(stored), alert)
+	if err != nil {
+		return false, fmt.Errorf("unmarshal alert: %w", err)
+	}
+
+	return isNew, nil
+}
+
+func (a *Alerts) Withdraw(alert *internal.Alert) error {
+	var result []any
+	err := a.caller.Call17Typed(alertWithdrawFunc, []any{detect.AlertKey(alert), alert.Id.String()}, &result)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", alertWithdrawFunc, err)
+	}
+
+	return nil
+}
+
+func (a *Alerts) Active(now time.Time) ([]internal.Alert, error) {
+	var result [][]string
+	err := a.caller.Call17Typed(alertsActiveFunc, []any{now.UnixMilli()}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", alertsActiveFunc, err)
+	}
+	if len(result) != 1 {
+		return nil, fmt.Errorf("call %s: unexpected result %v", alertsActiveFunc, result)
+	}
+
+	alerts := make([]internal.Alert, 0, len(result[0]))
+	for _, data := range result[0] {
+		var alert internal.Alert
+		err = json.Unmarshal([]byte(data), &alert)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	detect.SortAlerts(alerts)
+
+	return alerts, nil
+}
+
+// History истории входов пользователей в спейсе detect_history, вход хранится в JSON
+type History struct {
+	caller Caller
+}
+
+func NewHistory(caller Caller) *History {
+	return &History{caller: caller}
+}
+
+func (h *History) Add(key string, login detect.Login, size int, retention time.Duration) ([]detect.Login, error) {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return nil, fmt.Errorf("marshal login: %w", err)
+	}
+
+	var result [][]string
+	err = h.caller.Call17Typed(
+		historyAddFunc,
+		[]any{key, login.EventId, login.Time.UnixMilli(), string(data), size, retention.Milliseconds()},
+		&result,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", historyAddFunc, err)
+	}
+	if len(result) != 1 {
+		return nil, fmt.Errorf("call %s: unexpected result %v", historyAddFunc, result)
+	}
+
+	logins := make([]detect.Login, 0, len(result[0]))
+	for _, data := range result[0] {
+		var l detect.Login
+		err = json.Unmarshal([]byte(data), &l)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal login: %w", err)
+		}
+		logins = append(logins, l)
+	}
+
+	return logins, nil
+}
@@ -0,0 +1,189 @@
+package tarantool
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/detect"
+	"keycloak-events-adapter/internal/tarantool/mock"
+)
+
+func TestWindows_Add(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	caller := mock.NewMockCaller(ctrl)
+	at := time.UnixMilli(1705312800000)
+
+	caller.EXPECT().Call17Typed(windowAddFunc, []any{"user_failures/master/john", "event", int64(1705312800000), int64(300000)}, gomock.Any()).
+		DoAndReturn(func(_ string, _ any, result any) error {
+			*result.(*[]int) = []int{3}
+			return nil
+		})
+	count, err := NewWindows(caller).Add("user_failures/master/john", "event", at, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	caller.EXPECT().Call17Typed(windowAddFunc, gomock.Any(), gomock.Any()).Return(errors.New("connection error"))
+	_, err = NewWindows(caller).Add("user_failures/master/john", "event", at, 5*time.Minute)
+	assert.Error(t, err)
+}
+
+func TestWindows_Increment(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	caller := mock.NewMockCaller(ctrl)
+	at := time.UnixMilli(1705312800000)
+
+	caller.EXPECT().Call17Typed(counterAddFunc, []any{"client_failure_ratio/all/master/account", int64(1705312800000), int64(30000), 10}, gomock.Any()).
+		DoAndReturn(func(_ string, _ any, result any) error {
+			*result.(*[]int) = []int{42}
+			return nil
+		})
+	count, err := NewWindows(caller).Increment("client_failure_ratio/all/master/account", at, 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 42, count)
+
+	caller.EXPECT().Call17Typed(counterAddFunc, gomock.Any(), gomock.Any()).Return(nil)
+	_, err = NewWindows(caller).Increment("client_failure_ratio/all/master/account", at, 5*time.Minute)
+	assert.Error(t, err)
+}
+
+func TestAlerts_Raise(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	caller := mock.NewMockCaller(ctrl)
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	active := internal.Alert{Id: uuid.New(), Rule: "user_failures", Key: "master/john", FirstSeen: at.Add(-time.Minute)}
+	alert := &internal.Alert{
+		Id:        uuid.New(),
+		Rule:      "user_failures",
+		Key:       "master/john",
+		Value:     5,
+		FirstSeen: at,
+		LastSeen:  at,
+		ExpiresAt: at.Add(time.Hour),
+	}
+
+	caller.EXPECT().Call17Typed(alertRaiseFunc, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, args any, result any) error {
+			a := args.([]any)
+			assert.Equal(t, "user_failures/master/john", a[0])
+			assert.Equal(t, at.UnixMilli(), a[2])
+			assert.Equal(t, at.Add(time.Hour).UnixMilli(), a[3])
+
+			// хранилище возвращает тревогу с id и first_seen активной
+			var stored internal.Alert
+			require.NoError(t, json.Unmarshal([]byte(a[1].(string)), &stored))
+			stored.Id, stored.FirstSeen = active.Id, active.FirstSeen
+			data, err := json.Marshal(stored)
+			require.NoError(t, err)
+
+			*result.(*[]any) = []any{false, string(data)}
+			return nil
+		})
+
+	isNew, err := NewAlerts(caller).Raise(alert)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, active.Id, alert.Id)
+	assert.Equal(t, active.FirstSeen, alert.FirstSeen)
+	assert.Equal(t, float64(5), alert.Value)
+
+	caller.EXPECT().Call17Typed(alertRaiseFunc, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, _ any, result any) error {
+			*result.(*[]any) = []any{"unexpected"}
+			return nil
+		})
+	_, err = NewAlerts(caller).Raise(alert)
+	assert.Error(t, err)
+}
+
+func TestAlerts_Withdraw(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	caller := mock.NewMockCaller(ctrl)
+	alert := &internal.Alert{Id: uuid.New(), Rule: "user_failures", Key: "master/john"}
+
+	caller.EXPECT().Call17Typed(alertWithdrawFunc, []any{"user_failures/master/john", alert.Id.String()}, gomock.Any())
+	require.NoError(t, NewAlerts(caller).Withdraw(alert))
+
+	caller.EXPECT().Call17Typed(alertWithdrawFunc, gomock.Any(), gomock.Any()).Return(errors.New("connection error"))
+	assert.Error(t, NewAlerts(caller).Withdraw(alert))
+}
+
+func TestAlerts_Active(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	caller := mock.NewMockCaller(ctrl)
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	caller.EXPECT().Call17Typed(alertsActiveFunc, []any{now.UnixMilli()}, gomock.Any()).
+		DoAndReturn(func(_ string, _ any, result any) error {
+			*result.(*[][]string) = [][]string{{
+				`{"rule":"ip_users","key":"10.0.0.1","last_seen":"2024-01-15T09:50:00Z"}`,
+				`{"rule":"user_failures","key":"master/john","last_seen":"2024-01-15T09:55:00Z"}`,
+			}}
+			return nil
+		})
+
+	alerts, err := NewAlerts(caller).Active(now)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "master/john", alerts[0].Key)
+	assert.Equal(t, "10.0.0.1", alerts[1].Key)
+}
+
+func TestHistory_Add(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	caller := mock.NewMockCaller(ctrl)
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	login := detect.Login{EventId: "event", Time: at, IpAddress: "203.0.113.7", CountryCode: "DE"}
+
+	caller.EXPECT().Call17Typed(historyAddFunc, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, args any, result any) error {
+			a := args.([]any)
+			assert.Equal(t, []any{"master/john", "event", at.UnixMilli()}, a[:3])
+			assert.Equal(t, []any{50, int64(86400000)}, a[4:])
+
+			var stored detect.Login
+			require.NoError(t, json.Unmarshal([]byte(a[3].(string)), &stored))
+			assert.Equal(t, login, stored)
+
+			*result.(*[][]string) = [][]string{{
+				`{"event_id":"second","time":"2024-01-15T09:00:00Z","country_code":"DE","location":{"latitude":52.52,"longitude":13.4}}`,
+				`{"event_id":"first","time":"2024-01-14T09:00:00Z","client_id":"account"}`,
+			}}
+			return nil
+		})
+
+	previous, err := NewHistory(caller).Add("master/john", login, 50, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []detect.Login{
+		{
+			EventId:     "second",
+			Time:        at.Add(-time.Hour),
+			CountryCode: "DE",
+			Location:    &detect.Location{Latitude: 52.52, Longitude: 13.4},
+		},
+		{EventId: "first", Time: at.Add(-25 * time.Hour), ClientId: "account"},
+	}, previous)
+
+	caller.EXPECT().Call17Typed(historyAddFunc, gomock.Any(), gomock.Any()).Return(errors.New("connection error"))
+	_, err = NewHistory(caller).Add("master/john", login, 50, 24*time.Hour)
+	assert.Error(t, err)
+}
@@ -8,44 +8,44 @@ import (
 	"github.com/tarantool/go-tarantool/queue"
 	"go.uber.org/zap"
 	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/breaker"
 	"time"
 )
 
 const EventsQueueName = "events"
 const AdminEventsQueueName = "admin_events"
 
+// breakerPoll период проверки автомата, пока пробную задачу отправляет другой обработчик
+const breakerPoll = 100 * time.Millisecond
+
+// Event забирает задачи из очереди по одной. Если задан автомат (breaker может быть nil),
+// то пока цепь разомкнута, задачи не забираются, а в полуоткрытом состоянии отправляется одна пробная задача.
 type Event[T internal.Event | internal.AdminEvent] struct {
 	queue       queue.Queue
 	eventSender internal.EventSender[T]
+	breaker     *breaker.Breaker
 	logger      *zap.Logger
 }
 
 func NewEvent[T internal.Event | internal.AdminEvent](
 	queue queue.Queue,
 	eventSender internal.EventSender[T],
+	breaker *breaker.Breaker,
 	logger *zap.Logger,
 ) *Event[T] {
 	return &Event[T]{
 		queue:       queue,
 		eventSender: eventSender,
+		breaker:     breaker,
 		logger:      logger,
 	}
 }
 
 func (e *Event[T]) Push(event *T) error {
-	_, err := e.queue.PutWithOpts(event, queue.Opts{
-		Ttl: 4 * time.Hour,
-	})
-	if err != nil {
-		e.logger.Error("failed to push", zap.Error(err))
-		return errors.New("can't put to queue")
-	}
-
-	return nil
+	return put(e.queue, event, e.logger)
 }
 
 func (e *Event[T]) Process(ctx context.Context) {
-	var event *T
 	for {
 		select {
 		case <-ctx.Done():
@@ -54,37 +54,108 @@ func (e *Event[T]) Process(ctx context.Context) {
 		default:
 		}
 
+		token, ok := breakerAllow(ctx, e.breaker)
+		if !ok {
+			continue
+		}
+
+		// событие декодируется в новый объект: декодер дополняет существующие карты, и поля
+		// предыдущей задачи попали бы в следующую
+		var event *T
 		task, err := e.queue.TakeTypedTimeout(1*time.Second, &event)
 		if err != nil {
+			breakerCancel(e.breaker, token)
 			e.logger.Error("can't take task", zap.Error(err))
 			time.Sleep(1 * time.Second)
 			continue
 		}
 		if task == nil {
+			breakerCancel(e.breaker, token)
 			continue
 		}
 
 		err = e.eventSender.Send(event)
+		breakerDone(e.breaker, token, err)
+		if errors.Is(err, internal.ErrRejected) {
+			// повторная отправка завершится тем же отказом, задача удаляется из очереди
+			e.logger.Error("event is rejected by sink, dropping", zap.Error(err))
+			ack(task, e.logger)
+
+			continue
+		}
 		if err != nil {
 			e.logger.Error("can't send event", zap.Error(err))
-			err = task.ReleaseCfg(queue.Opts{
-				Delay: 10 * time.Second,
-			})
-			if err != nil {
-				e.logger.Error("can't release task", zap.Error(err))
-			}
+			release(task, e.logger)
 
 			continue
 		}
 
-		err = task.Ack()
-		if err != nil {
-			e.logger.Error("can't release task, trying to delete", zap.Error(err))
+		ack(task, e.logger)
+	}
+}
+
+// breakerAllow проверяет автомат перед взятием задачи, при разомкнутой цепи ждет и возвращает false
+func breakerAllow(ctx context.Context, b *breaker.Breaker) (breaker.Token, bool) {
+	if b == nil {
+		return 0, true
+	}
+	token, err := b.Allow()
+	if err == nil {
+		return token, true
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(min(max(b.Wait(), breakerPoll), takeTimeout)):
+	}
+
+	return 0, false
+}
 
-			err = task.Delete()
-			if err != nil {
-				e.logger.Error("can't delete task", zap.Error(err))
-			}
+// breakerCancel возвращает разрешение автомату, если задача не была взята
+func breakerCancel(b *breaker.Breaker, token breaker.Token) {
+	if b != nil {
+		b.Cancel(token)
+	}
+}
+
+// breakerDone учитывает только ошибки доступности приемника: отказ принять событие
+// или ошибка конвейера означают, что приемник ответил
+func breakerDone(b *breaker.Breaker, token breaker.Token, err error) {
+	if b != nil {
+		b.Done(token, !errors.Is(err, internal.ErrUnavailable))
+	}
+}
+
+func put[T internal.Event | internal.AdminEvent](q queue.Queue, event *T, logger *zap.Logger) error {
+	_, err := q.PutWithOpts(event, queue.Opts{
+		Ttl: 4 * time.Hour,
+	})
+	if err != nil {
+		logger.Error("failed to push", zap.Error(err))
+		return errors.New("can't put to queue")
+	}
+
+	return nil
+}
+
+func release(task *queue.Task, logger *zap.Logger) {
+	err := task.ReleaseCfg(queue.Opts{
+		Delay: 10 * time.Second,
+	})
+	if err != nil {
+		logger.Error("can't release task", zap.Error(err))
+	}
+}
+
+func ack(task *queue.Task, logger *zap.Logger) {
+	err := task.Ack()
+	if err != nil {
+		logger.Error("can't release task, trying to delete", zap.Error(err))
+
+		err = task.Delete()
+		if err != nil {
+			logger.Error("can't delete task", zap.Error(err))
 		}
 	}
 }
@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
 	"github.com/tarantool/go-tarantool/queue"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
+	"gopkg.in/vmihailenco/msgpack.v2"
 	"keycloak-events-adapter/internal"
+	"keycloak-events-adapter/internal/breaker"
 	"keycloak-events-adapter/internal/tarantool/mock"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -51,7 +58,7 @@ func TestEvent_Process(t *testing.T) {
 
 			tt.prepare(queueMock)
 
-			event := NewEvent[internal.Event](queueMock, internal.NewDummy[internal.Event](logger), logger)
+			event := NewEvent[internal.Event](queueMock, internal.NewDummy[internal.Event](logger), nil, logger)
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
@@ -94,7 +101,7 @@ func TestEvent_Process(t *testing.T) {
 
 			tt.prepare(queueMock)
 
-			event := NewEvent[internal.AdminEvent](queueMock, internal.NewDummy[internal.AdminEvent](logger), logger)
+			event := NewEvent[internal.AdminEvent](queueMock, internal.NewDummy[internal.AdminEvent](logger), nil, logger)
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
@@ -111,6 +118,81 @@ func TestEvent_Process(t *testing.T) {
 	}
 }
 
+func TestEvent_ProcessBreaker(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		takes   int
+	}{
+		{name: "open breaker pauses taking", timeout: time.Minute, takes: 0},
+		{name: "half-open breaker takes probe", timeout: 0, takes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			queueMock := mock.NewMockQueue(ctrl)
+			logger := zap.NewNop()
+
+			b := breaker.New(1, tt.timeout)
+			token, err := b.Allow()
+			require.NoError(t, err)
+			b.Done(token, false)
+
+			var eventPtr *internal.Event
+			call := queueMock.EXPECT().TakeTypedTimeout(1*time.Second, &eventPtr).Return(nil, nil)
+			if tt.takes == 0 {
+				call.Times(0)
+			} else {
+				call.MinTimes(tt.takes)
+			}
+
+			event := NewEvent[internal.Event](queueMock, internal.NewDummy[internal.Event](logger), b, logger)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					event.Process(ctx)
+				}()
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+			wg.Wait()
+		})
+	}
+}
+
+func TestBreakerDone(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want breaker.State
+	}{
+		{name: "success", want: breaker.StateClosed},
+		{name: "unavailable sink opens breaker", err: internal.Unavailable(errors.New("connection refused")), want: breaker.StateOpen},
+		{name: "rejected event keeps breaker closed", err: errors.New("invalid event"), want: breaker.StateClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := breaker.New(1, time.Minute)
+			token, err := b.Allow()
+			require.NoError(t, err)
+
+			breakerDone(b, token, tt.err)
+			require.Equal(t, tt.want, b.State())
+		})
+	}
+}
+
 func TestEvent_Push(t *testing.T) {
 	id := uuid.New()
 	now := time.Now()
@@ -298,7 +380,7 @@ func TestEvent_Push(t *testing.T) {
 			eventSender := internal.NewDummy[internal.Event](logger)
 
 			tt.prepare(queueMock)
-			eventStorage := NewEvent[internal.Event](queueMock, eventSender, logger)
+			eventStorage := NewEvent[internal.Event](queueMock, eventSender, nil, logger)
 			if err := eventStorage.Push(tt.args.event); (err != nil) != tt.wantErr {
 				t.Errorf("Push() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -316,10 +398,146 @@ func TestEvent_Push(t *testing.T) {
 			eventSender := internal.NewDummy[internal.AdminEvent](logger)
 
 			tt.prepare(queueMock)
-			eventStorage := NewEvent[internal.AdminEvent](queueMock, eventSender, logger)
+			eventStorage := NewEvent[internal.AdminEvent](queueMock, eventSender, nil, logger)
 			if err := eventStorage.Push(tt.args.event); (err != nil) != tt.wantErr {
 				t.Errorf("Push() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+// fakeConn отдает задачи очереди, закодированные msgpack, остальные вызовы очереди выполняются без ответа
+type fakeConn struct {
+	tarantool.Connector
+	mu     sync.Mutex
+	events []*internal.Event
+	calls  []string
+}
+
+func (c *fakeConn) Call17Typed(functionName string, _ interface{}, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !strings.HasSuffix(functionName, ":take") {
+		c.calls = append(c.calls, functionName[strings.LastIndex(functionName, ":")+1:])
+		return nil
+	}
+	if len(c.events) == 0 {
+		return nil
+	}
+
+	event := c.events[0]
+	c.events = c.events[1:]
+	data, err := msgpack.Marshal([]any{[]any{uint64(len(c.events)), "t", event}})
+	if err != nil {
+		return err
+	}
+
+	return msgpack.Unmarshal(data, result)
+}
+
+func (c *fakeConn) ConfiguredTimeout() time.Duration {
+	return 0
+}
+
+// queueCalls возвращает вызванные команды очереди, кроме take
+func (c *fakeConn) queueCalls() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.calls)
+}
+
+type captureSender struct {
+	mu     sync.Mutex
+	events []*internal.Event
+	err    error
+}
+
+func (s *captureSender) Send(event *internal.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func (s *captureSender) sent() []*internal.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.events)
+}
+
+func TestEvent_ProcessDecodesEachTask(t *testing.T) {
+	t.Parallel()
+
+	conn := &fakeConn{events: []*internal.Event{
+		{
+			Id:         uuid.New(),
+			RealmName:  "first",
+			Details:    map[string]string{"first": "1"},
+			Enrichment: &internal.Enrichment{Tags: map[string]string{"tenant": "first"}},
+		},
+		{
+			Id:        uuid.New(),
+			RealmName: "second",
+			Details:   map[string]string{"second": "2"},
+		},
+	}}
+	sender := &captureSender{}
+	event := NewEvent[internal.Event](queue.New(conn, EventsQueueName), sender, nil, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		event.Process(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return len(sender.sent()) == 2 }, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	sent := sender.sent()
+	require.Equal(t, map[string]string{"first": "1"}, sent[0].Details)
+	require.Equal(t, "second", sent[1].RealmName)
+	require.Equal(t, map[string]string{"second": "2"}, sent[1].Details)
+	require.Nil(t, sent[1].Enrichment)
+}
+
+func TestEvent_ProcessSendError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "delivered event is acked", want: "ack"},
+		{name: "failed event is released", err: errors.New("sink error"), want: "release"},
+		{name: "rejected event is acked", err: internal.Rejected(errors.New("invalid event")), want: "ack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := &fakeConn{events: []*internal.Event{{Id: uuid.New(), RealmName: "master"}}}
+			sender := &captureSender{err: tt.err}
+			event := NewEvent[internal.Event](queue.New(conn, EventsQueueName), sender, nil, zap.NewNop())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				event.Process(ctx)
+			}()
+
+			require.Eventually(t, func() bool { return len(conn.queueCalls()) == 1 }, time.Second, 10*time.Millisecond)
+			cancel()
+			<-done
+
+			require.Equal(t, []string{tt.want}, conn.queueCalls())
+		})
+	}
+}
@@ -4,8 +4,222 @@ box.cfg {
     listen = 3301
 }
 
+local clock = require('clock')
+local fiber = require('fiber')
+local json = require('json')
+
 queue = require('queue')
 box.once("create_queue", function()
     queue.create_tube('events', 'fifottl', { if_not_exists = true })
     queue.create_tube('admin_events', 'fifottl', { if_not_exists = true })
 end)
+
+-- Детекторы: скользящие окна и активные тревоги, общие для реплик адаптера. Время в миллисекундах Unix.
+box.once("create_detect", function()
+    queue.create_tube('alerts', 'fifottl', { if_not_exists = true })
+
+    local windows = box.schema.space.create('detect_windows', {
+        if_not_exists = true,
+        format = {
+            { name = 'key', type = 'string' },
+            { name = 'member', type = 'string' },
+            { name = 'time', type = 'unsigned' },
+            { name = 'expires_at', type = 'unsigned' },
+        },
+    })
+    windows:create_index('primary', { parts = { 'key', 'member' }, if_not_exists = true })
+    windows:create_index('expires_at', { parts = { 'expires_at' }, unique = false, if_not_exists = true })
+
+    local alerts = box.schema.space.create('detect_alerts', {
+        if_not_exists = true,
+        format = {
+            { name = 'key', type = 'string' },
+            { name = 'alert', type = 'string' },
+            { name = 'expires_at', type = 'unsigned' },
+        },
+    })
+    alerts:create_index('primary', { parts = { 'key' }, if_not_exists = true })
+    alerts:create_index('expires_at', { parts = { 'expires_at' }, unique = false, if_not_exists = true })
+end)
+
+-- Индекс отметок окон по времени: устаревшие отметки удаляются без обхода всего окна.
+-- Счетчики окон по интервалам времени для правил, которым не нужны различные отметки.
+box.once("create_detect_counters", function()
+    box.space.detect_windows:create_index('time', { parts = { 'key', 'time' }, unique = false, if_not_exists = true })
+
+    local counters = box.schema.space.create('detect_counters', {
+        if_not_exists = true,
+        format = {
+            { name = 'key', type = 'string' },
+            { name = 'slot', type = 'unsigned' },
+            { name = 'count', type = 'unsigned' },
+            { name = 'expires_at', type = 'unsigned' },
+        },
+    })
+    counters:create_index('primary', { parts = { 'key', 'slot' }, if_not_exists = true })
+    counters:create_index('expires_at', { parts = { 'expires_at' }, unique = false, if_not_exists = true })
+end)
+
+-- История входов пользователей для детектора аномальных входов, вход хранится в JSON
+box.once("create_detect_history", function()
+    local history = box.schema.space.create('detect_history', {
+        if_not_exists = true,
+        format = {
+            { name = 'key', type = 'string' },
+            { name = 'event_id', type = 'string' },
+            { name = 'time', type = 'unsigned' },
+            { name = 'login', type = 'string' },
+            { name = 'expires_at', type = 'unsigned' },
+        },
+    })
+    history:create_index('primary', { parts = { 'key', 'event_id' }, if_not_exists = true })
+    history:create_index('time', { parts = { 'key', 'time' }, unique = false, if_not_exists = true })
+    history:create_index('expires_at', { parts = { 'expires_at' }, unique = false, if_not_exists = true })
+end)
+
+-- detect_window_add добавляет отметку member со временем at в окно key, удаляет отметки старше at - window
+-- и возвращает число различных отметок в окне
+function detect_window_add(key, member, at, window)
+    return box.atomic(function()
+        local space = box.space.detect_windows
+        local current = space:get({ key, member })
+        if current == nil or current.time < at then
+            space:replace({ key, member, at, at + window })
+        end
+
+        local stale = {}
+        for _, t in space.index.time:pairs({ key, math.max(at - window, 0) }, { iterator = 'LT' }) do
+            if t.key ~= key then
+                break
+            end
+            table.insert(stale, t.member)
+        end
+        for _, m in ipairs(stale) do
+            space:delete({ key, m })
+        end
+
+        return space.index.primary:count({ key })
+    end)
+end
+
+-- detect_counter_add увеличивает счетчик key в интервале времени at длиной bucket и возвращает сумму
+-- последних buckets интервалов
+function detect_counter_add(key, at, bucket, buckets)
+    return box.atomic(function()
+        local space = box.space.detect_counters
+        local slot = math.floor(at / bucket)
+        space:upsert({ key, slot, 1, (slot + buckets) * bucket }, { { '+', 3, 1 } })
+
+        local total = 0
+        for _, t in space.index.primary:pairs({ key, slot }, { iterator = 'LE' }) do
+            if t.key ~= key or t.slot <= slot - buckets then
+                break
+            end
+            total = total + t.count
+        end
+
+        return total
+    end)
+end
+
+-- detect_alert_raise сохраняет тревогу в JSON до expires_at. Активная тревога заменяется с сохранением
+-- id и first_seen. Возвращает признак новой тревоги и сохраненную тревогу.
+function detect_alert_raise(key, alert, last_seen, expires_at)
+    return box.atomic(function()
+        local space = box.space.detect_alerts
+        local current = space:get(key)
+        local is_new = current == nil or current.expires_at <= last_seen
+        if not is_new then
+            local data = json.decode(alert)
+            local active = json.decode(current.alert)
+            data.id = active.id
+            data.first_seen = active.first_seen
+            alert = json.encode(data)
+        end
+        space:replace({ key, alert, expires_at })
+
+        return is_new, alert
+    end)
+end
+
+-- detect_alert_withdraw удаляет тревогу key, если активна тревога с идентификатором id
+function detect_alert_withdraw(key, id)
+    return box.atomic(function()
+        local space = box.space.detect_alerts
+        local current = space:get(key)
+        if current ~= nil and json.decode(current.alert).id == id then
+            space:delete(key)
+        end
+    end)
+end
+
+-- detect_alerts_active возвращает тревоги в JSON, активные в момент now
+function detect_alerts_active(now)
+    local result = {}
+    for _, t in box.space.detect_alerts.index.expires_at:pairs({ now }, { iterator = 'GT' }) do
+        table.insert(result, t.alert)
+    end
+
+    return result
+end
+
+-- detect_history_add добавляет вход event_id в историю key и возвращает остальные входы истории в JSON,
+-- последние первыми. История хранит не больше size последних входов и не старше retention от последнего входа.
+function detect_history_add(key, event_id, at, login, size, retention)
+    return box.atomic(function()
+        local space = box.space.detect_history
+        if space:get({ key, event_id }) == nil then
+            space:replace({ key, event_id, at, login, at + retention })
+        end
+
+        local newest
+        local kept = 0
+        local stale = {}
+        local previous = {}
+        for _, t in space.index.time:pairs({ key }, { iterator = 'REQ' }) do
+            newest = newest or t.time
+            kept = kept + 1
+            if kept > size or t.time < newest - retention then
+                table.insert(stale, t.event_id)
+            elseif t.event_id ~= event_id then
+                table.insert(previous, t.login)
+            end
+        end
+        for _, id in ipairs(stale) do
+            space:delete({ key, id })
+        end
+
+        return previous
+    end)
+end
+
+-- detect_expire удаляет устаревшие отметки и счетчики окон, тревоги и входы
+local function detect_expire(space, key_of)
+    local now = math.floor(clock.time() * 1000)
+    local expired = {}
+    for _, t in space.index.expires_at:pairs({ now }, { iterator = 'LT' }) do
+        table.insert(expired, key_of(t))
+        if #expired >= 1000 then
+            break
+        end
+    end
+    for _, key in ipairs(expired) do
+        space:delete(key)
+    end
+end
+
+fiber.create(function()
+    fiber.name('detect_expire')
+    while true do
+        fiber.sleep(10)
+        local ok, err = pcall(function()
+            detect_expire(box.space.detect_windows, function(t) return { t.key, t.member } end)
+            detect_expire(box.space.detect_counters, function(t) return { t.key, t.slot } end)
+            detect_expire(box.space.detect_alerts, function(t) return { t.key } end)
+            detect_expire(box.space.detect_history, function(t) return { t.key, t.event_id } end)
+        end)
+        if not ok then
+            require('log').error('detect_expire: %s', err)
+        end
+    end
+end)